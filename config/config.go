@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 
@@ -20,6 +22,28 @@ type AppConfig struct {
 		Username string
 		Password string
 	}
+	Payment struct {
+		Provider        string
+		XenditSecretKey string
+		Window          time.Duration
+		SweepInterval   time.Duration
+	}
+	Fee struct {
+		ServicePercentage float64
+		TaxPercentage     float64
+	}
+	Mail struct {
+		Provider  string
+		Host      string
+		Port      string
+		Username  string
+		Password  string
+		From      string
+		OutboxDir string
+	}
+	Storage struct {
+		Dir string
+	}
 }
 
 var lock = &sync.Mutex{}
@@ -53,10 +77,82 @@ func initConfig() *AppConfig {
 	defaultConfig.Database.Port = os.Getenv("DB_PORT")
 	defaultConfig.Database.Username = os.Getenv("DB_USERNAME")
 	defaultConfig.Database.Password = os.Getenv("DB_PASSWORD")
+	defaultConfig.Payment.Provider = os.Getenv("PAYMENT_PROVIDER")
+	defaultConfig.Payment.XenditSecretKey = os.Getenv("XENDIT_SECRET_KEY")
+
+	paymentWindow, err := strconv.Atoi(os.Getenv("PAYMENT_WINDOW_MINUTES"))
+	if err != nil || paymentWindow <= 0 {
+		paymentWindow = 24 * 60
+	}
+	defaultConfig.Payment.Window = time.Duration(paymentWindow) * time.Minute
+
+	sweepInterval, err := strconv.Atoi(os.Getenv("EXPIRY_SWEEP_INTERVAL_SECONDS"))
+	if err != nil || sweepInterval <= 0 {
+		sweepInterval = 60
+	}
+	defaultConfig.Payment.SweepInterval = time.Duration(sweepInterval) * time.Second
+
+	serviceFee, err := strconv.ParseFloat(os.Getenv("SERVICE_FEE_PERCENTAGE"), 64)
+	if err != nil || serviceFee < 0 {
+		serviceFee = 0
+	}
+	defaultConfig.Fee.ServicePercentage = serviceFee
+
+	tax, err := strconv.ParseFloat(os.Getenv("TAX_PERCENTAGE"), 64)
+	if err != nil || tax < 0 {
+		tax = 0
+	}
+	defaultConfig.Fee.TaxPercentage = tax
 
 	constant.JWT_SECRET_KEY = os.Getenv("JWT_SECRET_KEY")
+
+	accessTokenTTL, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_MINUTES"))
+	if err == nil && accessTokenTTL > 0 {
+		constant.ACCESS_TOKEN_TTL = time.Duration(accessTokenTTL) * time.Minute
+	}
+
+	refreshTokenTTL, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_DAYS"))
+	if err == nil && refreshTokenTTL > 0 {
+		constant.REFRESH_TOKEN_TTL = time.Duration(refreshTokenTTL) * 24 * time.Hour
+	}
+
 	constant.XENDIT_CALLBACK_TOKEN = os.Getenv("XENDIT_CALLBACK_TOKEN")
 
+	bookingRequestWindow, err := strconv.Atoi(os.Getenv("BOOKING_REQUEST_WINDOW_HOURS"))
+	if err == nil && bookingRequestWindow > 0 {
+		constant.BOOKING_REQUEST_WINDOW = time.Duration(bookingRequestWindow) * time.Hour
+	}
+
+	defaultConfig.Mail.Provider = os.Getenv("MAIL_PROVIDER")
+	defaultConfig.Mail.Host = os.Getenv("SMTP_HOST")
+	defaultConfig.Mail.Port = os.Getenv("SMTP_PORT")
+	defaultConfig.Mail.Username = os.Getenv("SMTP_USERNAME")
+	defaultConfig.Mail.Password = os.Getenv("SMTP_PASSWORD")
+	defaultConfig.Mail.From = os.Getenv("MAIL_FROM")
+	defaultConfig.Mail.OutboxDir = os.Getenv("MAIL_OUTBOX_DIR")
+
+	verificationTTL, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_HOURS"))
+	if err == nil && verificationTTL > 0 {
+		constant.EMAIL_VERIFICATION_TTL = time.Duration(verificationTTL) * time.Hour
+	}
+
+	passwordResetTTL, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_MINUTES"))
+	if err == nil && passwordResetTTL > 0 {
+		constant.PASSWORD_RESET_TTL = time.Duration(passwordResetTTL) * time.Minute
+	}
+
+	constant.APP_URL = os.Getenv("APP_URL")
+
+	defaultConfig.Storage.Dir = os.Getenv("STORAGE_DIR")
+	if defaultConfig.Storage.Dir == "" {
+		defaultConfig.Storage.Dir = "uploads"
+	}
+
+	photoMaxSize, err := strconv.Atoi(os.Getenv("PHOTO_MAX_SIZE_MB"))
+	if err == nil && photoMaxSize > 0 {
+		constant.PHOTO_MAX_SIZE = int64(photoMaxSize) << 20
+	}
+
 	Mode = os.Getenv("MODE")
 
 	return &defaultConfig
@@ -1,4 +1,20 @@
 package constant
 
+import "time"
+
 var JWT_SECRET_KEY string
-var XENDIT_CALLBACK_TOKEN string
\ No newline at end of file
+var XENDIT_CALLBACK_TOKEN string
+
+var ACCESS_TOKEN_TTL = 15 * time.Minute
+var REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+var EMAIL_VERIFICATION_TTL = 24 * time.Hour
+var PASSWORD_RESET_TTL = time.Hour
+
+var APP_URL string
+
+var PHOTO_MAX_SIZE int64 = 5 << 20
+var PHOTO_MAX_FILES = 10
+
+var NOTIFICATION_KEEP_ALIVE = 30 * time.Second
+var REVIEW_WINDOW = 14 * 24 * time.Hour
+var BOOKING_REQUEST_WINDOW = 24 * time.Hour
@@ -0,0 +1,5 @@
+package constant
+
+const GUEST_ROLE = "guest"
+const HOST_ROLE = "host"
+const ADMIN_ROLE = "admin"
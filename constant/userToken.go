@@ -0,0 +1,4 @@
+package constant
+
+const EMAIL_VERIFICATION_TOKEN = "email_verification"
+const PASSWORD_RESET_TOKEN = "password_reset"
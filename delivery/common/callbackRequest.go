@@ -1,9 +1,17 @@
 package common
 
 type CallbackRequest struct {
+	ID string `json:"id"`
 	ExternalID string `json:"external_id"`
 	PaymentMethod string `json:"payment_method"`
 	PaymentChannel string `json:"payment_channel"`
 	PaidAt string `json:"paid_at"`
 	Status string `json:"status"`
-}
\ No newline at end of file
+	Amount float64 `json:"amount"`
+	PaidAmount float64 `json:"paid_amount"`
+	Updated string `json:"updated"`
+
+	// set from the request by the payment provider
+	EventID string `json:"-"`
+	Payload string `json:"-"`
+}
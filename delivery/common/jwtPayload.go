@@ -3,4 +3,6 @@ package common
 type JWTPayload struct {
 	UserID int
 	Email string
+	Role string
+	SessionID int
 }
\ No newline at end of file
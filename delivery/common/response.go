@@ -1,5 +1,7 @@
 package common
 
+import "github.com/furqonzt99/airbnb/pagination"
+
 //DefaultResponse default payload response
 type DefaultResponse struct {
 	Code    int    `json:"code"`
@@ -25,6 +27,15 @@ type ResponsePagination struct {
 	Data    interface{} `json:"data"`
 }
 
+type ResponseCursorPagination struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor"`
+	HasMore    bool        `json:"has_more"`
+	Data       interface{} `json:"data"`
+}
+
 func SuccessResponse(data interface{}) ResponseSuccess {
 	return ResponseSuccess{
 		Code:    200,
@@ -50,6 +61,17 @@ func PaginationResponse(page, perpage int, data interface{}) ResponsePagination
 	}
 }
 
+func CursorPaginationResponse(result pagination.Result, data interface{}) ResponseCursorPagination {
+	return ResponseCursorPagination{
+		Code:       200,
+		Message:    "Successful Operation",
+		Total:      result.Total,
+		NextCursor: result.NextCursor,
+		HasMore:    result.HasMore,
+		Data:       data,
+	}
+}
+
 //NewInternalServerErrorResponse default internal server error response
 func NewSuccessOperationResponse() DefaultResponse {
 	return DefaultResponse{
@@ -0,0 +1,319 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/delivery/controllers/transaction"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	hr "github.com/furqonzt99/airbnb/repository/house"
+	rr "github.com/furqonzt99/airbnb/repository/rating"
+	tr "github.com/furqonzt99/airbnb/repository/transaction"
+	ur "github.com/furqonzt99/airbnb/repository/user"
+	wr "github.com/furqonzt99/airbnb/repository/webhook"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminController struct {
+	UserRepo        ur.UserInterface
+	HouseRepo       hr.HouseInterface
+	TransactionRepo tr.Transaction
+	RatingRepo      rr.Rating
+	WebhookRepo     wr.WebhookInterface
+	Transactions    *transaction.TransactionController
+}
+
+func NewAdminController(userRepo ur.UserInterface, houseRepo hr.HouseInterface, transactionRepo tr.Transaction, ratingRepo rr.Rating, webhookRepo wr.WebhookInterface, transactions *transaction.TransactionController) *AdminController {
+	return &AdminController{
+		UserRepo:        userRepo,
+		HouseRepo:       houseRepo,
+		TransactionRepo: transactionRepo,
+		RatingRepo:      ratingRepo,
+		WebhookRepo:     webhookRepo,
+		Transactions:    transactions,
+	}
+}
+
+func (ac AdminController) GetUsers(c echo.Context) error {
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	users, result, err := ac.UserRepo.GetAllAdmin(c.QueryParam("search"), page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []UserResponse{}
+	for _, user := range users {
+		data = append(data, UserResponse{
+			ID:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			Role:      user.Role,
+			Suspended: user.Suspended,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (ac AdminController) SuspendUser(c echo.Context) error {
+	return ac.setUserSuspended(c, true)
+}
+
+func (ac AdminController) RestoreUser(c echo.Context) error {
+	return ac.setUserSuspended(c, false)
+}
+
+func (ac AdminController) setUserSuspended(c echo.Context, suspended bool) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	admin, _ := mw.ExtractTokenUser(c)
+
+	// an admin locking themselves out leaves nobody to restore the account
+	if suspended && admin.UserID == userId {
+		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+	}
+
+	user, err := ac.UserRepo.SetSuspended(userId, suspended)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if suspended {
+		ac.UserRepo.RevokeAllRefreshTokens(userId)
+	}
+
+	data := UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Suspended: user.Suspended,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(data))
+}
+
+func (ac AdminController) GetHouses(c echo.Context) error {
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	houses, result, err := ac.HouseRepo.GetAllAdmin(c.QueryParam("search"), page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []HouseResponse{}
+	for _, house := range houses {
+		data = append(data, HouseResponse{
+			ID:        house.ID,
+			UserID:    house.UserID,
+			UserName:  house.User.Name,
+			Title:     house.Title,
+			City:      house.City,
+			Price:     house.Price,
+			Status:    house.Status,
+			Suspended: house.Suspended,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (ac AdminController) SuspendHouse(c echo.Context) error {
+	return ac.setHouseSuspended(c, true)
+}
+
+func (ac AdminController) RestoreHouse(c echo.Context) error {
+	return ac.setHouseSuspended(c, false)
+}
+
+func (ac AdminController) setHouseSuspended(c echo.Context, suspended bool) error {
+	houseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	house, err := ac.HouseRepo.SetSuspended(houseId, suspended)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	data := HouseResponse{
+		ID:        house.ID,
+		UserID:    house.UserID,
+		Title:     house.Title,
+		City:      house.City,
+		Price:     house.Price,
+		Status:    house.Status,
+		Suspended: house.Suspended,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(data))
+}
+
+func (ac AdminController) ForceTransactionStatus(c echo.Context) error {
+	var forceStatusRequest ForceStatusRequest
+
+	if err := c.Bind(&forceStatusRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	if err := c.Validate(&forceStatusRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	trxId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	admin, _ := mw.ExtractTokenUser(c)
+
+	prevData, err := ac.TransactionRepo.GetById(trxId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if prevData.Status == forceStatusRequest.Status {
+		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+	}
+
+	transaction, err := ac.TransactionRepo.ForceStatus(trxId, admin.UserID, forceStatusRequest.Status, forceStatusRequest.Note)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	data := TransactionResponse{
+		ID:         transaction.ID,
+		InvoiceID:  transaction.InvoiceID,
+		FromStatus: prevData.Status,
+		Status:     transaction.Status,
+		TotalPrice: transaction.TotalPrice,
+		Note:       forceStatusRequest.Note,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(data))
+}
+
+func (ac AdminController) GetRatings(c echo.Context) error {
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	ratings, result, err := ac.RatingRepo.GetAllAdmin(c.QueryParam("search"), page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []RatingResponse{}
+	for _, rating := range ratings {
+		data = append(data, RatingResponse{
+			TransactionID: int(rating.TransactionID),
+			HouseID:       int(rating.HouseID),
+			UserID:        int(rating.UserID),
+			Username:      rating.User.Name,
+			Rating:        rating.Rating,
+			Comment:       rating.Comment,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (ac AdminController) DeleteRating(c echo.Context) error {
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	rating, err := ac.RatingRepo.Get(transactionId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if _, err := ac.RatingRepo.Delete(int(rating.UserID), transactionId); err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (ac AdminController) GetWebhookEvents(c echo.Context) error {
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	webhookEvents, result, err := ac.WebhookRepo.GetAll(c.QueryParam("invoice_id"), page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []WebhookEventResponse{}
+	for _, webhookEvent := range webhookEvents {
+		data = append(data, newWebhookEventResponse(webhookEvent))
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+// ReplayWebhookEvent processes a stored payment event again, e.g. once the
+// transaction it was rejected for is fixed. An event applied already isn't
+// applied twice.
+func (ac AdminController) ReplayWebhookEvent(c echo.Context) error {
+	webhookEventId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	webhookEvent, err := ac.WebhookRepo.Get(webhookEventId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	err = ac.Transactions.ProcessWebhookEvent(webhookEvent)
+	if err == transaction.ErrUnknownInvoice {
+		return c.JSON(http.StatusNotFound, common.ErrorResponse(http.StatusNotFound, err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
+	}
+
+	webhookEvent, err = ac.WebhookRepo.Get(webhookEventId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(newWebhookEventResponse(webhookEvent)))
+}
+
+func newWebhookEventResponse(webhookEvent model.WebhookEvent) WebhookEventResponse {
+	response := WebhookEventResponse{
+		ID:         webhookEvent.ID,
+		EventID:    webhookEvent.EventID,
+		InvoiceID:  webhookEvent.InvoiceID,
+		Status:     webhookEvent.Status,
+		Amount:     webhookEvent.Amount,
+		OccurredAt: webhookEvent.OccurredAt,
+		Result:     webhookEvent.Result,
+	}
+
+	if !webhookEvent.ProcessedAt.IsZero() {
+		response.ProcessedAt = &webhookEvent.ProcessedAt
+	}
+
+	return response
+}
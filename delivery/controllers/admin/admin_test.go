@@ -0,0 +1,404 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/delivery/controllers/transaction"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/payment"
+	hr "github.com/furqonzt99/airbnb/repository/house"
+	rr "github.com/furqonzt99/airbnb/repository/rating"
+	tr "github.com/furqonzt99/airbnb/repository/transaction"
+	ur "github.com/furqonzt99/airbnb/repository/user"
+	wr "github.com/furqonzt99/airbnb/repository/webhook"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var adminToken, _ = mw.CreateToken(1, "admin@gmail.com", constant.ADMIN_ROLE, 1)
+
+func newAdminController() *AdminController {
+	transactions := transaction.NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(""), helper.Fees{}, event.NewBus(nil))
+
+	return NewAdminController(mockUserRepository{}, mockHouseRepository{}, mockTransactionRepository{}, mockRatingRepository{}, mockWebhookRepository{}, transactions)
+}
+
+func newFalseAdminController() *AdminController {
+	transactions := transaction.NewTransactionController(mockFalseTransactionRepository{}, mockFalseWebhookRepository{}, payment.NewFakeProvider(""), helper.Fees{}, event.NewBus(nil))
+
+	return NewAdminController(mockFalseUserRepository{}, mockFalseHouseRepository{}, mockFalseTransactionRepository{}, mockFalseRatingRepository{}, mockFalseWebhookRepository{}, transactions)
+}
+
+func serve(handler echo.HandlerFunc, method, path string, body interface{}, names []string, values []string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = &AdminValidator{Validator: validator.New()}
+
+	reqBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(method, "/", bytes.NewBuffer(reqBody))
+	res := httptest.NewRecorder()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", adminToken))
+
+	context := e.NewContext(req, res)
+	context.SetPath(path)
+	context.SetParamNames(names...)
+	context.SetParamValues(values...)
+
+	if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(mw.RequireRole(constant.ADMIN_ROLE)(handler))(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func TestUsers(t *testing.T) {
+	t.Run("Get Users", func(t *testing.T) {
+		res := serve(newAdminController().GetUsers, http.MethodGet, "/admin/users", nil, nil, nil)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(2), response.Total)
+		assert.False(t, response.HasMore)
+		assert.Equal(t, 2, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Suspend User", func(t *testing.T) {
+		res := serve(newAdminController().SuspendUser, http.MethodPut, "/admin/users/:id/suspend", nil, []string{"id"}, []string{"2"})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["suspended"])
+	})
+
+	t.Run("Restore User", func(t *testing.T) {
+		res := serve(newAdminController().RestoreUser, http.MethodPut, "/admin/users/:id/restore", nil, []string{"id"}, []string{"2"})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, false, response.Data.(map[string]interface{})["suspended"])
+	})
+
+	t.Run("Error Suspend Self", func(t *testing.T) {
+		res := serve(newAdminController().SuspendUser, http.MethodPut, "/admin/users/:id/suspend", nil, []string{"id"}, []string{"1"})
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Suspend User Not Found", func(t *testing.T) {
+		res := serve(newFalseAdminController().SuspendUser, http.MethodPut, "/admin/users/:id/suspend", nil, []string{"id"}, []string{"100"})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestHouses(t *testing.T) {
+	t.Run("Get Houses", func(t *testing.T) {
+		res := serve(newAdminController().GetHouses, http.MethodGet, "/admin/houses", nil, nil, nil)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 1, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Suspend House", func(t *testing.T) {
+		res := serve(newAdminController().SuspendHouse, http.MethodPut, "/admin/houses/:id/suspend", nil, []string{"id"}, []string{"1"})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["suspended"])
+	})
+
+	t.Run("Error Restore House Not Found", func(t *testing.T) {
+		res := serve(newFalseAdminController().RestoreHouse, http.MethodPut, "/admin/houses/:id/restore", nil, []string{"id"}, []string{"100"})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestForceTransactionStatus(t *testing.T) {
+	t.Run("Force Status", func(t *testing.T) {
+		res := serve(newAdminController().ForceTransactionStatus, http.MethodPut, "/admin/transactions/:id/status", ForceStatusRequest{
+			Status: "PAID",
+			Note:   "paid by bank transfer, callback never arrived",
+		}, []string{"id"}, []string{"1"})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "PENDING", data["from_status"])
+		assert.Equal(t, "PAID", data["status"])
+	})
+
+	t.Run("Error Force Same Status", func(t *testing.T) {
+		res := serve(newAdminController().ForceTransactionStatus, http.MethodPut, "/admin/transactions/:id/status", ForceStatusRequest{
+			Status: "PENDING",
+			Note:   "no op",
+		}, []string{"id"}, []string{"1"})
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Force Status Without Note", func(t *testing.T) {
+		res := serve(newAdminController().ForceTransactionStatus, http.MethodPut, "/admin/transactions/:id/status", ForceStatusRequest{
+			Status: "PAID",
+		}, []string{"id"}, []string{"1"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Force Status Not Found", func(t *testing.T) {
+		res := serve(newFalseAdminController().ForceTransactionStatus, http.MethodPut, "/admin/transactions/:id/status", ForceStatusRequest{
+			Status: "PAID",
+			Note:   "paid by bank transfer",
+		}, []string{"id"}, []string{"100"})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestRatings(t *testing.T) {
+	t.Run("Get Ratings", func(t *testing.T) {
+		res := serve(newAdminController().GetRatings, http.MethodGet, "/admin/ratings", nil, nil, nil)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 1, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Delete Rating", func(t *testing.T) {
+		res := serve(newAdminController().DeleteRating, http.MethodDelete, "/admin/ratings/:transactionId", nil, []string{"transactionId"}, []string{"1"})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Delete Rating Not Found", func(t *testing.T) {
+		res := serve(newFalseAdminController().DeleteRating, http.MethodDelete, "/admin/ratings/:transactionId", nil, []string{"transactionId"}, []string{"1"})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestWebhookEvents(t *testing.T) {
+	t.Run("Get Webhook Events", func(t *testing.T) {
+		res := serve(newAdminController().GetWebhookEvents, http.MethodGet, "/admin/webhook-events", nil, nil, nil)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 1, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Error Get Webhook Events", func(t *testing.T) {
+		res := serve(newFalseAdminController().GetWebhookEvents, http.MethodGet, "/admin/webhook-events", nil, nil, nil)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+
+	t.Run("Replay Webhook Event", func(t *testing.T) {
+		res := serve(newAdminController().ReplayWebhookEvent, http.MethodPost, "/admin/webhook-events/:id/replay", nil, []string{"id"}, []string{"1"})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "JHAKHSHJSIWOAM", data["invoice_id"])
+	})
+
+	t.Run("Error Replay Webhook Event Applied Already", func(t *testing.T) {
+		res := serve(newAdminController().ReplayWebhookEvent, http.MethodPost, "/admin/webhook-events/:id/replay", nil, []string{"id"}, []string{"2"})
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Replay Webhook Event Not Found", func(t *testing.T) {
+		res := serve(newFalseAdminController().ReplayWebhookEvent, http.MethodPost, "/admin/webhook-events/:id/replay", nil, []string{"id"}, []string{"100"})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Replay Webhook Event Invalid Id", func(t *testing.T) {
+		res := serve(newAdminController().ReplayWebhookEvent, http.MethodPost, "/admin/webhook-events/:id/replay", nil, []string{"id"}, []string{"abc"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+// the mocks embed the repository interfaces so they only implement what the
+// admin controller calls
+
+type mockUserRepository struct{ ur.UserInterface }
+
+func (m mockUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return []model.User{
+		{Model: gorm.Model{ID: 1}, Name: "User 1", Email: "user1@gmail.com", Role: "admin"},
+		{Model: gorm.Model{ID: 2}, Name: "User 2", Email: "user2@gmail.com", Role: "host", Suspended: true},
+	}, pagination.Result{Total: 2}, nil
+}
+
+func (m mockUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{Model: gorm.Model{ID: uint(userId)}, Name: "User 2", Email: "user2@gmail.com", Role: "host", Suspended: suspended}, nil
+}
+
+func (m mockUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return nil
+}
+
+type mockFalseUserRepository struct{ ur.UserInterface }
+
+func (m mockFalseUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
+}
+
+func (m mockFalseUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{}, errors.New("Error")
+}
+
+type mockHouseRepository struct{ hr.HouseInterface }
+
+func (m mockHouseRepository) GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{{Model: gorm.Model{ID: 1}, UserID: 2, Title: "House 1", City: "City 1", Price: 150000, Status: "open"}}, pagination.Result{}, nil
+}
+
+func (m mockHouseRepository) SetSuspended(houseId int, suspended bool) (model.House, error) {
+	return model.House{Model: gorm.Model{ID: uint(houseId)}, UserID: 2, Title: "House 1", City: "City 1", Price: 150000, Status: "open", Suspended: suspended}, nil
+}
+
+type mockFalseHouseRepository struct{ hr.HouseInterface }
+
+func (m mockFalseHouseRepository) GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) SetSuspended(houseId int, suspended bool) (model.House, error) {
+	return model.House{}, errors.New("Error")
+}
+
+type mockTransactionRepository struct{ tr.Transaction }
+
+func (m mockTransactionRepository) GetById(trxId int) (model.Transaction, error) {
+	return model.Transaction{Model: gorm.Model{ID: uint(trxId)}, InvoiceID: "JHAKHSHJSIWOAM", TotalPrice: 300000, Status: "PENDING"}, nil
+}
+
+func (m mockTransactionRepository) ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error) {
+	return model.Transaction{Model: gorm.Model{ID: uint(trxId)}, InvoiceID: "JHAKHSHJSIWOAM", TotalPrice: 300000, Status: status}, nil
+}
+
+func (m mockTransactionRepository) GetByInvoice(invId string) (model.Transaction, error) {
+	return model.Transaction{Model: gorm.Model{ID: 1}, InvoiceID: invId, TotalPrice: 300000, Status: "PENDING"}, nil
+}
+
+func (m mockTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	return true, nil
+}
+
+type mockFalseTransactionRepository struct{ tr.Transaction }
+
+func (m mockFalseTransactionRepository) GetById(trxId int) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
+}
+
+func (m mockFalseTransactionRepository) ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
+}
+
+type mockRatingRepository struct{ rr.Rating }
+
+func (m mockRatingRepository) GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	return []model.Rating{{TransactionID: 1, HouseID: 1, UserID: 2, Rating: 1, Comment: "spam spam spam"}}, pagination.Result{}, nil
+}
+
+func (m mockRatingRepository) Get(transactionId int) (model.Rating, error) {
+	return model.Rating{TransactionID: uint(transactionId), HouseID: 1, UserID: 2}, nil
+}
+
+func (m mockRatingRepository) Delete(userId, transactionId int) (model.Rating, error) {
+	return model.Rating{TransactionID: uint(transactionId), HouseID: 1, UserID: uint(userId)}, nil
+}
+
+type mockFalseRatingRepository struct{ rr.Rating }
+
+func (m mockFalseRatingRepository) GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
+}
+
+func (m mockFalseRatingRepository) Get(transactionId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (m mockFalseRatingRepository) Delete(userId, transactionId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+type mockWebhookRepository struct{ wr.WebhookInterface }
+
+// Get returns an event rejected before for id 1 and an applied one otherwise.
+func (m mockWebhookRepository) Get(webhookEventId int) (model.WebhookEvent, error) {
+	result := wr.APPLIED_RESULT
+	if webhookEventId == 1 {
+		result = transaction.ErrUnknownInvoice.Error()
+	}
+
+	return model.WebhookEvent{Model: gorm.Model{ID: uint(webhookEventId)}, EventID: "fake-JHAKHSHJSIWOAM-PAID", InvoiceID: "JHAKHSHJSIWOAM", Status: "PAID", Amount: 300000, Result: result}, nil
+}
+
+func (m mockWebhookRepository) GetAll(invId string, page pagination.Page) ([]model.WebhookEvent, pagination.Result, error) {
+	return []model.WebhookEvent{{Model: gorm.Model{ID: 1}, EventID: "fake-JHAKHSHJSIWOAM-PAID", InvoiceID: "JHAKHSHJSIWOAM", Status: "PAID", Amount: 300000}}, pagination.Result{}, nil
+}
+
+func (m mockWebhookRepository) GetLatestApplied(invId string) (model.WebhookEvent, error) {
+	return model.WebhookEvent{}, gorm.ErrRecordNotFound
+}
+
+func (m mockWebhookRepository) Claim(webhookEventId int, result string) (bool, error) {
+	return true, nil
+}
+
+func (m mockWebhookRepository) MarkProcessed(webhookEventId int, result string) error {
+	return nil
+}
+
+type mockFalseWebhookRepository struct{ wr.WebhookInterface }
+
+func (m mockFalseWebhookRepository) Get(webhookEventId int) (model.WebhookEvent, error) {
+	return model.WebhookEvent{}, errors.New("Error")
+}
+
+func (m mockFalseWebhookRepository) GetAll(invId string, page pagination.Page) ([]model.WebhookEvent, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
+}
@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type ForceStatusRequest struct {
+	Status model.TransactionStatus `json:"status" validate:"required,oneof=REQUESTED PENDING PAID CHECKED_IN COMPLETED CANCELLED EXPIRED DECLINED REFUNDED"`
+	Note   string                  `json:"note" validate:"required"`
+}
+
+type AdminValidator struct {
+	Validator *validator.Validate
+}
+
+func (av *AdminValidator) Validate(i interface{}) error {
+	if err := av.Validator.Struct(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+type UserResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Suspended bool   `json:"suspended"`
+}
+
+type HouseResponse struct {
+	ID        uint    `json:"id"`
+	UserID    uint    `json:"user_id"`
+	UserName  string  `json:"user_name"`
+	Title     string  `json:"title"`
+	City      string  `json:"city"`
+	Price     float64 `json:"price"`
+	Status    string  `json:"status"`
+	Suspended bool    `json:"suspended"`
+}
+
+type TransactionResponse struct {
+	ID         uint                    `json:"id"`
+	InvoiceID  string                  `json:"invoice_id"`
+	FromStatus model.TransactionStatus `json:"from_status"`
+	Status     model.TransactionStatus `json:"status"`
+	TotalPrice float64                 `json:"total_price"`
+	Note       string                  `json:"note"`
+}
+
+type RatingResponse struct {
+	TransactionID int    `json:"transaction_id"`
+	HouseID       int    `json:"house_id"`
+	UserID        int    `json:"user_id"`
+	Username      string `json:"username"`
+	Rating        int    `json:"rating"`
+	Comment       string `json:"comment"`
+}
+
+type WebhookEventResponse struct {
+	ID          uint       `json:"id"`
+	EventID     string     `json:"event_id"`
+	InvoiceID   string     `json:"invoice_id"`
+	Status      string     `json:"status"`
+	Amount      float64    `json:"amount"`
+	OccurredAt  time.Time  `json:"occurred_at"`
+	ProcessedAt *time.Time `json:"processed_at"`
+	Result      string     `json:"result"`
+}
@@ -0,0 +1,297 @@
+package conversation
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	cr "github.com/furqonzt99/airbnb/repository/conversation"
+	"github.com/labstack/echo/v4"
+)
+
+type ConversationController struct {
+	Repository cr.ConversationInterface
+	Events     event.Publisher
+}
+
+func NewConversationController(repo cr.ConversationInterface, events event.Publisher) *ConversationController {
+	return &ConversationController{Repository: repo, Events: events}
+}
+
+func (cc ConversationController) GetAll(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	conversations, result, err := cc.Repository.GetAllByUser(user.UserID, page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	conversationIds := []uint{}
+	for _, conversation := range conversations {
+		conversationIds = append(conversationIds, conversation.ID)
+	}
+
+	lastMessages, err := cc.Repository.GetLastMessages(conversationIds)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	unread, err := cc.Repository.CountUnread(user.UserID, conversationIds)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []ConversationResponse{}
+	for _, conversation := range conversations {
+		response := newConversationResponse(conversation, unread[conversation.ID])
+		if lastMessage, ok := lastMessages[conversation.ID]; ok {
+			messageResponse := newMessageResponse(lastMessage)
+			response.LastMessage = &messageResponse
+		}
+
+		data = append(data, response)
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (cc ConversationController) GetUnread(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	unread, err := cc.Repository.CountAllUnread(user.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(UnreadResponse{UnreadCount: unread}))
+}
+
+// Start opens the thread with a host, or continues it when the guest and host
+// already talked about the house. Either participant of a booking can start
+// from its transaction_id; without one the caller is the guest asking about
+// house_id.
+func (cc ConversationController) Start(c echo.Context) error {
+	var startRequest StartConversationRequest
+
+	if err := c.Bind(&startRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&startRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	conversation := model.Conversation{GuestID: uint(user.UserID)}
+
+	if startRequest.TransactionID != 0 {
+		transaction, err := cc.Repository.GetTransaction(user.UserID, startRequest.TransactionID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		if startRequest.HouseID != 0 && uint(startRequest.HouseID) != transaction.HouseID {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		transactionId := transaction.ID
+		conversation = model.Conversation{
+			GuestID:       transaction.UserID,
+			HostID:        transaction.HostID,
+			HouseID:       transaction.HouseID,
+			TransactionID: &transactionId,
+		}
+	} else {
+		if startRequest.HouseID == 0 {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		house, err := cc.Repository.GetHouse(startRequest.HouseID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		if house.UserID == uint(user.UserID) {
+			return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+		}
+
+		conversation.HostID = house.UserID
+		conversation.HouseID = house.ID
+	}
+
+	conversation, err := cc.Repository.FindOrCreate(conversation)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	message, err := cc.Repository.CreateMessage(model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       uint(user.UserID),
+		Body:           startRequest.Message,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	conversation.LastMessageAt = message.CreatedAt
+
+	cc.notify(conversation, message)
+
+	data := newConversationResponse(conversation, 0)
+	messageResponse := newMessageResponse(message)
+	data.LastMessage = &messageResponse
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(data))
+}
+
+// GetMessages lists a thread oldest first. Opening it marks the messages of
+// the other participant as read; admins can read any thread without doing so.
+func (cc ConversationController) GetMessages(c echo.Context) error {
+	conversationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	conversation, err := cc.Repository.Get(conversationId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if isParticipant(conversation, user.UserID) {
+		if err := cc.Repository.MarkRead(conversationId, user.UserID); err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+	} else if user.Role != constant.ADMIN_ROLE {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	messages, result, err := cc.Repository.GetMessages(conversationId, page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []MessageResponse{}
+	for _, message := range messages {
+		data = append(data, newMessageResponse(message))
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (cc ConversationController) SendMessage(c echo.Context) error {
+	conversationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	var sendRequest SendMessageRequest
+
+	if err := c.Bind(&sendRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&sendRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	conversation, err := cc.Repository.Get(conversationId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if !isParticipant(conversation, user.UserID) {
+		if user.Role == constant.ADMIN_ROLE {
+			return c.JSON(http.StatusForbidden, common.ErrorResponse(http.StatusForbidden, "Only participants can send messages"))
+		}
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	message, err := cc.Repository.CreateMessage(model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       uint(user.UserID),
+		Body:           sendRequest.Message,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	// replying means the thread has been read
+	cc.Repository.MarkRead(conversationId, user.UserID)
+
+	cc.notify(conversation, message)
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(newMessageResponse(message)))
+}
+
+// notify tells the other participant about the message.
+func (cc ConversationController) notify(conversation model.Conversation, message model.Message) {
+	recipientId := conversation.HostID
+	if message.SenderID == conversation.HostID {
+		recipientId = conversation.GuestID
+	}
+
+	cc.Events.Publish(event.Event{
+		Type:    event.MESSAGE_RECEIVED,
+		UserID:  int(recipientId),
+		Message: fmt.Sprintf("New message from %v", message.Sender.Name),
+		Data:    map[string]interface{}{"conversation_id": conversation.ID, "message_id": message.ID},
+	})
+}
+
+func isParticipant(conversation model.Conversation, userId int) bool {
+	return uint(userId) == conversation.GuestID || uint(userId) == conversation.HostID
+}
+
+func newConversationResponse(conversation model.Conversation, unread int64) ConversationResponse {
+	return ConversationResponse{
+		ID:            conversation.ID,
+		HouseID:       conversation.HouseID,
+		HouseTitle:    conversation.House.Title,
+		GuestID:       conversation.GuestID,
+		GuestName:     conversation.Guest.Name,
+		HostID:        conversation.HostID,
+		HostName:      conversation.Host.Name,
+		TransactionID: conversation.TransactionID,
+		LastMessageAt: conversation.LastMessageAt,
+		UnreadCount:   unread,
+	}
+}
+
+func newMessageResponse(message model.Message) MessageResponse {
+	response := MessageResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		SenderName:     message.Sender.Name,
+		Message:        message.Body,
+		CreatedAt:      message.CreatedAt,
+	}
+
+	if !message.ReadAt.IsZero() {
+		readAt := message.ReadAt
+		response.ReadAt = &readAt
+	}
+
+	return response
+}
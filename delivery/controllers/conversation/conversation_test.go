@@ -0,0 +1,292 @@
+package conversation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var guestToken, _ = mw.CreateToken(1, "guest@gmail.com", constant.GUEST_ROLE, 1)
+var hostToken, _ = mw.CreateToken(2, "host@gmail.com", constant.HOST_ROLE, 1)
+var strangerToken, _ = mw.CreateToken(3, "stranger@gmail.com", constant.GUEST_ROLE, 1)
+var adminToken, _ = mw.CreateToken(4, "admin@gmail.com", constant.ADMIN_ROLE, 1)
+
+func serve(repo mockConversationRepository, handler func(ConversationController, echo.Context) error, token string, body interface{}, id string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = &ConversationValidator{Validator: validator.New()}
+
+	reqBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+	res := httptest.NewRecorder()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/conversations/:id/messages")
+	context.SetParamNames("id")
+	context.SetParamValues(id)
+
+	conversationController := NewConversationController(repo, event.NewBus(nil))
+	handlerFunc := func(c echo.Context) error {
+		return handler(*conversationController, c)
+	}
+
+	if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(handlerFunc)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func TestGetAll(t *testing.T) {
+	t.Run("Get Conversations", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.GetAll, guestToken, nil, "")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(1), response.Total)
+
+		conversation := response.Data.([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, float64(2), conversation["unread_count"])
+		assert.Equal(t, "Is there parking?", conversation["last_message"].(map[string]interface{})["message"])
+	})
+
+	t.Run("Get Unread Count", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.GetUnread, guestToken, nil, "")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, float64(2), response.Data.(map[string]interface{})["unread_count"])
+	})
+}
+
+func TestStart(t *testing.T) {
+	t.Run("Start From House", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, guestToken, map[string]interface{}{
+			"house_id": 3,
+			"message":  "Is there parking?",
+		}, "")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(2), data["host_id"])
+		assert.Nil(t, data["transaction_id"])
+	})
+
+	t.Run("Host Starts From Booking", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, hostToken, map[string]interface{}{
+			"transaction_id": 5,
+			"message":        "The key is under the mat",
+		}, "")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(1), data["guest_id"])
+		assert.Equal(t, float64(5), data["transaction_id"])
+	})
+
+	t.Run("Error Start From Booking Of Someone Else", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, strangerToken, map[string]interface{}{
+			"transaction_id": 5,
+			"message":        "Hello",
+		}, "")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Start With Own House", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, hostToken, map[string]interface{}{
+			"house_id": 3,
+			"message":  "Hello",
+		}, "")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Start Without House", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, guestToken, map[string]interface{}{
+			"message": "Hello",
+		}, "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Start Without Message", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, guestToken, map[string]interface{}{
+			"house_id": 3,
+		}, "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Start With Unknown House", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.Start, guestToken, map[string]interface{}{
+			"house_id": 100,
+			"message":  "Hello",
+		}, "")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestMessages(t *testing.T) {
+	t.Run("Participant Reads Thread", func(t *testing.T) {
+		repo := mockConversationRepository{markedRead: &[]int{}}
+		res := serve(repo, ConversationController.GetMessages, hostToken, nil, "1")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 2, len(response.Data.([]interface{})))
+		assert.Equal(t, []int{2}, *repo.markedRead)
+	})
+
+	t.Run("Admin Reads Thread Without Marking It Read", func(t *testing.T) {
+		repo := mockConversationRepository{markedRead: &[]int{}}
+		res := serve(repo, ConversationController.GetMessages, adminToken, nil, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 0, len(*repo.markedRead))
+	})
+
+	t.Run("Error Stranger Reads Thread", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.GetMessages, strangerToken, nil, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Read Unknown Thread", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.GetMessages, guestToken, nil, "100")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Send Message", func(t *testing.T) {
+		repo := mockConversationRepository{markedRead: &[]int{}}
+		res := serve(repo, ConversationController.SendMessage, guestToken, map[string]interface{}{"message": "Thanks!"}, "1")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "Thanks!", response.Data.(map[string]interface{})["message"])
+		assert.Equal(t, []int{1}, *repo.markedRead)
+	})
+
+	t.Run("Error Admin Sends Message", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.SendMessage, adminToken, map[string]interface{}{"message": "Hello"}, "1")
+
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Error Stranger Sends Message", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.SendMessage, strangerToken, map[string]interface{}{"message": "Hello"}, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Send Empty Message", func(t *testing.T) {
+		res := serve(mockConversationRepository{}, ConversationController.SendMessage, guestToken, map[string]interface{}{"message": ""}, "1")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+// guest 1 and host 2 talk about house 3, booked as transaction 5
+
+type mockConversationRepository struct {
+	markedRead *[]int
+}
+
+func (m mockConversationRepository) GetHouse(houseId int) (model.House, error) {
+	if houseId != 3 {
+		return model.House{}, errors.New("record not found")
+	}
+	return model.House{Model: gorm.Model{ID: 3}, UserID: 2, Title: "Rumah Bagus"}, nil
+}
+
+func (m mockConversationRepository) GetTransaction(userId, trxId int) (model.Transaction, error) {
+	if trxId != 5 || (userId != 1 && userId != 2) {
+		return model.Transaction{}, errors.New("record not found")
+	}
+	return model.Transaction{Model: gorm.Model{ID: 5}, UserID: 1, HostID: 2, HouseID: 3}, nil
+}
+
+func (m mockConversationRepository) FindOrCreate(conversation model.Conversation) (model.Conversation, error) {
+	conversation.ID = 1
+	return conversation, nil
+}
+
+func (m mockConversationRepository) Get(conversationId int) (model.Conversation, error) {
+	if conversationId != 1 {
+		return model.Conversation{}, errors.New("record not found")
+	}
+	return model.Conversation{Model: gorm.Model{ID: 1}, GuestID: 1, HostID: 2, HouseID: 3}, nil
+}
+
+func (m mockConversationRepository) GetAllByUser(userId int, page pagination.Page) ([]model.Conversation, pagination.Result, error) {
+	return []model.Conversation{{Model: gorm.Model{ID: 1}, GuestID: 1, HostID: 2, HouseID: 3, LastMessageAt: time.Now()}}, pagination.Result{Total: 1}, nil
+}
+
+func (m mockConversationRepository) GetLastMessages(conversationIds []uint) (map[uint]model.Message, error) {
+	return map[uint]model.Message{1: {ConversationID: 1, SenderID: 2, Body: "Is there parking?"}}, nil
+}
+
+func (m mockConversationRepository) CountUnread(userId int, conversationIds []uint) (map[uint]int64, error) {
+	return map[uint]int64{1: 2}, nil
+}
+
+func (m mockConversationRepository) CountAllUnread(userId int) (int64, error) {
+	return 2, nil
+}
+
+func (m mockConversationRepository) GetMessages(conversationId int, page pagination.Page) ([]model.Message, pagination.Result, error) {
+	return []model.Message{
+		{Model: gorm.Model{ID: 1}, ConversationID: 1, SenderID: 1, Body: "Is there parking?", ReadAt: time.Now()},
+		{Model: gorm.Model{ID: 2}, ConversationID: 1, SenderID: 2, Body: "Yes, in the garage"},
+	}, pagination.Result{Total: 2}, nil
+}
+
+func (m mockConversationRepository) CreateMessage(message model.Message) (model.Message, error) {
+	message.ID = 3
+	message.CreatedAt = time.Now()
+	return message, nil
+}
+
+func (m mockConversationRepository) MarkRead(conversationId, userId int) error {
+	if m.markedRead != nil {
+		*m.markedRead = append(*m.markedRead, userId)
+	}
+	return nil
+}
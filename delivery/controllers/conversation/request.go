@@ -0,0 +1,30 @@
+package conversation
+
+import (
+	"net/http"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type StartConversationRequest struct {
+	HouseID       int    `json:"house_id"`
+	TransactionID int    `json:"transaction_id"`
+	Message       string `json:"message" validate:"required,max=2000"`
+}
+
+type SendMessageRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+}
+
+type ConversationValidator struct {
+	Validator *validator.Validate
+}
+
+func (cv *ConversationValidator) Validate(i interface{}) error {
+	if err := cv.Validator.Struct(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+	return nil
+}
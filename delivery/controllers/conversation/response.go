@@ -0,0 +1,31 @@
+package conversation
+
+import "time"
+
+type ConversationResponse struct {
+	ID            uint             `json:"id"`
+	HouseID       uint             `json:"house_id"`
+	HouseTitle    string           `json:"house_title"`
+	GuestID       uint             `json:"guest_id"`
+	GuestName     string           `json:"guest_name"`
+	HostID        uint             `json:"host_id"`
+	HostName      string           `json:"host_name"`
+	TransactionID *uint            `json:"transaction_id"`
+	LastMessage   *MessageResponse `json:"last_message"`
+	LastMessageAt time.Time        `json:"last_message_at"`
+	UnreadCount   int64            `json:"unread_count"`
+}
+
+type MessageResponse struct {
+	ID             uint       `json:"id"`
+	ConversationID uint       `json:"conversation_id"`
+	SenderID       uint       `json:"sender_id"`
+	SenderName     string     `json:"sender_name"`
+	Message        string     `json:"message"`
+	ReadAt         *time.Time `json:"read_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type UnreadResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
@@ -1,24 +1,37 @@
 package house
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/furqonzt99/airbnb/delivery/controllers/rating"
 	"github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"github.com/furqonzt99/airbnb/repository/house"
+	"github.com/furqonzt99/airbnb/storage"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type HouseController struct {
-	Repo house.HouseInterface
+	Repo    house.HouseInterface
+	Storage storage.Storage
 }
 
-func NewHouseControllers(prorep house.HouseInterface) *HouseController {
-	return &HouseController{Repo: prorep}
+func NewHouseControllers(prorep house.HouseInterface, store storage.Storage) *HouseController {
+	return &HouseController{Repo: prorep, Storage: store}
 }
 
 func (hc HouseController) CreateHouseController() echo.HandlerFunc {
@@ -29,14 +42,34 @@ func (hc HouseController) CreateHouseController() echo.HandlerFunc {
 		newHouseReq := CreateHouseRequestFormat{}
 		c.Bind(&newHouseReq)
 
+		if newHouseReq.CancellationPolicy != "" && !helper.IsValidCancellationPolicy(newHouseReq.CancellationPolicy) {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		if newHouseReq.MaxGuests < 0 || !newHouseReq.HouseRulesRequestFormat.isValid(newHouseReq.MinStay) {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		if !isValidLocation(newHouseReq.Latitude, newHouseReq.Longitude) {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Latitude and longitude must be set together and be valid coordinates!"))
+		}
+
 		newHouse := model.House{
-			UserID:  uint(user.UserID),
-			Title:   newHouseReq.Title,
-			Address: newHouseReq.Address,
-			City:    newHouseReq.City,
-			Price:   newHouseReq.Price,
-			Status:  newHouseReq.Status,
+			UserID:             uint(user.UserID),
+			Title:              newHouseReq.Title,
+			Address:            newHouseReq.Address,
+			City:               newHouseReq.City,
+			Price:              newHouseReq.Price,
+			Status:             newHouseReq.Status,
+			CancellationPolicy: newHouseReq.CancellationPolicy,
+			MinStay:            newHouseReq.MinStay,
+			MaxGuests:          newHouseReq.MaxGuests,
+			CleaningFee:        newHouseReq.CleaningFee,
+			InstantBook:        newHouseReq.InstantBook,
+			Latitude:           newHouseReq.Latitude,
+			Longitude:          newHouseReq.Longitude,
 		}
+		newHouseReq.HouseRulesRequestFormat.apply(&newHouse)
 
 		house, err := hc.Repo.Create(newHouse)
 		if err != nil {
@@ -57,74 +90,96 @@ func (hc HouseController) CreateHouseController() echo.HandlerFunc {
 	}
 }
 
-func (hc HouseController) GetAllHouseController() echo.HandlerFunc {
-
-	return func(c echo.Context) error {
-		page, _ := strconv.Atoi(c.QueryParam("page"))
-		perpage, _ := strconv.Atoi(c.QueryParam("perpage"))
-		search := c.QueryParam("search")
-		city := c.QueryParam("city")
+// NewHouseResponses is the house projection of the listings. When the request
+// is authenticated, the houses the caller saved to a wishlist are marked.
+func (hc HouseController) NewHouseResponses(c echo.Context, houses []model.House) []HouseResponse {
+	saved := map[uint]bool{}
+	if user, err := middleware.ExtractTokenUser(c); err == nil && len(houses) > 0 {
+		houseIds := []uint{}
+		for _, item := range houses {
+			houseIds = append(houseIds, item.ID)
+		}
 
-		if page == 0 {
-			page = 1
+		if savedIds, err := hc.Repo.GetSavedHouseIDs(user.UserID, houseIds); err == nil {
+			saved = savedIds
 		}
+	}
 
-		if perpage == 0 {
-			perpage = 10
+	data := []HouseResponse{}
+	for _, item := range houses {
+		featuresData := []FeatureResponse{}
+		for _, feature := range item.Features {
+			featuresData = append(featuresData, FeatureResponse{
+				ID:   feature.ID,
+				Name: feature.Name,
+			})
 		}
 
-		offset := (page - 1) * perpage
+		ratingData := []rating.RatingResponse{}
+		for _, r := range item.Ratings {
+			ratingData = append(ratingData, rating.NewRatingResponse(r))
+		}
 
-		houses, _ := hc.Repo.GetAll(offset, perpage, search, city)
+		data = append(data, HouseResponse{
+			ID:                 item.ID,
+			UserID:             item.User.ID,
+			UserName:           item.User.Name,
+			Title:              item.Title,
+			Address:            item.Address,
+			City:               item.City,
+			Price:              item.Price,
+			Rating:             item.RatingSummary.Average,
+			RatingSummary:      newRatingSummaryResponse(item.RatingSummary),
+			Status:             item.Status,
+			CancellationPolicy: item.CancellationPolicy,
+			MinStay:            item.MinStay,
+			MaxStay:            item.MaxStay,
+			MaxGuests:          item.MaxGuests,
+			Bedrooms:           item.Bedrooms,
+			Bathrooms:          item.Bathrooms,
+			CheckinTime:        item.CheckinTime,
+			CheckoutTime:       item.CheckoutTime,
+			PetsAllowed:        item.PetsAllowed,
+			SmokingAllowed:     item.SmokingAllowed,
+			EventsAllowed:      item.EventsAllowed,
+			AdditionalRules:    item.AdditionalRules,
+			CleaningFee:        item.CleaningFee,
+			InstantBook:        item.IsInstantBook(),
+			Features:           featuresData,
+			Ratings:            ratingData,
+			Photos:             hc.newPhotoResponses(item.Photos),
+			Latitude:           item.Latitude,
+			Longitude:          item.Longitude,
+			IsSaved:            saved[item.ID],
+		})
+	}
 
-		if len(houses) == 0 {
-			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
-		}
+	return data
+}
 
-		data := []HouseResponse{}
-		for _, item := range houses {
-			featuresData := []FeatureResponse{}
-			for _, feature := range item.Features {
-				featuresData = append(featuresData, FeatureResponse{
-					ID:   feature.ID,
-					Name: feature.Name,
-				})
-			}
+func (hc HouseController) GetAllHouseController() echo.HandlerFunc {
 
-			ratingData := []rating.RatingResponse{}
-			ratings := []int{}
+	return func(c echo.Context) error {
+		page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
 
-			for _, r := range item.Ratings {
-				ratingData = append(ratingData, rating.RatingResponse{
-					HouseID:  int(r.HouseID),
-					UserID:   int(r.UserID),
-					Username: r.User.Name,
-					Rating:   r.Rating,
-					Comment:  r.Comment,
-				})
+		filter, err := parseHouseFilter(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
 
-				ratings = append(ratings, r.Rating)
-			}
+		houses, result, err := hc.Repo.GetAll(filter, page)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
 
-			rating := helper.CalculateRatings(ratings)
-
-			data = append(
-				data, HouseResponse{
-					ID:       item.ID,
-					UserID:   item.User.ID,
-					UserName: item.User.Name,
-					Title:    item.Title,
-					Address:  item.Address,
-					City:     item.City,
-					Price:    item.Price,
-					Rating:   rating,
-					Status:   item.Status,
-					Features: featuresData,
-					Ratings:  ratingData,
-				},
-			)
-		}
-		return c.JSON(http.StatusOK, common.PaginationResponse(page, perpage, data))
+		data := hc.NewHouseResponses(c, houses)
+		for i := range data {
+			data[i].DistanceKm = distanceFrom(filter.Near, houses[i])
+		}
+		return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
 	}
 }
 
@@ -134,53 +189,19 @@ func (hc HouseController) GetMyHouseController() echo.HandlerFunc {
 
 		user, _ := middleware.ExtractTokenUser(c)
 
-		house, _ := hc.Repo.GetAllMine(user.UserID)
-
-		data := []HouseResponse{}
-		for _, item := range house {
-			featuresData := []FeatureResponse{}
-			for _, feature := range item.Features {
-				featuresData = append(featuresData, FeatureResponse{
-					ID:   feature.ID,
-					Name: feature.Name,
-				})
-			}
-
-			ratingData := []rating.RatingResponse{}
-			ratings := []int{}
-
-			for _, r := range item.Ratings {
-				ratingData = append(ratingData, rating.RatingResponse{
-					HouseID:  int(r.HouseID),
-					UserID:   int(r.UserID),
-					Username: r.User.Name,
-					Rating:   r.Rating,
-					Comment:  r.Comment,
-				})
-
-				ratings = append(ratings, r.Rating)
-			}
+		page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
 
-			rating := helper.CalculateRatings(ratings)
-
-			data = append(
-				data, HouseResponse{
-					ID:       item.ID,
-					UserID:   item.User.ID,
-					UserName: item.User.Name,
-					Title:    item.Title,
-					Address:  item.Address,
-					City:     item.City,
-					Price:    item.Price,
-					Rating:   rating,
-					Status:   item.Status,
-					Features: featuresData,
-					Ratings:  ratingData,
-				},
-			)
+		house, result, err := hc.Repo.GetAllMine(user.UserID, page)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
 		}
 
-		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+		data := hc.NewHouseResponses(c, house)
+
+		return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
 	}
 }
 
@@ -192,48 +213,11 @@ func (hc HouseController) GetHouseController() echo.HandlerFunc {
 
 		house, err := hc.Repo.Get(id)
 
-		if err != nil {
+		if err != nil || house.Suspended {
 			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 		}
 
-		featuresData := []FeatureResponse{}
-		for _, feature := range house.Features {
-			featuresData = append(featuresData, FeatureResponse{
-				ID:   feature.ID,
-				Name: feature.Name,
-			})
-		}
-
-		ratingData := []rating.RatingResponse{}
-		ratings := []int{}
-
-		for _, r := range house.Ratings {
-			ratingData = append(ratingData, rating.RatingResponse{
-				HouseID:  int(r.HouseID),
-				UserID:   int(r.UserID),
-				Username: r.User.Name,
-				Rating:   r.Rating,
-				Comment:  r.Comment,
-			})
-
-			ratings = append(ratings, r.Rating)
-		}
-
-		rating := helper.CalculateRatings(ratings)
-
-		data := HouseResponse{
-			ID:       house.ID,
-			UserID:   house.User.ID,
-			UserName: house.User.Name,
-			Title:    house.Title,
-			Address:  house.Address,
-			City:     house.City,
-			Price:    house.Price,
-			Rating:   rating,
-			Status:   house.Status,
-			Features: featuresData,
-			Ratings:  ratingData,
-		}
+		data := hc.NewHouseResponses(c, []model.House{house})[0]
 
 		return c.JSON(http.StatusOK, common.SuccessResponse(data))
 	}
@@ -250,12 +234,31 @@ func (hc HouseController) UpdateHouseController() echo.HandlerFunc {
 			return err
 		}
 
+		if putHouseReq.CancellationPolicy != "" && !helper.IsValidCancellationPolicy(putHouseReq.CancellationPolicy) {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		if putHouseReq.MaxGuests < 0 || !putHouseReq.HouseRulesRequestFormat.isValid(putHouseReq.MinStay) {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		if !isValidLocation(putHouseReq.Latitude, putHouseReq.Longitude) {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Latitude and longitude must be set together and be valid coordinates!"))
+		}
+
 		newHouse := model.House{
-			Title:   putHouseReq.Title,
-			Address: putHouseReq.Address,
-			City:    putHouseReq.City,
-			Price:   putHouseReq.Price,
-			Status:  putHouseReq.Status,
+			Title:              putHouseReq.Title,
+			Address:            putHouseReq.Address,
+			City:               putHouseReq.City,
+			Price:              putHouseReq.Price,
+			Status:             putHouseReq.Status,
+			CancellationPolicy: putHouseReq.CancellationPolicy,
+			MinStay:            putHouseReq.MinStay,
+			MaxGuests:          putHouseReq.MaxGuests,
+			CleaningFee:        putHouseReq.CleaningFee,
+			InstantBook:        putHouseReq.InstantBook,
+			Latitude:           putHouseReq.Latitude,
+			Longitude:          putHouseReq.Longitude,
 		}
 
 		houseData, _ := hc.Repo.Get(id)
@@ -263,6 +266,11 @@ func (hc HouseController) UpdateHouseController() echo.HandlerFunc {
 			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 		}
 
+		newHouse.PetsAllowed = houseData.PetsAllowed
+		newHouse.SmokingAllowed = houseData.SmokingAllowed
+		newHouse.EventsAllowed = houseData.EventsAllowed
+		putHouseReq.HouseRulesRequestFormat.apply(&newHouse)
+
 		err = hc.Repo.HouseHasFeatureDelete(id)
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
@@ -307,3 +315,691 @@ func (hc HouseController) DeleteHouseController() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 	}
 }
+
+func (hc HouseController) GetAvailabilityController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		house, err := hc.Repo.Get(id)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+
+		from := today
+		if c.QueryParam("from") != "" {
+			from, err = time.Parse("2006-01-02", c.QueryParam("from"))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid from date!"))
+			}
+		}
+
+		to := from.AddDate(0, 0, 30)
+		if c.QueryParam("to") != "" {
+			to, err = time.Parse("2006-01-02", c.QueryParam("to"))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid to date!"))
+			}
+		}
+
+		// keep the calendar to at most one year per request
+		if to.Before(from) || to.After(from.AddDate(1, 0, 0)) {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "To date must be after from date and within one year!"))
+		}
+
+		minStay := house.MinStay
+		if minStay < 1 {
+			minStay = 1
+		}
+
+		// look a bit beyond the range so gaps at the edges are measured correctly
+		bookings, err := hc.Repo.GetBookings(id, from.AddDate(0, 0, -minStay), to.AddDate(0, 0, minStay))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		blockedDates, err := hc.Repo.GetBlockedDates(id, from.AddDate(0, 0, -minStay), to.AddDate(0, 0, minStay))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		days := []CalendarDayResponse{}
+		for _, day := range helper.BuildCalendar(from, to, minStay, bookings, blockedDates) {
+			days = append(days, CalendarDayResponse{
+				Date:   day.Date.Format("2006-01-02"),
+				Status: day.Status,
+			})
+		}
+
+		data := AvailabilityResponse{
+			HouseID: house.ID,
+			From:    from.Format("2006-01-02"),
+			To:      to.Format("2006-01-02"),
+			MinStay: minStay,
+			Days:    days,
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (hc HouseController) BlockDateController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		blockDateReq := BlockDateRequestFormat{}
+		c.Bind(&blockDateReq)
+
+		if err := c.Validate(blockDateReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		startDate, err := time.Parse(time.RFC3339, blockDateReq.StartDate+"T00:00:00.000Z")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid start date!"))
+		}
+
+		endDate, err := time.Parse(time.RFC3339, blockDateReq.EndDate+"T00:00:00.000Z")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid end date!"))
+		}
+
+		if endDate.Before(startDate) {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "End date must not before start date!"))
+		}
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		blockedDate, err := hc.Repo.CreateBlockedDate(model.HouseBlockedDate{
+			HouseID:   houseData.ID,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Reason:    blockDateReq.Reason,
+		})
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		data := BlockedDateResponse{
+			ID:        blockedDate.ID,
+			HouseID:   blockedDate.HouseID,
+			StartDate: blockedDate.StartDate.Format("2006-01-02"),
+			EndDate:   blockedDate.EndDate.Format("2006-01-02"),
+			Reason:    blockedDate.Reason,
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (hc HouseController) UnblockDateController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+		blockId, _ := strconv.Atoi(c.Param("blockId"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		_, err = hc.Repo.DeleteBlockedDate(id, blockId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+func (hc HouseController) GetQuoteController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		checkinDate, err := time.Parse(time.RFC3339, c.QueryParam("checkin_date")+"T00:00:00.000Z")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid checkin date!"))
+		}
+
+		checkoutDate, err := time.Parse(time.RFC3339, c.QueryParam("checkout_date")+"T00:00:00.000Z")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid checkout date!"))
+		}
+
+		if !checkoutDate.After(checkinDate) {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Checkout date must after checkin date!"))
+		}
+
+		house, err := hc.Repo.Get(id)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		quote := helper.CalculateQuote(house.Price, checkinDate, checkoutDate, house.PricingRules)
+
+		nights := []NightPriceResponse{}
+		for _, night := range quote.Nights {
+			nights = append(nights, NightPriceResponse{
+				Date:     night.Date.Format("2006-01-02"),
+				Price:    night.Price,
+				Seasonal: night.Seasonal,
+				Weekend:  night.Weekend,
+			})
+		}
+
+		data := QuoteResponse{
+			HouseID:            house.ID,
+			CheckinDate:        checkinDate.Format("2006-01-02"),
+			CheckoutDate:       checkoutDate.Format("2006-01-02"),
+			TotalNight:         len(quote.Nights),
+			Nights:             nights,
+			Subtotal:           quote.Subtotal,
+			DiscountPercentage: quote.DiscountPercentage,
+			Discount:           quote.Discount,
+			Total:              quote.Total,
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (hc HouseController) GetPricingRulesController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+
+		pricingRules, err := hc.Repo.GetPricingRules(id)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		data := []PricingRuleResponse{}
+		for _, item := range pricingRules {
+			data = append(data, newPricingRuleResponse(item))
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (hc HouseController) CreatePricingRuleController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		pricingRuleReq := PricingRuleRequestFormat{}
+		c.Bind(&pricingRuleReq)
+
+		if err := c.Validate(pricingRuleReq); err != nil || !helper.IsValidPricingRule(pricingRuleReq.Type) {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		pricingRule := model.HousePricingRule{
+			Type:       pricingRuleReq.Type,
+			Price:      pricingRuleReq.Price,
+			Percentage: pricingRuleReq.Percentage,
+			MinNights:  pricingRuleReq.MinNights,
+		}
+
+		switch pricingRuleReq.Type {
+		case helper.WEEKEND_RULE:
+			if pricingRuleReq.Percentage <= 0 {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Weekend percentage must be greater than 0!"))
+			}
+		case helper.SEASONAL_RULE:
+			pricingRule.StartDate, err = time.Parse(time.RFC3339, pricingRuleReq.StartDate+"T00:00:00.000Z")
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid start date!"))
+			}
+
+			pricingRule.EndDate, err = time.Parse(time.RFC3339, pricingRuleReq.EndDate+"T00:00:00.000Z")
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid end date!"))
+			}
+
+			if pricingRuleReq.Price <= 0 || pricingRule.EndDate.Before(pricingRule.StartDate) {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Seasonal price and date range are required!"))
+			}
+		case helper.LENGTH_OF_STAY_RULE:
+			if pricingRuleReq.MinNights < 2 || pricingRuleReq.Percentage <= 0 || pricingRuleReq.Percentage > 100 {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Min nights must be at least 2 and percentage between 0 and 100!"))
+			}
+		}
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		pricingRule.HouseID = houseData.ID
+
+		pricingRule, err = hc.Repo.CreatePricingRule(pricingRule)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(newPricingRuleResponse(pricingRule)))
+	}
+}
+
+func (hc HouseController) DeletePricingRuleController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+		ruleId, _ := strconv.Atoi(c.Param("ruleId"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		_, err = hc.Repo.DeletePricingRule(id, ruleId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+func (hc HouseController) UploadPhotoController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		form, err := c.MultipartForm()
+		if err != nil || len(form.File["photos"]) == 0 {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Upload at least one file in the photos field!"))
+		}
+		if len(form.File["photos"]) > constant.PHOTO_MAX_FILES {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, fmt.Sprintf("Upload at most %v photos at once!", constant.PHOTO_MAX_FILES)))
+		}
+
+		// check every file before storing any so a bad one rejects the batch
+		uploads := []photoUpload{}
+		for _, fileHeader := range form.File["photos"] {
+			if fileHeader.Size > constant.PHOTO_MAX_SIZE {
+				return c.JSON(http.StatusRequestEntityTooLarge, common.ErrorResponse(http.StatusRequestEntityTooLarge, fmt.Sprintf("%v is larger than %v MB!", fileHeader.Filename, constant.PHOTO_MAX_SIZE>>20)))
+			}
+
+			file, err := fileHeader.Open()
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+			}
+			content, err := io.ReadAll(io.LimitReader(file, constant.PHOTO_MAX_SIZE+1))
+			file.Close()
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+			}
+			if int64(len(content)) > constant.PHOTO_MAX_SIZE {
+				return c.JSON(http.StatusRequestEntityTooLarge, common.ErrorResponse(http.StatusRequestEntityTooLarge, fmt.Sprintf("%v is larger than %v MB!", fileHeader.Filename, constant.PHOTO_MAX_SIZE>>20)))
+			}
+
+			// trust the bytes, not the content type the client sent
+			contentType := http.DetectContentType(content)
+			extension, ok := helper.PHOTO_CONTENT_TYPES[contentType]
+			if !ok {
+				return c.JSON(http.StatusUnsupportedMediaType, common.ErrorResponse(http.StatusUnsupportedMediaType, fmt.Sprintf("%v must be a jpeg or png image!", fileHeader.Filename)))
+			}
+
+			thumbnail, err := helper.GenerateThumbnail(bytes.NewReader(content), helper.THUMBNAIL_WIDTH)
+			if err == helper.ErrImageTooLarge {
+				return c.JSON(http.StatusRequestEntityTooLarge, common.ErrorResponse(http.StatusRequestEntityTooLarge, fmt.Sprintf("%v is larger than %v megapixels!", fileHeader.Filename, helper.PHOTO_MAX_PIXELS/1000000)))
+			}
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, fmt.Sprintf("%v is not a valid image!", fileHeader.Filename)))
+			}
+
+			uploads = append(uploads, photoUpload{
+				contentType: contentType,
+				extension:   extension,
+				content:     content,
+				thumbnail:   thumbnail,
+			})
+		}
+
+		data := []PhotoResponse{}
+		for _, upload := range uploads {
+			name := uuid.New().String()
+			key := fmt.Sprintf("houses/%v/%v%v", houseData.ID, name, upload.extension)
+			thumbnailKey := fmt.Sprintf("houses/%v/%v_thumb.jpg", houseData.ID, name)
+
+			if err := hc.Storage.Save(key, bytes.NewReader(upload.content)); err != nil {
+				return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+			}
+			if err := hc.Storage.Save(thumbnailKey, bytes.NewReader(upload.thumbnail)); err != nil {
+				hc.Storage.Delete(key)
+				return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+			}
+
+			photo, err := hc.Repo.CreatePhoto(model.HousePhoto{
+				HouseID:      houseData.ID,
+				Key:          key,
+				ThumbnailKey: thumbnailKey,
+				ContentType:  upload.contentType,
+				Size:         int64(len(upload.content)),
+			})
+			if err != nil {
+				hc.Storage.Delete(key)
+				hc.Storage.Delete(thumbnailKey)
+				return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+			}
+
+			data = append(data, hc.newPhotoResponse(photo))
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (hc HouseController) ReorderPhotosController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		reorderReq := ReorderPhotosRequestFormat{}
+		c.Bind(&reorderReq)
+
+		if err := c.Validate(reorderReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		photos, err := hc.Repo.ReorderPhotos(id, reorderReq.PhotoIDs)
+		if err == house.ErrInvalidPhotoOrder {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Photo order must list every photo of the house once!"))
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(hc.newPhotoResponses(photos)))
+	}
+}
+
+func (hc HouseController) SetCoverPhotoController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+		photoId, _ := strconv.Atoi(c.Param("photoId"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		photo, err := hc.Repo.SetCoverPhoto(id, photoId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(hc.newPhotoResponse(photo)))
+	}
+}
+
+func (hc HouseController) DeletePhotoController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, _ := strconv.Atoi(c.Param("id"))
+		photoId, _ := strconv.Atoi(c.Param("photoId"))
+
+		user, _ := middleware.ExtractTokenUser(c)
+
+		houseData, err := hc.Repo.Get(id)
+		if err != nil || houseData.UserID != uint(user.UserID) {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		photo, err := hc.Repo.DeletePhoto(id, photoId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		}
+
+		// the row is gone already, a file left behind only wastes disk
+		if err := hc.Storage.Delete(photo.Key); err != nil {
+			c.Logger().Error(err)
+		}
+		if err := hc.Storage.Delete(photo.ThumbnailKey); err != nil {
+			c.Logger().Error(err)
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+type photoUpload struct {
+	contentType string
+	extension   string
+	content     []byte
+	thumbnail   []byte
+}
+
+func (hc HouseController) newPhotoResponse(photo model.HousePhoto) PhotoResponse {
+	return PhotoResponse{
+		ID:           photo.ID,
+		URL:          hc.Storage.URL(photo.Key),
+		ThumbnailURL: hc.Storage.URL(photo.ThumbnailKey),
+		Position:     photo.Position,
+		IsCover:      photo.IsCover,
+	}
+}
+
+func (hc HouseController) newPhotoResponses(photos []model.HousePhoto) []PhotoResponse {
+	sort.Slice(photos, func(i, j int) bool { return photos[i].Position < photos[j].Position })
+
+	data := []PhotoResponse{}
+	for _, photo := range photos {
+		data = append(data, hc.newPhotoResponse(photo))
+	}
+
+	return data
+}
+
+// parseHouseFilter reads the search query. lat and lng search around a
+// point, within radius_km when given. min_lat, max_lat, min_lng and max_lng
+// search inside a map area. features is a comma separated list of feature
+// ids the house must all have.
+func parseHouseFilter(c echo.Context) (house.HouseFilter, error) {
+	filter := house.HouseFilter{
+		Search: c.QueryParam("search"),
+		City:   c.QueryParam("city"),
+		Sort:   c.QueryParam("sort"),
+	}
+
+	if c.QueryParam("lat") != "" || c.QueryParam("lng") != "" {
+		latitude, latErr := strconv.ParseFloat(c.QueryParam("lat"), 64)
+		longitude, lngErr := strconv.ParseFloat(c.QueryParam("lng"), 64)
+		if latErr != nil || lngErr != nil || !helper.IsValidCoordinate(latitude, longitude) {
+			return filter, errors.New("lat and lng must be valid coordinates!")
+		}
+
+		filter.Near = &house.GeoPoint{Latitude: latitude, Longitude: longitude}
+		filter.RadiusKm = helper.DEFAULT_SEARCH_RADIUS_KM
+
+		if c.QueryParam("radius_km") != "" {
+			radius, err := strconv.ParseFloat(c.QueryParam("radius_km"), 64)
+			if err != nil || radius <= 0 || radius > helper.MAX_SEARCH_RADIUS_KM {
+				return filter, fmt.Errorf("radius_km must be between 0 and %v!", helper.MAX_SEARCH_RADIUS_KM)
+			}
+			filter.RadiusKm = radius
+		}
+	} else if c.QueryParam("radius_km") != "" {
+		return filter, errors.New("radius_km needs lat and lng!")
+	}
+
+	boundsParams := []string{c.QueryParam("min_lat"), c.QueryParam("max_lat"), c.QueryParam("min_lng"), c.QueryParam("max_lng")}
+	if strings.Join(boundsParams, "") != "" {
+		bounds := [4]float64{}
+		for i, param := range boundsParams {
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return filter, errors.New("min_lat, max_lat, min_lng and max_lng must all be set!")
+			}
+			bounds[i] = value
+		}
+
+		if !helper.IsValidCoordinate(bounds[0], bounds[2]) || !helper.IsValidCoordinate(bounds[1], bounds[3]) || bounds[0] > bounds[1] {
+			return filter, errors.New("min_lat, max_lat, min_lng and max_lng must be valid coordinates!")
+		}
+
+		filter.Bounds = &house.GeoBounds{
+			MinLatitude:  bounds[0],
+			MaxLatitude:  bounds[1],
+			MinLongitude: bounds[2],
+			MaxLongitude: bounds[3],
+		}
+	}
+
+	if c.QueryParam("checkin_date") != "" || c.QueryParam("checkout_date") != "" {
+		checkinDate, checkinErr := time.Parse(time.RFC3339, c.QueryParam("checkin_date")+"T00:00:00.000Z")
+		checkoutDate, checkoutErr := time.Parse(time.RFC3339, c.QueryParam("checkout_date")+"T00:00:00.000Z")
+		if checkinErr != nil || checkoutErr != nil {
+			return filter, errors.New("checkin_date and checkout_date must both be set as YYYY-MM-DD!")
+		}
+
+		if !checkoutDate.After(checkinDate) {
+			return filter, errors.New("Checkout date must after checkin date!")
+		}
+
+		filter.CheckinDate = checkinDate
+		filter.CheckoutDate = checkoutDate
+	}
+
+	if c.QueryParam("guests") != "" {
+		guests, err := strconv.Atoi(c.QueryParam("guests"))
+		if err != nil || guests < 1 {
+			return filter, errors.New("guests must be at least 1!")
+		}
+		filter.Guests = guests
+	}
+
+	for param, value := range map[string]*float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if c.QueryParam(param) == "" {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(c.QueryParam(param), 64)
+		if err != nil || price < 0 {
+			return filter, fmt.Errorf("%v must be a positive number!", param)
+		}
+		*value = price
+	}
+
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return filter, errors.New("min_price must not be above max_price!")
+	}
+
+	if c.QueryParam("features") != "" {
+		for _, param := range strings.Split(c.QueryParam("features"), ",") {
+			featureId, err := strconv.Atoi(strings.TrimSpace(param))
+			if err != nil || featureId < 1 {
+				return filter, errors.New("features must be a comma separated list of feature ids!")
+			}
+			filter.FeatureIDs = append(filter.FeatureIDs, featureId)
+		}
+	}
+
+	if c.QueryParam("min_rating") != "" {
+		minRating, err := strconv.ParseFloat(c.QueryParam("min_rating"), 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			return filter, errors.New("min_rating must be between 0 and 5!")
+		}
+		filter.MinRating = minRating
+	}
+
+	if filter.Sort != "" && !helper.IsValidHouseSort(filter.Sort) {
+		return filter, errors.New("sort must be price, rating, newest or distance!")
+	}
+
+	if filter.Sort == helper.DISTANCE_SORT && filter.Near == nil {
+		return filter, errors.New("sort by distance needs lat and lng!")
+	}
+
+	return filter, nil
+}
+
+func isValidLocation(latitude, longitude *float64) bool {
+	if latitude == nil && longitude == nil {
+		return true
+	}
+
+	return latitude != nil && longitude != nil && helper.IsValidCoordinate(*latitude, *longitude)
+}
+
+// distanceFrom returns the distance in km rounded to meters, or nil when the
+// search has no point or the house has no location.
+func distanceFrom(near *house.GeoPoint, item model.House) *float64 {
+	if near == nil || item.Latitude == nil || item.Longitude == nil {
+		return nil
+	}
+
+	distance := math.Round(helper.CalculateDistance(near.Latitude, near.Longitude, *item.Latitude, *item.Longitude)*1000) / 1000
+	return &distance
+}
+
+func newRatingSummaryResponse(summary model.RatingSummary) RatingSummaryResponse {
+	return RatingSummaryResponse{
+		Count:         summary.Count,
+		Cleanliness:   summary.Cleanliness,
+		Accuracy:      summary.Accuracy,
+		Location:      summary.Location,
+		Communication: summary.Communication,
+		Value:         summary.Value,
+		Distribution: map[int]int{
+			1: summary.OneStar,
+			2: summary.TwoStar,
+			3: summary.ThreeStar,
+			4: summary.FourStar,
+			5: summary.FiveStar,
+		},
+	}
+}
+
+func newPricingRuleResponse(pricingRule model.HousePricingRule) PricingRuleResponse {
+	response := PricingRuleResponse{
+		ID:         pricingRule.ID,
+		HouseID:    pricingRule.HouseID,
+		Type:       pricingRule.Type,
+		Price:      pricingRule.Price,
+		Percentage: pricingRule.Percentage,
+		MinNights:  pricingRule.MinNights,
+	}
+
+	if pricingRule.Type == helper.SEASONAL_RULE {
+		response.StartDate = pricingRule.StartDate.Format("2006-01-02")
+		response.EndDate = pricingRule.EndDate.Format("2006-01-02")
+	}
+
+	return response
+}
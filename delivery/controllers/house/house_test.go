@@ -2,17 +2,31 @@ package house
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	hr "github.com/furqonzt99/airbnb/repository/house"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -23,6 +37,7 @@ import (
 )
 
 var jwtToken string
+var photoStorage = &mockStorage{files: map[string][]byte{}}
 
 func TestCreateHouse(t *testing.T) {
 	t.Run("Test Login", func(t *testing.T) {
@@ -41,13 +56,13 @@ func TestCreateHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/login")
 
-		userController := user.NewUsersControllers(mockUserRepository{})
+		userController := user.NewUsersControllers(mockUserRepository{}, mailer.NewOutboxMailer(""))
 		userController.LoginController()(context)
 
 		response := common.ResponseSuccess{}
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
-		jwtToken = response.Data.(string)
+		jwtToken = response.Data.(map[string]interface{})["access_token"].(string)
 		assert.Equal(t, "Successful Operation", response.Message)
 		assert.NotNil(t, jwtToken)
 	})
@@ -73,7 +88,7 @@ func TestCreateHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreateHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -105,7 +120,7 @@ func TestCreateHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses")
 
-		houseController := NewHouseControllers(mockFalseHouseRepository{})
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreateHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -131,7 +146,7 @@ func TestGetAllHouse(t *testing.T) {
 		context.SetParamNames("name")
 		context.SetParamValues("Rumah")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		houseController.GetAllHouseController()(context)
 
 		response := GetAllHouseResponseFormat{}
@@ -154,14 +169,223 @@ func TestGetAllHouse(t *testing.T) {
 		context.SetParamNames("name")
 		context.SetParamValues("Rumah")
 
-		houseController := NewHouseControllers(mockFalseHouseRepository{})
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
+		houseController.GetAllHouseController()(context)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+
+	t.Run("Test Get All House Past Last Page", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses")
+
+		houseController := NewHouseControllers(mockEmptyHouseRepository{}, photoStorage)
 		houseController.GetAllHouseController()(context)
 
 		response := GetAllHouseResponseFormat{}
 
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-		assert.Equal(t, "Successful Operation", response.Message)
-		assert.Equal(t, "Rumah Bagus", response.Data[0].Title)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 0, len(response.Data))
+	})
+}
+
+func TestSearchHouseByLocation(t *testing.T) {
+	search := func(query string) (*httptest.ResponseRecorder, common.ResponseSuccess) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetAllHouseController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return res, response
+	}
+
+	t.Run("Test Search Within Radius", func(t *testing.T) {
+		res, response := search("lat=-6.2&lng=106.816666&radius_km=10&sort=distance")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		house := response.Data.([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, -6.175392, house["latitude"])
+		assert.InDelta(t, 2.97, house["distance_km"], 0.01)
+	})
+
+	t.Run("Test Search Within Bounds", func(t *testing.T) {
+		res, response := search("min_lat=-6.3&max_lat=-6.1&min_lng=106.7&max_lng=106.9")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		house := response.Data.([]interface{})[0].(map[string]interface{})
+		assert.NotContains(t, house, "distance_km")
+	})
+
+	t.Run("Test Search Bounds Across Antimeridian", func(t *testing.T) {
+		res, _ := search("min_lat=-20&max_lat=-10&min_lng=170&max_lng=-170")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Search Invalid Coordinate", func(t *testing.T) {
+		res, _ := search("lat=-100&lng=106.816666")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Radius Too Large", func(t *testing.T) {
+		res, _ := search("lat=-6.2&lng=106.816666&radius_km=5000")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Radius Without Point", func(t *testing.T) {
+		res, _ := search("radius_km=10")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Incomplete Bounds", func(t *testing.T) {
+		res, _ := search("min_lat=-6.3&max_lat=-6.1")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Sort By Distance Without Point", func(t *testing.T) {
+		res, _ := search("sort=distance")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Create House Without Longitude", func(t *testing.T) {
+		e := echo.New()
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"title":    "Rumah Bagus",
+			"address":  "Jalan Ujung",
+			"city":     "Jakarta",
+			"price":    100000,
+			"features": []int{1},
+			"latitude": -6.2,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreateHouseController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestSearchHouseFilters(t *testing.T) {
+	search := func(query string) (*httptest.ResponseRecorder, common.ResponseCursorPagination) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetAllHouseController()(context)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return res, response
+	}
+
+	t.Run("Test Search With Filters", func(t *testing.T) {
+		res, response := search("checkin_date=2021-12-20&checkout_date=2021-12-23&guests=2&min_price=50000&max_price=200000&features=1,2&min_rating=4&sort=price&limit=10")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(21), response.Total)
+		assert.True(t, response.HasMore)
+	})
+
+	t.Run("Test Search Next Page", func(t *testing.T) {
+		_, first := search("sort=price&limit=10")
+		res, response := search("sort=price&limit=10&cursor=" + first.NextCursor)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		cursor, _ := pagination.DecodeCursor(response.NextCursor)
+		assert.Equal(t, 20, cursor.Offset)
+	})
+
+	t.Run("Error Test Search Invalid Cursor", func(t *testing.T) {
+		res, _ := search("cursor=abc")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Limit Too Large", func(t *testing.T) {
+		res, _ := search("limit=1000")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Checkin Without Checkout", func(t *testing.T) {
+		res, _ := search("checkin_date=2021-12-20")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Checkout Before Checkin", func(t *testing.T) {
+		res, _ := search("checkin_date=2021-12-23&checkout_date=2021-12-20")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Invalid Guests", func(t *testing.T) {
+		res, _ := search("guests=0")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Min Price Above Max Price", func(t *testing.T) {
+		res, _ := search("min_price=300000&max_price=200000")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Invalid Features", func(t *testing.T) {
+		res, _ := search("features=1,wifi")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Invalid Min Rating", func(t *testing.T) {
+		res, _ := search("min_rating=6")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Search Invalid Sort", func(t *testing.T) {
+		res, _ := search("sort=cheapest")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
 	})
 }
 
@@ -178,7 +402,7 @@ func TestGetMyHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/myhouses")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.GetMyHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -204,7 +428,7 @@ func TestGetHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses/:id")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		houseController.GetHouseController()(context)
 
 		response := GetHouseResponseFormat{}
@@ -214,6 +438,89 @@ func TestGetHouse(t *testing.T) {
 		assert.Equal(t, "Rumah Bagus", response.Data.Title)
 	})
 
+	t.Run("Test Get House Rating Summary", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetHouseController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, 4.6, data["rating"])
+
+		summary := data["rating_summary"].(map[string]interface{})
+		assert.Equal(t, float64(5), summary["count"])
+		assert.Equal(t, 4.5, summary["cleanliness"])
+		assert.Equal(t, map[string]interface{}{"1": float64(0), "2": float64(0), "3": float64(0), "4": float64(2), "5": float64(3)}, summary["distribution"])
+	})
+
+	t.Run("Test Get House Saved By Caller", func(t *testing.T) {
+		token, _ := mw.CreateToken(1, "guest@gmail.com", constant.GUEST_ROLE, 1)
+
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		mw.OptionalJWT()(houseController.GetHouseController())(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["is_saved"])
+	})
+
+	t.Run("Test Get House Anonymously", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		mw.OptionalJWT()(houseController.GetHouseController())(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, false, response.Data.(map[string]interface{})["is_saved"])
+	})
+
+	t.Run("Error Test Get House Invalid Token", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", "Bearer invalid")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		err := mw.OptionalJWT()(houseController.GetHouseController())(context)
+
+		assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+	})
+
 	t.Run("Error Test Get House", func(t *testing.T) {
 		e := echo.New()
 
@@ -225,7 +532,7 @@ func TestGetHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses/:id")
 
-		houseController := NewHouseControllers(mockFalseHouseRepository{})
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
 		houseController.GetHouseController()(context)
 
 		response := GetHouseResponseFormat{}
@@ -235,6 +542,92 @@ func TestGetHouse(t *testing.T) {
 	})
 }
 
+func TestHouseRules(t *testing.T) {
+	create := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		body["title"] = "Rumah Bagus"
+		body["address"] = "Jalan Ujung"
+		body["city"] = "Indonesia"
+		body["price"] = 100000
+		body["features"] = []int{1}
+		requestBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreateHouseController())(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	t.Run("Test Create House With Rules", func(t *testing.T) {
+		res := create(map[string]interface{}{
+			"max_guests":       4,
+			"bedrooms":         2,
+			"bathrooms":        1.5,
+			"min_stay":         2,
+			"max_stay":         14,
+			"checkin_time":     "14:00",
+			"checkout_time":    "10:30",
+			"pets_allowed":     true,
+			"additional_rules": "No parties after 22:00",
+		})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Create House Invalid Checkin Time", func(t *testing.T) {
+		res := create(map[string]interface{}{"checkin_time": "3pm"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Create House Max Stay Below Min Stay", func(t *testing.T) {
+		res := create(map[string]interface{}{"min_stay": 7, "max_stay": 3})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Create House Negative Bedrooms", func(t *testing.T) {
+		res := create(map[string]interface{}{"bedrooms": -1})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Test Get House Rules", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetHouseController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(2), data["bedrooms"])
+		assert.Equal(t, "14:00", data["checkin_time"])
+		assert.Equal(t, true, data["pets_allowed"])
+		assert.Equal(t, false, data["smoking_allowed"])
+	})
+}
+
 func TestUpdateHouse(t *testing.T) {
 	t.Run("Test Update House", func(t *testing.T) {
 		e := echo.New()
@@ -259,7 +652,7 @@ func TestUpdateHouse(t *testing.T) {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.UpdateHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -292,7 +685,7 @@ func TestUpdateHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses/:id")
 
-		houseController := NewHouseControllers(mockFalseHouseRepository{})
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.UpdateHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -319,7 +712,7 @@ func TestDeleteHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses/:id")
 
-		houseController := NewHouseControllers(mockHouseRepository{})
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.DeleteHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -344,7 +737,7 @@ func TestDeleteHouse(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/houses/:id")
 
-		houseController := NewHouseControllers(mockFalseHouseRepository{})
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.DeleteHouseController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -357,39 +750,738 @@ func TestDeleteHouse(t *testing.T) {
 	})
 }
 
-type mockUserRepository struct{}
+func TestAvailability(t *testing.T) {
+	t.Run("Test Get Availability", func(t *testing.T) {
+		e := echo.New()
 
-func (m mockUserRepository) Register(newUser model.User) (model.User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(newUser.Password), 14)
-	return model.User{Email: newUser.Email, Password: string(hash), Name: newUser.Name}, nil
-}
+		req := httptest.NewRequest(http.MethodGet, "/?from=2030-01-01&to=2030-01-10", nil)
+		res := httptest.NewRecorder()
 
-func (m mockUserRepository) Login(email string) (model.User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte("test1234"), 14)
-	return model.User{
-		Model: gorm.Model{
-			ID: 1,
-		},
-		Name:     "tester",
-		Email:    "test@gmail.com",
-		Password: string(hash),
-	}, nil
-}
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/availability")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
 
-func (m mockUserRepository) Get(userid int) (model.User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte("test1234"), 14)
-	return model.User{Email: "test@gmail.com", Password: string(hash), Name: "tester"}, nil
-}
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetAvailabilityController()(context)
 
-func (m mockUserRepository) Update(newUser model.User, userId int) (model.User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
-	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
-}
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
-func (m mockUserRepository) Delete(userId int) (model.User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
-	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
-}
+		days := response.Data.(map[string]interface{})["days"].([]interface{})
+		status := map[string]string{}
+		for _, day := range days {
+			status[day.(map[string]interface{})["date"].(string)] = day.(map[string]interface{})["status"].(string)
+		}
+
+		assert.Equal(t, "Successful Operation", response.Message)
+		assert.Equal(t, 10, len(days))
+		assert.Equal(t, "available", status["2030-01-02"])
+		assert.Equal(t, "booked", status["2030-01-03"])
+		assert.Equal(t, "booked", status["2030-01-04"])
+		assert.Equal(t, "min_stay_violation", status["2030-01-05"])
+		assert.Equal(t, "min_stay_violation", status["2030-01-06"])
+		assert.Equal(t, "blocked", status["2030-01-07"])
+		assert.Equal(t, "available", status["2030-01-08"])
+	})
+
+	t.Run("Error Test Get Availability Invalid Range", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/?from=2030-01-10&to=2030-01-01", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/availability")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetAvailabilityController()(context)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Get Availability Not Found", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/availability")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
+		houseController.GetAvailabilityController()(context)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestBlockDate(t *testing.T) {
+	t.Run("Test Block Date", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"start_date": "2030-01-07",
+			"end_date":   "2030-01-08",
+			"reason":     "maintenance",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/blocked-dates")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.BlockDateController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Successful Operation", response.Message)
+		assert.Equal(t, "2030-01-08", response.Data.(map[string]interface{})["end_date"])
+	})
+
+	t.Run("Error Test Block Date End Before Start", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"start_date": "2030-01-07",
+			"end_date":   "2030-01-05",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/blocked-dates")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.BlockDateController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Test Unblock Date", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/blocked-dates/:blockId")
+		context.SetParamNames("id", "blockId")
+		context.SetParamValues("1", "1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.UnblockDateController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.DefaultResponse{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Successful Operation", response.Message)
+	})
+
+	t.Run("Error Test Unblock Date", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/blocked-dates/:blockId")
+		context.SetParamNames("id", "blockId")
+		context.SetParamValues("1", "1")
+
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.UnblockDateController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.DefaultResponse{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Not Found", response.Message)
+	})
+}
+
+type mockUserRepository struct{}
+
+func (m mockUserRepository) Register(newUser model.User) (model.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(newUser.Password), 14)
+	return model.User{Email: newUser.Email, Password: string(hash), Name: newUser.Name}, nil
+}
+
+func (m mockUserRepository) Login(email string) (model.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test1234"), 14)
+	return model.User{
+		Model: gorm.Model{
+			ID: 1,
+		},
+		Name:     "tester",
+		Email:    "test@gmail.com",
+		Password: string(hash),
+	}, nil
+}
+
+func (m mockUserRepository) Get(userid int) (model.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test1234"), 14)
+	return model.User{Email: "test@gmail.com", Password: string(hash), Name: "tester"}, nil
+}
+
+func (m mockUserRepository) Update(newUser model.User, userId int) (model.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
+	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
+}
+
+func (m mockUserRepository) Delete(userId int) (model.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
+	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
+}
+
+func (m mockUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return []model.User{{Email: "test@gmail.com", Name: "tester", Role: "guest"}}, pagination.Result{}, nil
+}
+
+func (m mockUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", Role: "guest", Suspended: suspended}, nil
+}
+
+func (m mockUserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	refreshToken.ID = 1
+	return refreshToken, nil
+}
+
+func (m mockUserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	return model.RefreshToken{}, errors.New("record not found")
+}
+
+func (m mockUserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	next.ID = prev.ID + 1
+	return next, nil
+}
+
+func (m mockUserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return nil
+}
+
+func (m mockUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return nil
+}
+
+func (m mockUserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	return true
+}
+
+func (m mockUserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	userToken.ID = 1
+	return userToken, nil
+}
+
+func (m mockUserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
+func (m mockUserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
+func TestQuote(t *testing.T) {
+	t.Run("Test Get Quote", func(t *testing.T) {
+		e := echo.New()
+
+		// friday to monday: two weekend nights and a length of stay discount
+		req := httptest.NewRequest(http.MethodGet, "/?checkin_date=2030-01-04&checkout_date=2030-01-07", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/quote")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetQuoteController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+		nights := data["nights"].([]interface{})
+
+		assert.Equal(t, "Successful Operation", response.Message)
+		assert.Equal(t, 3, len(nights))
+		assert.Equal(t, float64(150000), nights[0].(map[string]interface{})["price"])
+		assert.Equal(t, true, nights[1].(map[string]interface{})["weekend"])
+		assert.Equal(t, float64(100000), nights[2].(map[string]interface{})["price"])
+		assert.Equal(t, float64(400000), data["subtotal"])
+		assert.Equal(t, float64(40000), data["discount"])
+		assert.Equal(t, float64(360000), data["total"])
+	})
+
+	t.Run("Error Test Get Quote Invalid Date", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/?checkin_date=2030-01-07&checkout_date=2030-01-04", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/quote")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetQuoteController()(context)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Get Quote Not Found", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/?checkin_date=2030-01-04&checkout_date=2030-01-07", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/quote")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
+		houseController.GetQuoteController()(context)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestPricingRule(t *testing.T) {
+	t.Run("Test Create Pricing Rule", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"type":       "seasonal",
+			"price":      250000,
+			"start_date": "2030-12-20",
+			"end_date":   "2030-12-31",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreatePricingRuleController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Successful Operation", response.Message)
+		assert.Equal(t, "2030-12-31", response.Data.(map[string]interface{})["end_date"])
+	})
+
+	t.Run("Error Test Create Pricing Rule Invalid Type", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"type":       "holiday",
+			"percentage": 20,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreatePricingRuleController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Create Pricing Rule Invalid Length Of Stay", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"type":       "length_of_stay",
+			"percentage": 10,
+			"min_nights": 1,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.CreatePricingRuleController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Test Get Pricing Rules", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetPricingRulesController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Successful Operation", response.Message)
+		assert.Equal(t, 1, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Test Delete Pricing Rule", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules/:ruleId")
+		context.SetParamNames("id", "ruleId")
+		context.SetParamValues("1", "1")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.DeletePricingRuleController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Delete Pricing Rule", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/pricing-rules/:ruleId")
+		context.SetParamNames("id", "ruleId")
+		context.SetParamValues("1", "1")
+
+		houseController := NewHouseControllers(mockFalseHouseRepository{}, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.DeletePricingRuleController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestPhoto(t *testing.T) {
+	upload := func(houseRepo hr.HouseInterface, files map[string][]byte) *httptest.ResponseRecorder {
+		e := echo.New()
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, content := range files {
+			part, _ := writer.CreateFormFile("photos", name)
+			part.Write(content)
+		}
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/photos")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(houseRepo, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.UploadPhotoController())(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for x := 0; x < 640; x++ {
+		img.Set(x, x%480, color.RGBA{R: 255, A: 255})
+	}
+	pngFile := &bytes.Buffer{}
+	png.Encode(pngFile, img)
+
+	t.Run("Test Upload Photo", func(t *testing.T) {
+		res := upload(mockHouseRepository{}, map[string][]byte{"house.png": pngFile.Bytes()})
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		photo := response.Data.([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, true, strings.HasSuffix(photo["url"].(string), ".png"))
+		assert.Equal(t, true, strings.HasSuffix(photo["thumbnail_url"].(string), "_thumb.jpg"))
+
+		thumbnailKey := strings.TrimPrefix(photo["thumbnail_url"].(string), "http://localhost/uploads/")
+		thumbnail, _, err := image.Decode(bytes.NewReader(photoStorage.files[thumbnailKey]))
+		assert.Nil(t, err)
+		assert.Equal(t, helper.THUMBNAIL_WIDTH, thumbnail.Bounds().Dx())
+		assert.Equal(t, 240, thumbnail.Bounds().Dy())
+	})
+
+	t.Run("Error Test Upload Photo Unsupported Type", func(t *testing.T) {
+		res := upload(mockHouseRepository{}, map[string][]byte{"house.png": []byte("not an image at all")})
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	})
+
+	t.Run("Error Test Upload Photo Too Large", func(t *testing.T) {
+		maxSize := constant.PHOTO_MAX_SIZE
+		constant.PHOTO_MAX_SIZE = 100
+		defer func() { constant.PHOTO_MAX_SIZE = maxSize }()
+
+		res := upload(mockHouseRepository{}, map[string][]byte{"house.png": pngFile.Bytes()})
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("Error Test Upload Photo Too Many Pixels", func(t *testing.T) {
+		// a png header declaring 50000x50000 pixels, a few bytes that would
+		// decode to gigabytes
+		header := make([]byte, 13)
+		binary.BigEndian.PutUint32(header[0:], 50000)
+		binary.BigEndian.PutUint32(header[4:], 50000)
+		header[8], header[9] = 8, 2
+
+		bomb := &bytes.Buffer{}
+		bomb.WriteString("\x89PNG\r\n\x1a\n")
+		binary.Write(bomb, binary.BigEndian, uint32(len(header)))
+		bomb.WriteString("IHDR")
+		bomb.Write(header)
+		binary.Write(bomb, binary.BigEndian, crc32.ChecksumIEEE(append([]byte("IHDR"), header...)))
+
+		res := upload(mockHouseRepository{}, map[string][]byte{"bomb.png": bomb.Bytes()})
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("Error Test Upload Photo Too Many Files", func(t *testing.T) {
+		files := map[string][]byte{}
+		for i := 0; i <= constant.PHOTO_MAX_FILES; i++ {
+			files[fmt.Sprintf("house%v.png", i)] = pngFile.Bytes()
+		}
+
+		res := upload(mockHouseRepository{}, files)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Upload Photo No File", func(t *testing.T) {
+		res := upload(mockHouseRepository{}, map[string][]byte{})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Upload Photo Not Found", func(t *testing.T) {
+		res := upload(mockFalseHouseRepository{}, map[string][]byte{"house.png": pngFile.Bytes()})
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Test Get House Photos In Order", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id")
+
+		houseController := NewHouseControllers(mockHouseRepository{}, photoStorage)
+		houseController.GetHouseController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		photos := response.Data.(map[string]interface{})["photos"].([]interface{})
+		assert.Equal(t, 2, len(photos))
+		assert.Equal(t, "http://localhost/uploads/houses/1/cover.jpg", photos[0].(map[string]interface{})["url"])
+		assert.Equal(t, true, photos[0].(map[string]interface{})["is_cover"])
+	})
+
+	reorder := func(houseRepo hr.HouseInterface, photoIds []int) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.Validator = &HouseValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]interface{}{
+			"photo_ids": photoIds,
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/photos/order")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		houseController := NewHouseControllers(houseRepo, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(houseController.ReorderPhotosController())(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	t.Run("Test Reorder Photos", func(t *testing.T) {
+		res := reorder(mockHouseRepository{}, []int{1, 2})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Reorder Photos Missing Photo", func(t *testing.T) {
+		res := reorder(mockHouseRepository{}, []int{1})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Reorder Photos Empty", func(t *testing.T) {
+		res := reorder(mockHouseRepository{}, []int{})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	photoAction := func(houseRepo hr.HouseInterface, method string, handler func(hc *HouseController) echo.HandlerFunc) *httptest.ResponseRecorder {
+		e := echo.New()
+
+		req := httptest.NewRequest(method, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/houses/:id/photos/:photoId")
+		context.SetParamNames("id", "photoId")
+		context.SetParamValues("1", "2")
+
+		houseController := NewHouseControllers(houseRepo, photoStorage)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(handler(houseController))(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	setCover := func(hc *HouseController) echo.HandlerFunc { return hc.SetCoverPhotoController() }
+	deletePhoto := func(hc *HouseController) echo.HandlerFunc { return hc.DeletePhotoController() }
+
+	t.Run("Test Set Cover Photo", func(t *testing.T) {
+		res := photoAction(mockHouseRepository{}, http.MethodPut, setCover)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["is_cover"])
+	})
+
+	t.Run("Error Test Set Cover Photo Not Found", func(t *testing.T) {
+		res := photoAction(mockFalseHouseRepository{}, http.MethodPut, setCover)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Test Delete Photo", func(t *testing.T) {
+		photoStorage.Save("houses/1/photo.jpg", strings.NewReader("photo"))
+		photoStorage.Save("houses/1/photo_thumb.jpg", strings.NewReader("thumb"))
+
+		res := photoAction(mockHouseRepository{}, http.MethodDelete, deletePhoto)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NotContains(t, photoStorage.files, "houses/1/photo.jpg")
+		assert.NotContains(t, photoStorage.files, "houses/1/photo_thumb.jpg")
+	})
+
+	t.Run("Error Test Delete Photo Not Found", func(t *testing.T) {
+		res := photoAction(mockFalseHouseRepository{}, http.MethodDelete, deletePhoto)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
 
 type mockHouseRepository struct{}
 
@@ -397,12 +1489,13 @@ func (m mockHouseRepository) Create(newHouse model.House) (model.House, error) {
 	return model.House{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open"}, nil
 }
 
-func (m mockHouseRepository) GetAll(offset, pageSize int, search, city string) ([]model.House, error) {
-	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}, Ratings: []model.Rating{{Rating: 5}}}}, nil
+func (m mockHouseRepository) GetAll(filter hr.HouseFilter, page pagination.Page) ([]model.House, pagination.Result, error) {
+	latitude, longitude := -6.175392, 106.827153
+	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Latitude: &latitude, Longitude: &longitude, Features: []model.Feature{{Name: "wifi"}}, Ratings: []model.Rating{{Rating: 5}}}}, pagination.Result{Total: 21, HasMore: true, NextCursor: pagination.EncodeCursor(pagination.Cursor{Offset: page.Cursor.Offset + page.Limit})}, nil
 }
 
-func (m mockHouseRepository) GetAllMine(userId int) ([]model.House, error) {
-	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}, Ratings: []model.Rating{{Rating: 5}}}}, nil
+func (m mockHouseRepository) GetAllMine(userId int, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}, Ratings: []model.Rating{{Rating: 5}}}}, pagination.Result{}, nil
 }
 
 func (m mockHouseRepository) Get(houseId int) (model.House, error) {
@@ -410,14 +1503,33 @@ func (m mockHouseRepository) Get(houseId int) (model.House, error) {
 		Model: gorm.Model{
 			ID: 1,
 		},
-		UserID:   1,
-		Title:    "Rumah Bagus",
-		Address:  "Jalan Ujung",
-		City:     "Indonesia",
-		Price:    100000,
-		Status:   "open",
-		Features: []model.Feature{{Name: "wifi"}},
-		Ratings:  []model.Rating{{Rating: 5}},
+		UserID:      1,
+		Title:       "Rumah Bagus",
+		Address:     "Jalan Ujung",
+		City:        "Indonesia",
+		Price:       100000,
+		Status:      "open",
+		MinStay:     3,
+		Bedrooms:    2,
+		CheckinTime: "14:00",
+		PetsAllowed: true,
+		Features:    []model.Feature{{Name: "wifi"}},
+		Ratings:     []model.Rating{{Rating: 5}},
+		RatingSummary: model.RatingSummary{
+			Count:       5,
+			Average:     4.6,
+			Cleanliness: 4.5,
+			FourStar:    2,
+			FiveStar:    3,
+		},
+		PricingRules: []model.HousePricingRule{
+			{Type: "weekend", Percentage: 50},
+			{Type: "length_of_stay", Percentage: 10, MinNights: 3},
+		},
+		Photos: []model.HousePhoto{
+			{Model: gorm.Model{ID: 2}, Key: "houses/1/photo.jpg", ThumbnailKey: "houses/1/photo_thumb.jpg", Position: 1},
+			{Model: gorm.Model{ID: 1}, Key: "houses/1/cover.jpg", ThumbnailKey: "houses/1/cover_thumb.jpg", Position: 0, IsCover: true},
+		},
 	}, nil
 }
 
@@ -437,18 +1549,108 @@ func (m mockHouseRepository) HouseHasFeatureDelete(houseId int) error {
 	return nil
 }
 
+func (m mockHouseRepository) GetBookings(houseId int, from, to time.Time) ([]model.Transaction, error) {
+	return []model.Transaction{{
+		HouseID:      1,
+		CheckinDate:  time.Date(2030, 1, 3, 0, 0, 0, 0, time.UTC),
+		CheckoutDate: time.Date(2030, 1, 5, 0, 0, 0, 0, time.UTC),
+		Status:       "PAID",
+	}}, nil
+}
+
+func (m mockHouseRepository) GetBlockedDates(houseId int, from, to time.Time) ([]model.HouseBlockedDate, error) {
+	return []model.HouseBlockedDate{{
+		HouseID:   1,
+		StartDate: time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC),
+	}}, nil
+}
+
+func (m mockHouseRepository) CreateBlockedDate(blockedDate model.HouseBlockedDate) (model.HouseBlockedDate, error) {
+	blockedDate.ID = 1
+	return blockedDate, nil
+}
+
+func (m mockHouseRepository) DeleteBlockedDate(houseId, blockedDateId int) (model.HouseBlockedDate, error) {
+	return model.HouseBlockedDate{HouseID: 1}, nil
+}
+
+func (m mockHouseRepository) GetPricingRules(houseId int) ([]model.HousePricingRule, error) {
+	return []model.HousePricingRule{{HouseID: 1, Type: "weekend", Percentage: 50}}, nil
+}
+
+func (m mockHouseRepository) CreatePricingRule(pricingRule model.HousePricingRule) (model.HousePricingRule, error) {
+	pricingRule.ID = 1
+	return pricingRule, nil
+}
+
+func (m mockHouseRepository) DeletePricingRule(houseId, pricingRuleId int) (model.HousePricingRule, error) {
+	return model.HousePricingRule{HouseID: 1}, nil
+}
+
+func (m mockHouseRepository) GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{{UserID: 1, Title: "Rumah Bagus", City: "Indonesia", Price: 100000, Status: "open"}}, pagination.Result{}, nil
+}
+
+func (m mockHouseRepository) SetSuspended(houseId int, suspended bool) (model.House, error) {
+	return model.House{UserID: 1, Title: "Rumah Bagus", City: "Indonesia", Price: 100000, Status: "open", Suspended: suspended}, nil
+}
+
+func (m mockHouseRepository) GetPhotos(houseId int) ([]model.HousePhoto, error) {
+	return []model.HousePhoto{{Model: gorm.Model{ID: 1}, HouseID: 1, Key: "houses/1/cover.jpg", ThumbnailKey: "houses/1/cover_thumb.jpg", IsCover: true}}, nil
+}
+
+func (m mockHouseRepository) CreatePhoto(photo model.HousePhoto) (model.HousePhoto, error) {
+	photo.ID = 3
+	photo.Position = 2
+	return photo, nil
+}
+
+func (m mockHouseRepository) SetCoverPhoto(houseId, photoId int) (model.HousePhoto, error) {
+	return model.HousePhoto{Model: gorm.Model{ID: uint(photoId)}, HouseID: 1, Key: "houses/1/photo.jpg", ThumbnailKey: "houses/1/photo_thumb.jpg", Position: 1, IsCover: true}, nil
+}
+
+func (m mockHouseRepository) ReorderPhotos(houseId int, photoIds []int) ([]model.HousePhoto, error) {
+	if len(photoIds) != 2 {
+		return nil, hr.ErrInvalidPhotoOrder
+	}
+
+	photos := []model.HousePhoto{}
+	for position, photoId := range photoIds {
+		photos = append(photos, model.HousePhoto{Model: gorm.Model{ID: uint(photoId)}, HouseID: 1, Position: position})
+	}
+	return photos, nil
+}
+
+func (m mockHouseRepository) DeletePhoto(houseId, photoId int) (model.HousePhoto, error) {
+	return model.HousePhoto{Model: gorm.Model{ID: uint(photoId)}, HouseID: 1, Key: "houses/1/photo.jpg", ThumbnailKey: "houses/1/photo_thumb.jpg", Position: 1}, nil
+}
+
+func (m mockHouseRepository) GetSavedHouseIDs(userId int, houseIds []uint) (map[uint]bool, error) {
+	return map[uint]bool{1: true}, nil
+}
+
+// mockEmptyHouseRepository finds no houses, like a page after the last one
+type mockEmptyHouseRepository struct {
+	mockHouseRepository
+}
+
+func (m mockEmptyHouseRepository) GetAll(filter hr.HouseFilter, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{}, pagination.Result{}, nil
+}
+
 type mockFalseHouseRepository struct{}
 
 func (m mockFalseHouseRepository) Create(newHouse model.House) (model.House, error) {
 	return model.House{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open"}, errors.New("Error")
 }
 
-func (m mockFalseHouseRepository) GetAll(offset, pageSize int, search, city string) ([]model.House, error) {
-	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}}}, errors.New("Error")
+func (m mockFalseHouseRepository) GetAll(filter hr.HouseFilter, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}}}, pagination.Result{}, errors.New("Error")
 }
 
-func (m mockFalseHouseRepository) GetAllMine(userId int) ([]model.House, error) {
-	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}}}, errors.New("Error")
+func (m mockFalseHouseRepository) GetAllMine(userId int, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{{UserID: 1, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Indonesia", Price: 100000, Status: "open", Features: []model.Feature{{Name: "wifi"}}}}, pagination.Result{}, errors.New("Error")
 }
 
 func (m mockFalseHouseRepository) Get(houseId int) (model.House, error) {
@@ -470,3 +1672,86 @@ func (m mockFalseHouseRepository) HouseHasFeature(houseHasFeature model.HouseHas
 func (m mockFalseHouseRepository) HouseHasFeatureDelete(houseId int) error {
 	return nil
 }
+
+func (m mockFalseHouseRepository) GetBookings(houseId int, from, to time.Time) ([]model.Transaction, error) {
+	return nil, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) GetBlockedDates(houseId int, from, to time.Time) ([]model.HouseBlockedDate, error) {
+	return nil, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) CreateBlockedDate(blockedDate model.HouseBlockedDate) (model.HouseBlockedDate, error) {
+	return blockedDate, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) DeleteBlockedDate(houseId, blockedDateId int) (model.HouseBlockedDate, error) {
+	return model.HouseBlockedDate{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) GetPricingRules(houseId int) ([]model.HousePricingRule, error) {
+	return nil, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) CreatePricingRule(pricingRule model.HousePricingRule) (model.HousePricingRule, error) {
+	return pricingRule, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) DeletePricingRule(houseId, pricingRuleId int) (model.HousePricingRule, error) {
+	return model.HousePricingRule{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) SetSuspended(houseId int, suspended bool) (model.House, error) {
+	return model.House{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) GetPhotos(houseId int) ([]model.HousePhoto, error) {
+	return nil, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) CreatePhoto(photo model.HousePhoto) (model.HousePhoto, error) {
+	return model.HousePhoto{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) SetCoverPhoto(houseId, photoId int) (model.HousePhoto, error) {
+	return model.HousePhoto{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) ReorderPhotos(houseId int, photoIds []int) ([]model.HousePhoto, error) {
+	return nil, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) DeletePhoto(houseId, photoId int) (model.HousePhoto, error) {
+	return model.HousePhoto{}, errors.New("Error")
+}
+
+func (m mockFalseHouseRepository) GetSavedHouseIDs(userId int, houseIds []uint) (map[uint]bool, error) {
+	return nil, errors.New("Error")
+}
+
+// mockStorage keeps uploaded files in memory.
+type mockStorage struct {
+	files map[string][]byte
+}
+
+func (m *mockStorage) Save(key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.files[key] = data
+	return nil
+}
+
+func (m *mockStorage) Delete(key string) error {
+	delete(m.files, key)
+	return nil
+}
+
+func (m *mockStorage) URL(key string) string {
+	return "http://localhost/uploads/" + key
+}
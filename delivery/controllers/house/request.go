@@ -2,27 +2,123 @@ package house
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/furqonzt99/airbnb/model"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
 type CreateHouseRequestFormat struct {
-	Title    string  `json:"title" form:"title" validate:"required"`
-	Address  string  `json:"address" form:"address" validate:"required"`
-	City     string  `json:"city" form:"city" validate:"required"`
-	Price    float64 `json:"price" form:"price" validate:"required"`
-	Features []int   `json:"features" form:"features" validate:"required"`
-	Status   string  `json:"status" form:"status"`
+	Title              string   `json:"title" form:"title" validate:"required"`
+	Address            string   `json:"address" form:"address" validate:"required"`
+	City               string   `json:"city" form:"city" validate:"required"`
+	Price              float64  `json:"price" form:"price" validate:"required"`
+	Features           []int    `json:"features" form:"features" validate:"required"`
+	Status             string   `json:"status" form:"status"`
+	CancellationPolicy string   `json:"cancellation_policy" form:"cancellation_policy"`
+	MinStay            int      `json:"min_stay" form:"min_stay"`
+	MaxGuests          int      `json:"max_guests" form:"max_guests"`
+	CleaningFee        float64  `json:"cleaning_fee" form:"cleaning_fee"`
+	InstantBook        *bool    `json:"instant_book" form:"instant_book"`
+	Latitude           *float64 `json:"latitude" form:"latitude"`
+	Longitude          *float64 `json:"longitude" form:"longitude"`
+	HouseRulesRequestFormat
 }
 
 type PutHouseRequestFormat struct {
-	Title    string  `json:"title" form:"title" validate:"required"`
-	Address  string  `json:"address" form:"address" validate:"required"`
-	City     string  `json:"city" form:"city" validate:"required"`
-	Price    float64 `json:"price" form:"price" validate:"required"`
-	Features []int   `json:"features" form:"features" validate:"required"`
-	Status   string  `json:"status" form:"status"`
+	Title              string   `json:"title" form:"title" validate:"required"`
+	Address            string   `json:"address" form:"address" validate:"required"`
+	City               string   `json:"city" form:"city" validate:"required"`
+	Price              float64  `json:"price" form:"price" validate:"required"`
+	Features           []int    `json:"features" form:"features" validate:"required"`
+	Status             string   `json:"status" form:"status"`
+	CancellationPolicy string   `json:"cancellation_policy" form:"cancellation_policy"`
+	MinStay            int      `json:"min_stay" form:"min_stay"`
+	MaxGuests          int      `json:"max_guests" form:"max_guests"`
+	CleaningFee        float64  `json:"cleaning_fee" form:"cleaning_fee"`
+	InstantBook        *bool    `json:"instant_book" form:"instant_book"`
+	Latitude           *float64 `json:"latitude" form:"latitude"`
+	Longitude          *float64 `json:"longitude" form:"longitude"`
+	HouseRulesRequestFormat
+}
+
+// HouseRulesRequestFormat is the room layout and the rules guests agree to
+// when booking. Times are HH:MM in the local time of the house; a nil switch
+// keeps the current value on update.
+type HouseRulesRequestFormat struct {
+	MaxStay         int     `json:"max_stay" form:"max_stay"`
+	Bedrooms        int     `json:"bedrooms" form:"bedrooms"`
+	Bathrooms       float64 `json:"bathrooms" form:"bathrooms"`
+	CheckinTime     string  `json:"checkin_time" form:"checkin_time"`
+	CheckoutTime    string  `json:"checkout_time" form:"checkout_time"`
+	PetsAllowed     *bool   `json:"pets_allowed" form:"pets_allowed"`
+	SmokingAllowed  *bool   `json:"smoking_allowed" form:"smoking_allowed"`
+	EventsAllowed   *bool   `json:"events_allowed" form:"events_allowed"`
+	AdditionalRules string  `json:"additional_rules" form:"additional_rules"`
+}
+
+func (hr HouseRulesRequestFormat) isValid(minStay int) bool {
+	if hr.MaxStay < 0 || (hr.MaxStay > 0 && hr.MaxStay < minStay) {
+		return false
+	}
+
+	if hr.Bedrooms < 0 || hr.Bathrooms < 0 {
+		return false
+	}
+
+	return isValidTime(hr.CheckinTime) && isValidTime(hr.CheckoutTime)
+}
+
+// apply copies the rules onto house. Switches that weren't sent keep the
+// value house already has.
+func (hr HouseRulesRequestFormat) apply(house *model.House) {
+	house.MaxStay = hr.MaxStay
+	house.Bedrooms = hr.Bedrooms
+	house.Bathrooms = hr.Bathrooms
+	house.CheckinTime = hr.CheckinTime
+	house.CheckoutTime = hr.CheckoutTime
+	house.AdditionalRules = hr.AdditionalRules
+
+	if hr.PetsAllowed != nil {
+		house.PetsAllowed = *hr.PetsAllowed
+	}
+
+	if hr.SmokingAllowed != nil {
+		house.SmokingAllowed = *hr.SmokingAllowed
+	}
+
+	if hr.EventsAllowed != nil {
+		house.EventsAllowed = *hr.EventsAllowed
+	}
+}
+
+func isValidTime(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
+
+type BlockDateRequestFormat struct {
+	StartDate string `json:"start_date" form:"start_date" validate:"required"`
+	EndDate   string `json:"end_date" form:"end_date" validate:"required"`
+	Reason    string `json:"reason" form:"reason"`
+}
+
+type PricingRuleRequestFormat struct {
+	Type       string  `json:"type" form:"type" validate:"required"`
+	Price      float64 `json:"price" form:"price"`
+	Percentage float64 `json:"percentage" form:"percentage"`
+	MinNights  int     `json:"min_nights" form:"min_nights"`
+	StartDate  string  `json:"start_date" form:"start_date"`
+	EndDate    string  `json:"end_date" form:"end_date"`
+}
+
+type ReorderPhotosRequestFormat struct {
+	PhotoIDs []int `json:"photo_ids" form:"photo_ids" validate:"required,min=1"`
 }
 
 type HouseValidator struct {
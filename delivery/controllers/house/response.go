@@ -30,20 +30,109 @@ type DeleteHouseResponseFormat struct {
 }
 
 type HouseResponse struct {
-	ID       uint                    `json:"id"`
-	UserID   uint                    `json:"user_id"`
-	UserName string                  `json:"user_name"`
-	Title    string                  `json:"title"`
-	Address  string                  `json:"address"`
-	City     string                  `json:"city"`
-	Price    float64                 `json:"price"`
-	Rating   int                     `json:"rating"`
-	Status   string                  `json:"status"`
-	Features []FeatureResponse       `json:"features"`
-	Ratings  []rating.RatingResponse `json:"ratings"`
+	ID                 uint                    `json:"id"`
+	UserID             uint                    `json:"user_id"`
+	UserName           string                  `json:"user_name"`
+	Title              string                  `json:"title"`
+	Address            string                  `json:"address"`
+	City               string                  `json:"city"`
+	Price              float64                 `json:"price"`
+	Rating             float64                 `json:"rating"`
+	RatingSummary      RatingSummaryResponse   `json:"rating_summary"`
+	Status             string                  `json:"status"`
+	CancellationPolicy string                  `json:"cancellation_policy"`
+	MinStay            int                     `json:"min_stay"`
+	MaxStay            int                     `json:"max_stay"`
+	MaxGuests          int                     `json:"max_guests"`
+	Bedrooms           int                     `json:"bedrooms"`
+	Bathrooms          float64                 `json:"bathrooms"`
+	CheckinTime        string                  `json:"checkin_time"`
+	CheckoutTime       string                  `json:"checkout_time"`
+	PetsAllowed        bool                    `json:"pets_allowed"`
+	SmokingAllowed     bool                    `json:"smoking_allowed"`
+	EventsAllowed      bool                    `json:"events_allowed"`
+	AdditionalRules    string                  `json:"additional_rules"`
+	CleaningFee        float64                 `json:"cleaning_fee"`
+	InstantBook        bool                    `json:"instant_book"`
+	Features           []FeatureResponse       `json:"features"`
+	Ratings            []rating.RatingResponse `json:"ratings"`
+	Photos             []PhotoResponse         `json:"photos"`
+	Latitude           *float64                `json:"latitude"`
+	Longitude          *float64                `json:"longitude"`
+	DistanceKm         *float64                `json:"distance_km,omitempty"`
+	IsSaved            bool                    `json:"is_saved"`
+}
+
+type RatingSummaryResponse struct {
+	Count         int         `json:"count"`
+	Cleanliness   float64     `json:"cleanliness"`
+	Accuracy      float64     `json:"accuracy"`
+	Location      float64     `json:"location"`
+	Communication float64     `json:"communication"`
+	Value         float64     `json:"value"`
+	Distribution  map[int]int `json:"distribution"`
+}
+
+type PhotoResponse struct {
+	ID           uint   `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Position     int    `json:"position"`
+	IsCover      bool   `json:"is_cover"`
 }
 
 type FeatureResponse struct {
 	ID   uint   `json:"id"`
 	Name string `json:"name"`
 }
+
+type AvailabilityResponse struct {
+	HouseID uint                  `json:"house_id"`
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	MinStay int                   `json:"min_stay"`
+	Days    []CalendarDayResponse `json:"days"`
+}
+
+type CalendarDayResponse struct {
+	Date   string `json:"date"`
+	Status string `json:"status"`
+}
+
+type BlockedDateResponse struct {
+	ID        uint   `json:"id"`
+	HouseID   uint   `json:"house_id"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Reason    string `json:"reason"`
+}
+
+type PricingRuleResponse struct {
+	ID         uint    `json:"id"`
+	HouseID    uint    `json:"house_id"`
+	Type       string  `json:"type"`
+	Price      float64 `json:"price"`
+	Percentage float64 `json:"percentage"`
+	MinNights  int     `json:"min_nights"`
+	StartDate  string  `json:"start_date"`
+	EndDate    string  `json:"end_date"`
+}
+
+type QuoteResponse struct {
+	HouseID            uint                 `json:"house_id"`
+	CheckinDate        string               `json:"checkin_date"`
+	CheckoutDate       string               `json:"checkout_date"`
+	TotalNight         int                  `json:"total_night"`
+	Nights             []NightPriceResponse `json:"nights"`
+	Subtotal           float64              `json:"subtotal"`
+	DiscountPercentage float64              `json:"discount_percentage"`
+	Discount           float64              `json:"discount"`
+	Total              float64              `json:"total"`
+}
+
+type NightPriceResponse struct {
+	Date     string  `json:"date"`
+	Price    float64 `json:"price"`
+	Seasonal bool    `json:"seasonal"`
+	Weekend  bool    `json:"weekend"`
+}
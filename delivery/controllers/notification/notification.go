@@ -0,0 +1,161 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	nr "github.com/furqonzt99/airbnb/repository/notification"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationController struct {
+	Repository nr.NotificationInterface
+	Bus        *event.Bus
+}
+
+func NewNotificationController(repo nr.NotificationInterface, bus *event.Bus) *NotificationController {
+	return &NotificationController{Repository: repo, Bus: bus}
+}
+
+func (nc NotificationController) GetAll(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	notifications, result, err := nc.Repository.GetAll(user.UserID, c.QueryParam("unread") == "true", page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []NotificationResponse{}
+	for _, notification := range notifications {
+		data = append(data, newNotificationResponse(notification))
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (nc NotificationController) GetUnread(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	unread, err := nc.Repository.CountUnread(user.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(UnreadResponse{UnreadCount: unread}))
+}
+
+func (nc NotificationController) MarkRead(c echo.Context) error {
+	notificationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	notification, err := nc.Repository.MarkRead(user.UserID, notificationId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(newNotificationResponse(notification)))
+}
+
+func (nc NotificationController) MarkAllRead(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	if err := nc.Repository.MarkAllRead(user.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+// Stream sends the events of the user as Server-Sent Events until the client
+// disconnects. Comment lines keep idle connections open through proxies, and
+// the stream is closed at the next one once the session was revoked by logout,
+// password change or suspension.
+func (nc NotificationController) Stream(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	events, unsubscribe := nc.Bus.Subscribe(user.UserID)
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(constant.NOTIFICATION_KEEP_ALIVE)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if !mw.IsSessionActive(user) {
+				return nil
+			}
+
+			fmt.Fprint(res, ": keep-alive\n\n")
+			res.Flush()
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			data, _ := json.Marshal(newEventResponse(e))
+			fmt.Fprintf(res, "id: %v\nevent: %v\ndata: %s\n\n", e.ID, e.Type, data)
+			res.Flush()
+		}
+	}
+}
+
+func newNotificationResponse(notification model.Notification) NotificationResponse {
+	response := NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Message:   notification.Message,
+		Data:      json.RawMessage(notification.Data),
+		CreatedAt: notification.CreatedAt,
+	}
+
+	if notification.Data == "" {
+		response.Data = json.RawMessage("null")
+	}
+
+	if !notification.ReadAt.IsZero() {
+		readAt := notification.ReadAt
+		response.ReadAt = &readAt
+	}
+
+	return response
+}
+
+func newEventResponse(e event.Event) NotificationResponse {
+	data, _ := json.Marshal(e.Data)
+
+	return NotificationResponse{
+		ID:        e.ID,
+		Type:      e.Type,
+		Message:   e.Message,
+		Data:      data,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,293 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	nr "github.com/furqonzt99/airbnb/repository/notification"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var userToken, _ = mw.CreateToken(1, "guest@gmail.com", constant.GUEST_ROLE, 1)
+
+func serve(repo nr.NotificationInterface, handler func(NotificationController, echo.Context) error, path, id string) *httptest.ResponseRecorder {
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	res := httptest.NewRecorder()
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", userToken))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/notifications/:id/read")
+	context.SetParamNames("id")
+	context.SetParamValues(id)
+
+	notificationController := NewNotificationController(repo, event.NewBus(nil))
+	handlerFunc := func(c echo.Context) error {
+		return handler(*notificationController, c)
+	}
+
+	if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(handlerFunc)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func TestGetAll(t *testing.T) {
+	t.Run("Get Notifications", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.GetAll, "/notifications", "")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(2), response.Total)
+
+		notifications := response.Data.([]interface{})
+		assert.Equal(t, 2, len(notifications))
+
+		paid := notifications[0].(map[string]interface{})
+		assert.Equal(t, event.BOOKING_PAID, paid["type"])
+		assert.Equal(t, float64(5), paid["data"].(map[string]interface{})["transaction_id"])
+		assert.Nil(t, paid["read_at"])
+
+		assert.NotNil(t, notifications[1].(map[string]interface{})["read_at"])
+	})
+
+	t.Run("Get Unread Notifications", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.GetAll, "/notifications?unread=true", "")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(1), response.Total)
+	})
+
+	t.Run("Error Get Notifications Invalid Cursor", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.GetAll, "/notifications?cursor=invalid", "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Get Unread Count", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.GetUnread, "/notifications/unread", "")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, float64(1), response.Data.(map[string]interface{})["unread_count"])
+	})
+}
+
+func TestMarkRead(t *testing.T) {
+	t.Run("Mark Read", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.MarkRead, "/notifications/1/read", "1")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NotNil(t, response.Data.(map[string]interface{})["read_at"])
+	})
+
+	t.Run("Error Mark Read Of Someone Else", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.MarkRead, "/notifications/3/read", "3")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Mark Read Invalid Id", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.MarkRead, "/notifications/abc/read", "abc")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Mark All Read", func(t *testing.T) {
+		res := serve(mockNotificationRepository{}, NotificationController.MarkAllRead, "/notifications/read", "")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Mark All Read", func(t *testing.T) {
+		res := serve(mockFalseNotificationRepository{}, NotificationController.MarkAllRead, "/notifications/read", "")
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
+
+func TestStream(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	e := echo.New()
+	notificationController := NewNotificationController(mockNotificationRepository{}, bus)
+	e.GET("/notifications/stream", notificationController.Stream, mw.StreamJWT())
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	t.Run("Error Stream Without Token", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/notifications/stream")
+		assert.Nil(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("Stream Events Of The User", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/notifications/stream?access_token=" + userToken)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		// the headers are flushed after subscribing, so nothing is missed
+		bus.Publish(event.Event{Type: event.MESSAGE_RECEIVED, UserID: 2, Message: "Not for this user"})
+		bus.Publish(event.Event{Type: event.BOOKING_PAID, UserID: 1, Message: "Booking paid", Data: map[string]interface{}{"transaction_id": 5}})
+
+		lines := make(chan []string)
+		go func() {
+			scanner := bufio.NewScanner(res.Body)
+			block := []string{}
+			for scanner.Scan() {
+				if scanner.Text() == "" {
+					lines <- block
+					return
+				}
+				block = append(block, scanner.Text())
+			}
+		}()
+
+		select {
+		case block := <-lines:
+			assert.Equal(t, "event: "+event.BOOKING_PAID, block[1])
+			assert.True(t, strings.HasPrefix(block[2], "data: "))
+
+			data := map[string]interface{}{}
+			json.Unmarshal([]byte(strings.TrimPrefix(block[2], "data: ")), &data)
+			assert.Equal(t, "Booking paid", data["message"])
+			assert.Equal(t, float64(5), data["data"].(map[string]interface{})["transaction_id"])
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event received")
+		}
+	})
+}
+
+func TestStreamRevokedSession(t *testing.T) {
+	bus := event.NewBus(nil)
+	defer bus.Close()
+
+	keepAlive := constant.NOTIFICATION_KEEP_ALIVE
+	constant.NOTIFICATION_KEEP_ALIVE = 10 * time.Millisecond
+	defer func() { constant.NOTIFICATION_KEEP_ALIVE = keepAlive }()
+
+	sessions := &mockSessionChecker{}
+	sessions.active.Store(true)
+	mw.SetSessionChecker(sessions)
+	defer mw.SetSessionChecker(nil)
+
+	e := echo.New()
+	notificationController := NewNotificationController(mockNotificationRepository{}, bus)
+	e.GET("/notifications/stream", notificationController.Stream, mw.StreamJWT())
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	t.Run("Stream Closes After Logout", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/notifications/stream?access_token=" + userToken)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		sessions.active.Store(false)
+
+		closed := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, res.Body)
+			close(closed)
+		}()
+
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream still open after the session was revoked")
+		}
+	})
+}
+
+type mockSessionChecker struct {
+	active atomic.Value
+}
+
+func (m *mockSessionChecker) IsSessionActive(userId, sessionId int) bool {
+	return m.active.Load().(bool)
+}
+
+type mockNotificationRepository struct{}
+
+func (m mockNotificationRepository) Create(notification model.Notification) (model.Notification, error) {
+	return notification, nil
+}
+
+func (m mockNotificationRepository) GetAll(userId int, unreadOnly bool, page pagination.Page) ([]model.Notification, pagination.Result, error) {
+	notifications := []model.Notification{
+		{Model: gorm.Model{ID: 2}, UserID: 1, Type: event.BOOKING_PAID, Message: "Booking paid", Data: `{"transaction_id":5}`},
+	}
+
+	if unreadOnly {
+		return notifications, pagination.Result{Total: 1}, nil
+	}
+
+	notifications = append(notifications, model.Notification{Model: gorm.Model{ID: 1}, UserID: 1, Type: event.MESSAGE_RECEIVED, Message: "New message", ReadAt: time.Now()})
+
+	return notifications, pagination.Result{Total: 2}, nil
+}
+
+func (m mockNotificationRepository) CountUnread(userId int) (int64, error) {
+	return 1, nil
+}
+
+func (m mockNotificationRepository) MarkRead(userId, notificationId int) (model.Notification, error) {
+	if notificationId != 1 {
+		return model.Notification{}, gorm.ErrRecordNotFound
+	}
+
+	return model.Notification{Model: gorm.Model{ID: 1}, UserID: uint(userId), Type: event.MESSAGE_RECEIVED, ReadAt: time.Now()}, nil
+}
+
+func (m mockNotificationRepository) MarkAllRead(userId int) error {
+	return nil
+}
+
+type mockFalseNotificationRepository struct {
+	mockNotificationRepository
+}
+
+func (m mockFalseNotificationRepository) MarkAllRead(userId int) error {
+	return errors.New("Error")
+}
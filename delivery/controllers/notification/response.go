@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationResponse struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type UnreadResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
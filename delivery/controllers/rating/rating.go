@@ -1,22 +1,29 @@
 package rating
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	rr "github.com/furqonzt99/airbnb/repository/rating"
 	"github.com/labstack/echo/v4"
 )
 
 type RatingController struct {
 	Repository rr.Rating
+	Events     event.Publisher
 }
 
-func NewRatingController(repo rr.Rating) *RatingController {
-	return &RatingController{Repository: repo}
+func NewRatingController(repo rr.Rating, events event.Publisher) *RatingController {
+	return &RatingController{Repository: repo, Events: events}
 }
 
 func (rc RatingController) Create(c echo.Context) error {
@@ -32,16 +39,26 @@ func (rc RatingController) Create(c echo.Context) error {
 
 	user, _ := mw.ExtractTokenUser(c)
 
-	isCanGiveRating, _ := rc.Repository.IsCanGiveRating(user.UserID, ratingRequest.HouseID)
-	if !isCanGiveRating {
+	transaction, err := rc.Repository.GetTransaction(ratingRequest.TransactionID)
+	if err != nil || transaction.UserID != uint(user.UserID) {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 	}
 
+	if err := helper.CheckReviewable(transaction, time.Now(), constant.REVIEW_WINDOW); err != nil {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
+	}
+
 	data := model.Rating{
-		HouseID: uint(ratingRequest.HouseID),
-		UserID:  uint(user.UserID),
-		Rating:  ratingRequest.Rating,
-		Comment: ratingRequest.Comment,
+		TransactionID: transaction.ID,
+		HouseID:       transaction.HouseID,
+		UserID:        uint(user.UserID),
+		Rating:        ratingRequest.Rating,
+		Comment:       ratingRequest.Comment,
+		Cleanliness:   ratingRequest.Cleanliness,
+		Accuracy:      ratingRequest.Accuracy,
+		Location:      ratingRequest.Location,
+		Communication: ratingRequest.Communication,
+		Value:         ratingRequest.Value,
 	}
 
 	ratingData, err := rc.Repository.Create(data)
@@ -52,15 +69,16 @@ func (rc RatingController) Create(c echo.Context) error {
 		}
 	}
 
-	response := RatingResponse{
-		HouseID:  int(ratingData.HouseID),
-		UserID:   int(ratingData.UserID),
-		Username: ratingData.User.Name,
-		Rating:   ratingData.Rating,
-		Comment:  ratingData.Comment,
+	if house, err := rc.Repository.GetHouse(int(transaction.HouseID)); err == nil {
+		rc.Events.Publish(event.Event{
+			Type:    event.RATING_CREATED,
+			UserID:  int(house.UserID),
+			Message: fmt.Sprintf("%v rated %v %v stars", ratingData.User.Name, house.Title, ratingData.Rating),
+			Data:    map[string]interface{}{"house_id": house.ID, "transaction_id": ratingData.TransactionID},
+		})
 	}
 
-	return c.JSON(http.StatusOK, common.SuccessResponse(response)) 
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData))) 
 }
 
 func (rc RatingController) Update(c echo.Context) error {
@@ -74,7 +92,7 @@ func (rc RatingController) Update(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
 	}
 
-	houseId, err := strconv.Atoi(c.Param("houseId"))
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
@@ -82,11 +100,26 @@ func (rc RatingController) Update(c echo.Context) error {
 
 	user, _ := mw.ExtractTokenUser(c)
 
+	transaction, err := rc.Repository.GetTransaction(transactionId)
+	if err != nil || transaction.UserID != uint(user.UserID) {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	// a review can be edited only while it could still be written
+	if err := helper.CheckReviewable(transaction, time.Now(), constant.REVIEW_WINDOW); err != nil {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
+	}
+
 	data := model.Rating{
-		HouseID: uint(houseId),
-		UserID:  uint(user.UserID),
-		Rating:  ratingRequest.Rating,
-		Comment: ratingRequest.Comment,
+		TransactionID: uint(transactionId),
+		UserID:        uint(user.UserID),
+		Rating:        ratingRequest.Rating,
+		Comment:       ratingRequest.Comment,
+		Cleanliness:   ratingRequest.Cleanliness,
+		Accuracy:      ratingRequest.Accuracy,
+		Location:      ratingRequest.Location,
+		Communication: ratingRequest.Communication,
+		Value:         ratingRequest.Value,
 	}
 
 	ratingData, err := rc.Repository.Update(data)
@@ -94,20 +127,12 @@ func (rc RatingController) Update(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 	}
 
-	response := RatingResponse{
-		HouseID:  int(ratingData.HouseID),
-		UserID:   int(ratingData.UserID),
-		Username: ratingData.User.Name,
-		Rating:   ratingData.Rating,
-		Comment:  ratingData.Comment,
-	}
-
-	return c.JSON(http.StatusOK, common.SuccessResponse(response)) 
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData))) 
 }
 
 func (rc RatingController) Delete(c echo.Context) error {
 
-	houseId, err := strconv.Atoi(c.Param("houseId"))
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
@@ -115,10 +140,197 @@ func (rc RatingController) Delete(c echo.Context) error {
 
 	user, _ := mw.ExtractTokenUser(c)
 
-	_, err = rc.Repository.Delete(user.UserID, houseId)
+	_, err = rc.Repository.Delete(user.UserID, transactionId)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 	}
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse()) 
-}
\ No newline at end of file
+}
+
+// Reply posts or edits the public reply of the house owner to a rating.
+func (rc RatingController) Reply(c echo.Context) error {
+	var replyRequest ReplyRatingRequest
+
+	if err := c.Bind(&replyRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&replyRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	house, err := rc.ratedHouse(transactionId)
+	if err != nil || house.UserID != uint(user.UserID) {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	ratingData, err := rc.Repository.SetReply(transactionId, replyRequest.Reply)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	rc.Events.Publish(event.Event{
+		Type:    event.RATING_REPLIED,
+		UserID:  int(ratingData.UserID),
+		Message: fmt.Sprintf("The host of %v replied to your review", house.Title),
+		Data:    map[string]interface{}{"house_id": house.ID, "transaction_id": ratingData.TransactionID},
+	})
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData)))
+}
+
+func (rc RatingController) DeleteReply(c echo.Context) error {
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	house, err := rc.ratedHouse(transactionId)
+	if err != nil || house.UserID != uint(user.UserID) {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	ratingData, err := rc.Repository.DeleteReply(transactionId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData)))
+}
+
+// MarkHelpful counts a vote once per user. The reviewer and the house owner
+// can't vote on the review.
+func (rc RatingController) MarkHelpful(c echo.Context) error {
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	ratingData, err := rc.Repository.Get(transactionId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	house, err := rc.Repository.GetHouse(int(ratingData.HouseID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if ratingData.UserID == uint(user.UserID) || house.UserID == uint(user.UserID) {
+		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+	}
+
+	ratingData, err = rc.Repository.AddHelpfulVote(transactionId, user.UserID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData)))
+}
+
+func (rc RatingController) UnmarkHelpful(c echo.Context) error {
+	transactionId, err := strconv.Atoi(c.Param("transactionId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	ratingData, err := rc.Repository.RemoveHelpfulVote(transactionId, user.UserID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewRatingResponse(ratingData)))
+}
+
+// RateGuest lets the host of a completed stay review the guest, in the same
+// window the guest has to review the house.
+func (rc RatingController) RateGuest(c echo.Context) error {
+	var guestRatingRequest GuestRatingRequest
+
+	if err := c.Bind(&guestRatingRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&guestRatingRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	transaction, err := rc.Repository.GetTransaction(guestRatingRequest.TransactionID)
+	if err != nil || transaction.HostID != uint(user.UserID) {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if err := helper.CheckReviewable(transaction, time.Now(), constant.REVIEW_WINDOW); err != nil {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
+	}
+
+	guestRating, err := rc.Repository.SaveGuestRating(model.GuestRating{
+		TransactionID: transaction.ID,
+		HostID:        transaction.HostID,
+		GuestID:       transaction.UserID,
+		Rating:        guestRatingRequest.Rating,
+		Comment:       guestRatingRequest.Comment,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	rc.Events.Publish(event.Event{
+		Type:    event.GUEST_RATED,
+		UserID:  int(transaction.UserID),
+		Message: fmt.Sprintf("%v reviewed your stay", guestRating.Host.Name),
+		Data:    map[string]interface{}{"transaction_id": transaction.ID},
+	})
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(NewGuestRatingResponse(guestRating)))
+}
+
+func (rc RatingController) GetGuestRatings(c echo.Context) error {
+	guestId, err := strconv.Atoi(c.Param("guestId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	guestRatings, result, err := rc.Repository.GetGuestRatings(guestId, page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []GuestRatingResponse{}
+	for _, guestRating := range guestRatings {
+		data = append(data, NewGuestRatingResponse(guestRating))
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+// ratedHouse returns the house the rating of the stay is about.
+func (rc RatingController) ratedHouse(transactionId int) (model.House, error) {
+	rating, err := rc.Repository.Get(transactionId)
+	if err != nil {
+		return model.House{}, err
+	}
+
+	return rc.Repository.GetHouse(int(rating.HouseID))
+}
@@ -9,19 +9,30 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	rr "github.com/furqonzt99/airbnb/repository/rating"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var jwtToken string
+var guestToken, _ = mw.CreateToken(1, "guest@gmail.com", constant.GUEST_ROLE, 1)
+var hostToken, _ = mw.CreateToken(2, "host@gmail.com", constant.HOST_ROLE, 1)
+var voterToken, _ = mw.CreateToken(3, "voter@gmail.com", constant.GUEST_ROLE, 1)
 
 func TestCreateRating(t *testing.T) {
 	t.Run("Test Login", func(t *testing.T) {
@@ -40,13 +51,13 @@ func TestCreateRating(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/login")
 
-		userController := user.NewUsersControllers(mockUserRepository{})
+		userController := user.NewUsersControllers(mockUserRepository{}, mailer.NewOutboxMailer(""))
 		userController.LoginController()(context)
 
 		response := common.ResponseSuccess{}
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
-		jwtToken = response.Data.(string)
+		jwtToken = response.Data.(map[string]interface{})["access_token"].(string)
 		assert.Equal(t, "Successful Operation", response.Message)
 		assert.NotNil(t, jwtToken)
 	})
@@ -56,21 +67,21 @@ func TestCreateRating(t *testing.T) {
 		e.Validator = &RatingValidator{Validator: validator.New()}
 
 		requestBody, _ := json.Marshal(map[string]interface{}{
-			"house_id": 1,
-			"rating":   5,
-			"comment":  "nyaman",
+			"transaction_id": 1,
+			"rating":         5,
+			"comment":        "nyaman",
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
 		context.SetPath("/ratings")
 
-		ratingController := NewRatingController(mockRatingRepository{})
+		ratingController := NewRatingController(mockRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Create)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -88,21 +99,21 @@ func TestCreateRating(t *testing.T) {
 		e.Validator = &RatingValidator{Validator: validator.New()}
 
 		requestBody, _ := json.Marshal(map[string]interface{}{
-			"house_id": 1,
-			"rating":   5,
-			"comment":  "nyaman",
+			"transaction_id": 1,
+			"rating":         5,
+			"comment":        "nyaman",
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
 		context.SetPath("/ratings")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Create)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -124,12 +135,12 @@ func TestCreateRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
 		context.SetPath("/ratings")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Create)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -146,21 +157,21 @@ func TestCreateRating(t *testing.T) {
 		e.Validator = &RatingValidator{Validator: validator.New()}
 
 		requestBody, _ := json.Marshal(map[string]interface{}{
-			"house_id": 1,
-			"rating":   10,
-			"comment":  "nyaman",
+			"transaction_id": 1,
+			"rating":         10,
+			"comment":        "nyaman",
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
 		context.SetPath("/ratings")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Create)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -187,14 +198,14 @@ func TestUpdateRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockRatingRepository{})
+		ratingController := NewRatingController(mockRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Update)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -219,14 +230,14 @@ func TestUpdateRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Update)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -248,14 +259,14 @@ func TestUpdateRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Update)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -280,14 +291,14 @@ func TestUpdateRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Update)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -308,14 +319,14 @@ func TestDeleteRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockRatingRepository{})
+		ratingController := NewRatingController(mockRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Delete)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -334,14 +345,14 @@ func TestDeleteRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
-		context.SetParamNames("houseId")
+		context.SetPath("/ratings/:transactionId")
+		context.SetParamNames("transactionId")
 		context.SetParamValues("1")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Delete)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -360,12 +371,12 @@ func TestDeleteRating(t *testing.T) {
 		res := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
 
 		context := e.NewContext(req, res)
-		context.SetPath("/ratings/:houseId")
+		context.SetPath("/ratings/:transactionId")
 
-		ratingController := NewRatingController(mockFalseRatingRepository{})
+		ratingController := NewRatingController(mockFalseRatingRepository{}, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Delete)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -378,6 +389,323 @@ func TestDeleteRating(t *testing.T) {
 	})
 }
 
+func TestSubScores(t *testing.T) {
+	serveCreate := func(body map[string]interface{}) (*httptest.ResponseRecorder, common.ResponseSuccess) {
+		e := echo.New()
+		e.Validator = &RatingValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/ratings")
+
+		ratingController := NewRatingController(mockRatingRepository{}, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.Create)(context); err != nil {
+			log.Fatal(err)
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return res, response
+	}
+
+	t.Run("Create Rating With Sub Scores", func(t *testing.T) {
+		res, response := serveCreate(map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         5,
+			"cleanliness":    4,
+			"communication":  5,
+		})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(4), data["cleanliness"])
+		assert.Equal(t, float64(5), data["communication"])
+		assert.Equal(t, float64(0), data["value"])
+	})
+
+	for _, subScore := range []string{"cleanliness", "accuracy", "location", "communication", "value"} {
+		t.Run(fmt.Sprintf("Error Create Rating Invalid %v", subScore), func(t *testing.T) {
+			res, _ := serveCreate(map[string]interface{}{
+				"transaction_id": 1,
+				"rating":         5,
+				subScore:         6,
+			})
+
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+		})
+	}
+}
+
+func serveRating(repo rr.Rating, handler func(RatingController, echo.Context) error, token string, body interface{}, transactionId string) (*httptest.ResponseRecorder, common.ResponseSuccess) {
+	e := echo.New()
+	e.Validator = &RatingValidator{Validator: validator.New()}
+
+	requestBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+	res := httptest.NewRecorder()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/ratings/:transactionId/reply")
+	context.SetParamNames("transactionId")
+	context.SetParamValues(transactionId)
+
+	ratingController := NewRatingController(repo, event.NewBus(nil))
+	handlerFunc := func(c echo.Context) error {
+		return handler(*ratingController, c)
+	}
+
+	if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(handlerFunc)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	response := common.ResponseSuccess{}
+	json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+	return res, response
+}
+
+func TestReplyRating(t *testing.T) {
+
+	t.Run("Reply Rating", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.Reply, hostToken, map[string]interface{}{
+			"reply": "Thank you for staying",
+		}, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		reply := response.Data.(map[string]interface{})["reply"].(map[string]interface{})
+		assert.Equal(t, "Thank you for staying", reply["message"])
+	})
+
+	t.Run("Error Reply Rating Of House Of Someone Else", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.Reply, guestToken, map[string]interface{}{
+			"reply": "Thank you for staying",
+		}, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Reply Rating Without Reply", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.Reply, hostToken, map[string]interface{}{}, "1")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Reply Rating Invalid Transaction Id", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.Reply, hostToken, map[string]interface{}{
+			"reply": "Thank you for staying",
+		}, "abc")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Delete Reply", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.DeleteReply, hostToken, nil, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Nil(t, response.Data.(map[string]interface{})["reply"])
+	})
+
+	t.Run("Error Delete Reply", func(t *testing.T) {
+		res, _ := serveRating(mockFalseRatingRepository{}, RatingController.DeleteReply, hostToken, nil, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestHelpfulRating(t *testing.T) {
+
+	t.Run("Mark Helpful", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.MarkHelpful, voterToken, nil, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, float64(1), response.Data.(map[string]interface{})["helpful_count"])
+	})
+
+	t.Run("Error Mark Own Review Helpful", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.MarkHelpful, guestToken, nil, "1")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Mark Review Of Own House Helpful", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.MarkHelpful, hostToken, nil, "1")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Mark Helpful Missing Rating", func(t *testing.T) {
+		res, _ := serveRating(mockFalseRatingRepository{}, RatingController.MarkHelpful, voterToken, nil, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Unmark Helpful", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.UnmarkHelpful, voterToken, nil, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, float64(0), response.Data.(map[string]interface{})["helpful_count"])
+	})
+}
+
+func TestReviewWindow(t *testing.T) {
+	t.Run("Error Create Rating Before Checkout", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.Create, guestToken, map[string]interface{}{
+			"transaction_id": 2,
+			"rating":         5,
+		}, "")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+		assert.Equal(t, helper.ErrStayNotCompleted.Error(), response.Message)
+	})
+
+	t.Run("Error Create Rating After Review Window", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.Create, guestToken, map[string]interface{}{
+			"transaction_id": 3,
+			"rating":         5,
+		}, "")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+		assert.Equal(t, helper.ErrReviewWindowClosed.Error(), response.Message)
+	})
+
+	t.Run("Error Create Rating Of Stay Of Someone Else", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.Create, voterToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         5,
+		}, "")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Create Rating Ties Rating To Stay", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.Create, guestToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         5,
+		}, "")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(1), data["transaction_id"])
+		assert.Equal(t, float64(1), data["house_id"])
+	})
+
+	t.Run("Error Update Rating After Review Window", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.Update, guestToken, map[string]interface{}{
+			"rating": 4,
+		}, "3")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+}
+
+func TestGuestRating(t *testing.T) {
+	t.Run("Rate Guest", func(t *testing.T) {
+		res, response := serveRating(mockRatingRepository{}, RatingController.RateGuest, hostToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         4,
+			"comment":        "Left the house tidy",
+		}, "")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(1), data["guest_id"])
+		assert.Equal(t, "host", data["host_name"])
+	})
+
+	t.Run("Error Rate Guest Of Stay At House Of Someone Else", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.RateGuest, guestToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         4,
+		}, "")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Rate Guest Before Checkout", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.RateGuest, hostToken, map[string]interface{}{
+			"transaction_id": 2,
+			"rating":         4,
+		}, "")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Error Rate Guest Invalid Rating", func(t *testing.T) {
+		res, _ := serveRating(mockRatingRepository{}, RatingController.RateGuest, hostToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         6,
+		}, "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Rate Guest", func(t *testing.T) {
+		res, _ := serveRating(mockFalseRatingRepository{}, RatingController.RateGuest, hostToken, map[string]interface{}{
+			"transaction_id": 1,
+			"rating":         4,
+		}, "")
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
+
+func TestGetGuestRatings(t *testing.T) {
+	serveGuestRatings := func(repo rr.Rating, guestId string) *httptest.ResponseRecorder {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", hostToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/guest-ratings/:guestId")
+		context.SetParamNames("guestId")
+		context.SetParamValues(guestId)
+
+		ratingController := NewRatingController(repo, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(ratingController.GetGuestRatings)(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	t.Run("Get Guest Ratings", func(t *testing.T) {
+		res := serveGuestRatings(mockRatingRepository{}, "1")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(1), response.Total)
+	})
+
+	t.Run("Error Get Guest Ratings Invalid Guest Id", func(t *testing.T) {
+		res := serveGuestRatings(mockRatingRepository{}, "abc")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Get Guest Ratings", func(t *testing.T) {
+		res := serveGuestRatings(mockFalseRatingRepository{}, "1")
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
+
 type mockUserRepository struct{}
 
 func (m mockUserRepository) Register(newUser model.User) (model.User, error) {
@@ -409,38 +737,175 @@ func (m mockUserRepository) Delete(userId int) (model.User, error) {
 	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
 }
 
+func (m mockUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return []model.User{{Email: "test@gmail.com", Name: "tester", Role: "guest"}}, pagination.Result{}, nil
+}
+
+func (m mockUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", Role: "guest", Suspended: suspended}, nil
+}
+
+func (m mockUserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	refreshToken.ID = 1
+	return refreshToken, nil
+}
+
+func (m mockUserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	return model.RefreshToken{}, errors.New("record not found")
+}
+
+func (m mockUserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	next.ID = prev.ID + 1
+	return next, nil
+}
+
+func (m mockUserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return nil
+}
+
+func (m mockUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return nil
+}
+
+func (m mockUserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	return true
+}
+
+func (m mockUserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	userToken.ID = 1
+	return userToken, nil
+}
+
+func (m mockUserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
+func (m mockUserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
 type mockRatingRepository struct{}
 
-func (m mockRatingRepository) Create(model.Rating) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+// stay 1 is completed, stay 2 hasn't checked out yet and the review window of
+// stay 3 has closed
+func mockStay(transactionId int) (model.Transaction, error) {
+	checkoutDates := map[int]time.Time{
+		1: time.Now().AddDate(0, 0, -2),
+		2: time.Now().AddDate(0, 0, 2),
+		3: time.Now().AddDate(0, 0, -30),
+	}
+
+	checkoutDate, ok := checkoutDates[transactionId]
+	if !ok {
+		return model.Transaction{}, errors.New("Error")
+	}
+
+	return model.Transaction{Model: gorm.Model{ID: uint(transactionId)}, UserID: 1, HouseID: 1, HostID: 2, Status: "PAID", CheckinDate: checkoutDate.AddDate(0, 0, -2), CheckoutDate: checkoutDate}, nil
+}
+
+func (m mockRatingRepository) Create(rating model.Rating) (model.Rating, error) {
+	return rating, nil
 }
 
 func (m mockRatingRepository) Update(model.Rating) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+}
+
+func (m mockRatingRepository) Delete(userId, transactionId int) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+}
+
+func (rr mockRatingRepository) GetTransaction(transactionId int) (model.Transaction, error) {
+	return mockStay(transactionId)
+}
+
+func (rr mockRatingRepository) GetHouse(houseId int) (model.House, error) {
+	return model.House{Model: gorm.Model{ID: 1}, UserID: 2, Title: "Villa"}, nil
+}
+
+func (rr mockRatingRepository) Get(transactionId int) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+}
+
+func (rr mockRatingRepository) SetReply(transactionId int, reply string) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman", Reply: reply, RepliedAt: time.Now()}, nil
 }
 
-func (m mockRatingRepository) Delete(userId, houseId int) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+func (rr mockRatingRepository) DeleteReply(transactionId int) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
 }
 
-func (rr mockRatingRepository) IsCanGiveRating(userId, houseId int) (bool, error) {
-	return true, nil
+func (rr mockRatingRepository) AddHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman", HelpfulCount: 1}, nil
+}
+
+func (rr mockRatingRepository) RemoveHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	return model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, nil
+}
+
+func (rr mockRatingRepository) GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	return []model.Rating{{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 1, Comment: "spam"}}, pagination.Result{}, nil
+}
+
+func (rr mockRatingRepository) SaveGuestRating(guestRating model.GuestRating) (model.GuestRating, error) {
+	guestRating.Host = model.User{Name: "host"}
+	return guestRating, nil
+}
+
+func (rr mockRatingRepository) GetGuestRatings(guestId int, page pagination.Page) ([]model.GuestRating, pagination.Result, error) {
+	return []model.GuestRating{{TransactionID: 1, HostID: 2, GuestID: uint(guestId), Rating: 4, Host: model.User{Name: "host"}}}, pagination.Result{Total: 1}, nil
 }
 
 type mockFalseRatingRepository struct{}
 
 func (m mockFalseRatingRepository) Create(model.Rating) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, errors.New("Error")
+	return model.Rating{}, errors.New("Error")
 }
 
 func (m mockFalseRatingRepository) Update(model.Rating) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, errors.New("Error")
+	return model.Rating{}, errors.New("Error")
+}
+
+func (m mockFalseRatingRepository) Delete(userId, transactionId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) GetTransaction(transactionId int) (model.Transaction, error) {
+	return mockStay(transactionId)
+}
+
+func (rr mockFalseRatingRepository) GetHouse(houseId int) (model.House, error) {
+	return model.House{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) Get(transactionId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) SetReply(transactionId int, reply string) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) DeleteReply(transactionId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) AddHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) RemoveHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	return model.Rating{}, errors.New("Error")
+}
+
+func (rr mockFalseRatingRepository) GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
 }
 
-func (m mockFalseRatingRepository) Delete(userId, houseId int) (model.Rating, error) {
-	return model.Rating{HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"}, errors.New("Error")
+func (rr mockFalseRatingRepository) SaveGuestRating(guestRating model.GuestRating) (model.GuestRating, error) {
+	return model.GuestRating{}, errors.New("Error")
 }
 
-func (rr mockFalseRatingRepository) IsCanGiveRating(userId, houseId int) (bool, error) {
-	return true, nil
+func (rr mockFalseRatingRepository) GetGuestRatings(guestId int, page pagination.Page) ([]model.GuestRating, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("Error")
 }
@@ -9,14 +9,35 @@ import (
 )
 
 type PostRatingRequest struct {
-	HouseID int `json:"house_id" validate:"required"`
+	TransactionID int `json:"transaction_id" validate:"required"`
 	Rating int `json:"rating" validate:"required,max=5,min=1"`
 	Comment string `json:"comment"`
+	SubScoresRequest
 }
 
 type UpdateRatingRequest struct {
 	Rating int `json:"rating" validate:"required,max=5,min=1"`
 	Comment string `json:"comment"`
+	SubScoresRequest
+}
+
+// SubScoresRequest are the optional scores of a stay, each from one to five.
+type SubScoresRequest struct {
+	Cleanliness   int `json:"cleanliness" validate:"omitempty,min=1,max=5"`
+	Accuracy      int `json:"accuracy" validate:"omitempty,min=1,max=5"`
+	Location      int `json:"location" validate:"omitempty,min=1,max=5"`
+	Communication int `json:"communication" validate:"omitempty,min=1,max=5"`
+	Value         int `json:"value" validate:"omitempty,min=1,max=5"`
+}
+
+type ReplyRatingRequest struct {
+	Reply string `json:"reply" validate:"required,max=1000"`
+}
+
+type GuestRatingRequest struct {
+	TransactionID int    `json:"transaction_id" validate:"required"`
+	Rating        int    `json:"rating" validate:"required,max=5,min=1"`
+	Comment       string `json:"comment"`
 }
 
 type RatingValidator struct {
@@ -1,9 +1,76 @@
 package rating
 
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
 type RatingResponse struct {
-	HouseID  int    `json:"house_id"`
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
-	Rating   int    `json:"rating"`
-	Comment  string `json:"comment"`
+	TransactionID int            `json:"transaction_id"`
+	HouseID       int            `json:"house_id"`
+	UserID        int            `json:"user_id"`
+	Username      string         `json:"username"`
+	Rating        int            `json:"rating"`
+	Cleanliness   int            `json:"cleanliness"`
+	Accuracy      int            `json:"accuracy"`
+	Location      int            `json:"location"`
+	Communication int            `json:"communication"`
+	Value         int            `json:"value"`
+	Comment       string         `json:"comment"`
+	HelpfulCount  int            `json:"helpful_count"`
+	Reply         *ReplyResponse `json:"reply"`
+}
+
+type ReplyResponse struct {
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewRatingResponse(rating model.Rating) RatingResponse {
+	response := RatingResponse{
+		TransactionID: int(rating.TransactionID),
+		HouseID:       int(rating.HouseID),
+		UserID:        int(rating.UserID),
+		Username:      rating.User.Name,
+		Rating:        rating.Rating,
+		Cleanliness:   rating.Cleanliness,
+		Accuracy:      rating.Accuracy,
+		Location:      rating.Location,
+		Communication: rating.Communication,
+		Value:         rating.Value,
+		Comment:       rating.Comment,
+		HelpfulCount:  rating.HelpfulCount,
+	}
+
+	if rating.Reply != "" {
+		response.Reply = &ReplyResponse{
+			Message:   rating.Reply,
+			CreatedAt: rating.RepliedAt,
+		}
+	}
+
+	return response
+}
+
+type GuestRatingResponse struct {
+	TransactionID int       `json:"transaction_id"`
+	GuestID       int       `json:"guest_id"`
+	HostID        int       `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	Rating        int       `json:"rating"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func NewGuestRatingResponse(guestRating model.GuestRating) GuestRatingResponse {
+	return GuestRatingResponse{
+		TransactionID: int(guestRating.TransactionID),
+		GuestID:       int(guestRating.GuestID),
+		HostID:        int(guestRating.HostID),
+		HostName:      guestRating.Host.Name,
+		Rating:        guestRating.Rating,
+		Comment:       guestRating.Comment,
+		CreatedAt:     guestRating.CreatedAt,
+	}
 }
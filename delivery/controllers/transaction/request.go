@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/helper"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
@@ -12,12 +13,35 @@ type TransactionRequest struct {
 	HouseID int	`json:"house_id" validate:"required"`
 	CheckinDate string `json:"checkin_date" validate:"required"`
 	CheckoutDate string	`json:"checkout_date" validate:"required"`
+	Adults int `json:"adults" validate:"min=0"`
+	Children int `json:"children" validate:"min=0"`
+	Infants int `json:"infants" validate:"min=0"`
+	Pets int `json:"pets" validate:"min=0"`
+}
+
+// guests is the party of the request. Requests without adults are from
+// clients that don't ask for the guest count yet, so they count as one adult.
+func (tr TransactionRequest) guests() helper.Guests {
+	guests := helper.Guests{Adults: tr.Adults, Children: tr.Children, Infants: tr.Infants, Pets: tr.Pets}
+	if guests.Adults == 0 {
+		guests.Adults = 1
+	}
+
+	return guests
 }
 
 type RescheduleRequest struct {
 	CheckinDate string `json:"checkin_date" validate:"required"`
 }
 
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+type DeclineRequest struct {
+	Reason string `json:"reason"`
+}
+
 type TransactionValidator struct {
 	Validator *validator.Validate
 }
@@ -1,5 +1,11 @@
 package transaction
 
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
 type TransactionResponse struct {
 	ID int `json:"id"`
 	UserID int `json:"user_id"`
@@ -13,5 +19,56 @@ type TransactionResponse struct {
 	CheckinDate string `json:"checkin_date"`
 	CheckoutDate string `json:"checkout_date"`
 	TotalPrice float64 `json:"total_price"`
-	Status string `json:"status"`
-}
\ No newline at end of file
+	Status model.TransactionStatus `json:"status"`
+	RespondBy *time.Time `json:"respond_by,omitempty"`
+	DeclineReason string `json:"decline_reason,omitempty"`
+	Guests GuestsResponse `json:"guests"`
+	LineItems []LineItemResponse `json:"line_items,omitempty"`
+}
+
+type StatusHistoryResponse struct {
+	FromStatus model.TransactionStatus `json:"from_status,omitempty"`
+	ToStatus   model.TransactionStatus `json:"to_status"`
+	ChangedBy  *uint                   `json:"changed_by"`
+	Note       string                  `json:"note,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+type GuestsResponse struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+	Infants  int `json:"infants"`
+	Pets     int `json:"pets"`
+}
+
+type CancelResponse struct {
+	ID           int     `json:"id"`
+	InvoiceID    string  `json:"invoice_id"`
+	Status       model.TransactionStatus `json:"status"`
+	TotalPrice   float64 `json:"total_price"`
+	RefundAmount float64 `json:"refund_amount"`
+	RefundError  string  `json:"refund_error,omitempty"`
+	CancelledBy  string  `json:"cancelled_by"`
+}
+
+type QuoteResponse struct {
+	HouseID      int                `json:"house_id"`
+	CheckinDate  string             `json:"checkin_date"`
+	CheckoutDate string             `json:"checkout_date"`
+	TotalNight   int                `json:"total_night"`
+	Subtotal     float64            `json:"subtotal"`
+	Discount     float64            `json:"discount"`
+	CleaningFee  float64            `json:"cleaning_fee"`
+	ServiceFee   float64            `json:"service_fee"`
+	Tax          float64            `json:"tax"`
+	TotalPrice   float64            `json:"total_price"`
+	LineItems    []LineItemResponse `json:"line_items"`
+}
+
+type LineItemResponse struct {
+	Type      string  `json:"type"`
+	Name      string  `json:"name"`
+	UnitPrice float64 `json:"unit_price"`
+	Quantity  int     `json:"quantity"`
+	Amount    float64 `json:"amount"`
+}
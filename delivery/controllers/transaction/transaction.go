@@ -1,7 +1,9 @@
 package transaction
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,19 +12,34 @@ import (
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
 	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/payment"
 	tr "github.com/furqonzt99/airbnb/repository/transaction"
+	wr "github.com/furqonzt99/airbnb/repository/webhook"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
 )
 
+var ErrUnknownInvoice = errors.New("no transaction has this invoice")
+var ErrStaleWebhookEvent = errors.New("a newer payment event was applied already")
+var ErrAmountMismatch = errors.New("paid amount doesn't match the transaction total")
+var ErrWebhookEventClaimed = errors.New("the payment event is applied or being applied already")
+var ErrMissingEventTime = errors.New("payment event has no event time")
+
 type TransactionController struct {
 	Repository tr.Transaction
+	Webhooks   wr.WebhookInterface
+	Payment    payment.PaymentProvider
+	Fees       helper.Fees
+	Events     event.Publisher
 }
 
-func NewTransactionController(repo tr.Transaction) *TransactionController {
-	return &TransactionController{Repository: repo}
+func NewTransactionController(repo tr.Transaction, webhookRepo wr.WebhookInterface, paymentProvider payment.PaymentProvider, fees helper.Fees, events event.Publisher) *TransactionController {
+	return &TransactionController{Repository: repo, Webhooks: webhookRepo, Payment: paymentProvider, Fees: fees, Events: events}
 }
 
 func (tc TransactionController) Booking(c echo.Context) error {
@@ -39,11 +56,18 @@ func (tc TransactionController) Booking(c echo.Context) error {
 	
 	user, _ := mw.ExtractTokenUser(c)
 
-	hostId, err := tc.Repository.GetHostId(transactionRequest.HouseID)
+	guest, err := tc.Repository.GetUser(user.UserID)
+	if err != nil || !guest.EmailVerified {
+		return c.JSON(http.StatusForbidden, common.ErrorResponse(http.StatusForbidden, "Verify your email before booking"))
+	}
+
+	house, err := tc.Repository.GetHouse(transactionRequest.HouseID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
 	}
 
+	hostId := int(house.UserID)
+
 	invoiceId := strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", ""))
 
 	checkinDate, _ := time.Parse(time.RFC3339, transactionRequest.CheckinDate + "T00:00:00.000Z")
@@ -60,12 +84,16 @@ func (tc TransactionController) Booking(c echo.Context) error {
 	if !checkinDate.After(yesterday) || !checkoutDate.After(yesterday) {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Checkin date or checkout date cant past date!"))
 	}
-	// check availability
-	isAvailable, _ := tc.Repository.IsHouseAvailable(transactionRequest.HouseID, checkinDate, checkoutDate)
-	if !isAvailable {
-		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "House already booked at the date, please choose another date!"))
+
+	guests := transactionRequest.guests()
+	if err := helper.CheckStayRules(house, guests, helper.CountNight(checkinDate, checkoutDate)); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
+	// price the stay now so the same breakdown is stored and invoiced
+	quote := helper.CalculateQuote(house.Price, checkinDate, checkoutDate, house.PricingRules)
+	charges := helper.CalculateCharges(house.Title, quote, house.CleaningFee, tc.Fees)
+
 	data := model.Transaction{
 		UserID:        uint(user.UserID),
 		HouseID:       uint(transactionRequest.HouseID),
@@ -73,24 +101,76 @@ func (tc TransactionController) Booking(c echo.Context) error {
 		InvoiceID:     invoiceId,
 		CheckinDate:   checkinDate,
 		CheckoutDate:  checkoutDate,
+		Adults:        guests.Adults,
+		Children:      guests.Children,
+		Infants:       guests.Infants,
+		Pets:          guests.Pets,
+		TotalPrice:    charges.Total,
+		LineItems:     charges.LineItems,
+		Status:        model.PENDING_STATUS,
 	}
 
-	transactionData, err := tc.Repository.Create(data)
+	// without instant book the host has to approve before the guest pays,
+	// the request holds the dates meanwhile
+	if !house.IsInstantBook() {
+		data.Status = model.REQUESTED_STATUS
+		data.RespondBy = requestDeadline(time.Now(), checkinDate)
+	}
+
+	// check availability and create in one locked db transaction
+	transactionData, err := tc.Repository.CreateIfAvailable(data)
+	if err == tr.ErrHouseNotAvailable {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "House already booked at the date, please choose another date!"))
+	}
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
-	transactionPayment, err := helper.CreateInvoice(transactionData, user.Email) 
+	if data.Status == model.REQUESTED_STATUS {
+		tc.Events.Publish(event.Event{
+			Type:    event.BOOKING_REQUESTED,
+			UserID:  hostId,
+			Message: fmt.Sprintf("New booking request for %v from %v to %v", house.Title, transactionRequest.CheckinDate, transactionRequest.CheckoutDate),
+			Data:    map[string]interface{}{"transaction_id": transactionData.ID, "house_id": house.ID},
+		})
+
+		response := TransactionResponse{
+			ID:           int(transactionData.ID),
+			UserID:       int(transactionData.UserID),
+			HouseID:      transactionRequest.HouseID,
+			HostID:       hostId,
+			InvoiceID:    invoiceId,
+			CheckinDate:  transactionRequest.CheckinDate,
+			CheckoutDate: transactionRequest.CheckoutDate,
+			TotalPrice:   charges.Total,
+			Status:       data.Status,
+			RespondBy:    newRespondBy(data),
+			Guests:       newGuestsResponse(data),
+			LineItems:    newLineItemResponses(charges.LineItems),
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(response))
+	}
+
+	transactionPayment, err := tc.Payment.CreateInvoice(transactionData, user.Email, payment.NewInvoiceFromLineItems(charges.LineItems))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
 	// add payment url to db
 	updateData := model.Transaction{}
+	updateData.PaymentID = transactionPayment.PaymentID
 	updateData.PaymentUrl = transactionPayment.PaymentUrl
 	updateData.TotalPrice = transactionPayment.TotalPrice
 	tc.Repository.Update(invoiceId, updateData)
 
+	tc.Events.Publish(event.Event{
+		Type:    event.BOOKING_CREATED,
+		UserID:  hostId,
+		Message: fmt.Sprintf("New booking for %v from %v to %v", house.Title, transactionRequest.CheckinDate, transactionRequest.CheckoutDate),
+		Data:    map[string]interface{}{"transaction_id": transactionData.ID, "house_id": house.ID},
+	})
+
 	// reformat response
 	response := TransactionResponse{
 		ID:            int(transactionData.ID),
@@ -103,6 +183,178 @@ func (tc TransactionController) Booking(c echo.Context) error {
 		CheckoutDate:  transactionRequest.CheckoutDate,
 		TotalPrice:    transactionPayment.TotalPrice,
 		Status:        transactionPayment.Status,
+		Guests:        newGuestsResponse(data),
+		LineItems:     newLineItemResponses(charges.LineItems),
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(response))
+}
+
+// Approve accepts a booking request of the host's house. Only now the invoice
+// is created and the guest can pay.
+func (tc TransactionController) Approve(c echo.Context) error {
+	trxId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	transaction, err := tc.Repository.GetByParticipant(user.UserID, trxId)
+	if err != nil || int(transaction.HostID) != user.UserID {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if transaction.Status != model.REQUESTED_STATUS || !time.Now().Before(transaction.RespondBy) {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, "Booking request is no longer open"))
+	}
+
+	transactionPayment, err := tc.Payment.CreateInvoice(transaction, transaction.User.Email, payment.NewInvoiceFromLineItems(transaction.LineItems))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	data := model.Transaction{
+		Status:     model.PENDING_STATUS,
+		PaymentID:  transactionPayment.PaymentID,
+		PaymentUrl: transactionPayment.PaymentUrl,
+		TotalPrice: transactionPayment.TotalPrice,
+		ApprovedAt: time.Now(),
+	}
+
+	ok, err := tc.Repository.UpdateStatus(trxId, model.REQUESTED_STATUS, data, user.UserID, "")
+	if err != nil || !ok {
+		// declined while the invoice was created, nobody may pay it
+		tc.Payment.ExpireInvoice(transaction)
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, "Booking request is no longer open"))
+	}
+
+	tc.Events.Publish(event.Event{
+		Type:    event.BOOKING_APPROVED,
+		UserID:  int(transaction.UserID),
+		Message: fmt.Sprintf("Your booking request for %v was approved, complete the payment to confirm it", transaction.House.Title),
+		Data:    map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID, "payment_url": data.PaymentUrl},
+	})
+
+	response := TransactionResponse{
+		ID:           int(transaction.ID),
+		UserID:       int(transaction.UserID),
+		HouseID:      int(transaction.HouseID),
+		HostID:       int(transaction.HostID),
+		InvoiceID:    transaction.InvoiceID,
+		PaymentUrl:   data.PaymentUrl,
+		CheckinDate:  fmt.Sprint(transaction.CheckinDate),
+		CheckoutDate: fmt.Sprint(transaction.CheckoutDate),
+		TotalPrice:   data.TotalPrice,
+		Status:       data.Status,
+		Guests:       newGuestsResponse(transaction),
+		LineItems:    newLineItemResponses(transaction.LineItems),
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(response))
+}
+
+func (tc TransactionController) Decline(c echo.Context) error {
+	var declineRequest DeclineRequest
+	if err := c.Bind(&declineRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	trxId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	transaction, err := tc.Repository.GetByParticipant(user.UserID, trxId)
+	if err != nil || int(transaction.HostID) != user.UserID {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	data := model.Transaction{Status: model.DECLINED_STATUS, DeclineReason: declineRequest.Reason}
+
+	ok, err := tc.Repository.UpdateStatus(trxId, transaction.Status, data, user.UserID, declineRequest.Reason)
+	if err != nil && err != tr.ErrInvalidTransition {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if !ok {
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, "Booking request is no longer open"))
+	}
+
+	tc.Events.Publish(event.Event{
+		Type:    event.BOOKING_DECLINED,
+		UserID:  int(transaction.UserID),
+		Message: fmt.Sprintf("Your booking request for %v was declined", transaction.House.Title),
+		Data:    map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID},
+	})
+
+	response := TransactionResponse{
+		ID:            int(transaction.ID),
+		UserID:        int(transaction.UserID),
+		HouseID:       int(transaction.HouseID),
+		HostID:        int(transaction.HostID),
+		InvoiceID:     transaction.InvoiceID,
+		CheckinDate:   fmt.Sprint(transaction.CheckinDate),
+		CheckoutDate:  fmt.Sprint(transaction.CheckoutDate),
+		TotalPrice:    transaction.TotalPrice,
+		Status:        data.Status,
+		DeclineReason: data.DeclineReason,
+		Guests:        newGuestsResponse(transaction),
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(response))
+}
+
+func (tc TransactionController) Quote(c echo.Context) error {
+	var quoteRequest TransactionRequest
+
+	if err := c.Bind(&quoteRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	if err := c.Validate(&quoteRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	checkinDate, err := time.Parse(time.RFC3339, quoteRequest.CheckinDate + "T00:00:00.000Z")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid checkin date!"))
+	}
+
+	checkoutDate, err := time.Parse(time.RFC3339, quoteRequest.CheckoutDate + "T00:00:00.000Z")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Invalid checkout date!"))
+	}
+
+	if !checkoutDate.After(checkinDate) {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Checkout date must after checkin date!"))
+	}
+
+	house, err := tc.Repository.GetHouse(quoteRequest.HouseID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if err := helper.CheckStayRules(house, quoteRequest.guests(), helper.CountNight(checkinDate, checkoutDate)); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	quote := helper.CalculateQuote(house.Price, checkinDate, checkoutDate, house.PricingRules)
+	charges := helper.CalculateCharges(house.Title, quote, house.CleaningFee, tc.Fees)
+
+	response := QuoteResponse{
+		HouseID:      int(house.ID),
+		CheckinDate:  quoteRequest.CheckinDate,
+		CheckoutDate: quoteRequest.CheckoutDate,
+		TotalNight:   len(quote.Nights),
+		Subtotal:     quote.Subtotal,
+		Discount:     quote.Discount,
+		CleaningFee:  charges.CleaningFee,
+		ServiceFee:   charges.ServiceFee,
+		Tax:          charges.Tax,
+		TotalPrice:   charges.Total,
+		LineItems:    newLineItemResponses(charges.LineItems),
 	}
 
 	return c.JSON(http.StatusOK, common.SuccessResponse(response))
@@ -133,9 +385,7 @@ func (tc TransactionController) Reschedule(c echo.Context) error {
 	}
 
 	// decline reschedule if haven't paid yet
-	const PAID_STATUS = "PAID"
-
-	if prevData.Status != PAID_STATUS {
+	if prevData.Status != model.PAID_STATUS {
 		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
 	}
 
@@ -162,58 +412,275 @@ func (tc TransactionController) Reschedule(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "Checkin date or checkout date cant past date!"))
 	}
 
-	// check reschedule availability
-	isAvailable, _ := tc.Repository.IsHouseAvailableReschedule(trxId, int(prevData.HouseID), checkinDate, checkoutDate)
-	if !isAvailable {
+	// check availability and move the dates in one go
+	_, err = tc.Repository.Reschedule(trxId, checkinDate, checkoutDate)
+	if err == tr.ErrHouseNotAvailable {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, "House already booked at the date, please choose another date!"))
 	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
 
-	data := model.Transaction{
-		CheckinDate:   checkinDate,
-		CheckoutDate:  checkoutDate,
+	tc.Events.Publish(event.Event{
+		Type:    event.BOOKING_RESCHEDULED,
+		UserID:  int(prevData.HostID),
+		Message: fmt.Sprintf("Booking for %v moved to %v until %v", prevData.House.Title, checkinDate.Format("2006-01-02"), checkoutDate.Format("2006-01-02")),
+		Data:    map[string]interface{}{"transaction_id": prevData.ID, "house_id": prevData.HouseID},
+	})
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (tc TransactionController) Cancel(c echo.Context) error {
+	var cancelRequest CancelRequest
+	if err := c.Bind(&cancelRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
-	_, err = tc.Repository.Update(prevData.InvoiceID, data)
+	trxId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
-	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
-}
+	user, _ := mw.ExtractTokenUser(c)
 
-func (tc TransactionController) Callback(c echo.Context) error {
+	transaction, err := tc.Repository.GetByParticipant(user.UserID, trxId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	// only requested, unpaid or paid booking can be cancelled
+	if !transaction.Status.CanTransitionTo(model.CANCELLED_STATUS) {
+		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+	}
+
+	cancelledBy := "guest"
+	if int(transaction.HostID) == user.UserID {
+		cancelledBy = "host"
+	}
+
+	now := time.Now()
 
-	req := c.Request()
-	headers := req.Header
+	var refundAmount float64
+	if transaction.Status == model.PAID_STATUS {
+		// host cancellation always refunds the guest in full
+		refundAmount = transaction.TotalPrice
+		if cancelledBy == "guest" {
+			refundAmount = helper.CalculateRefund(transaction.House.CancellationPolicy, transaction.TotalPrice, transaction.CheckinDate, now)
+		}
+	}
 
-	xCallbackToken := headers.Get("X-Callback-Token")
+	data := model.Transaction{
+		Status:       model.CANCELLED_STATUS,
+		RefundAmount: refundAmount,
+		CancelReason: cancelRequest.Reason,
+		CancelledAt:  now,
+	}
 
-	if xCallbackToken != constant.XENDIT_CALLBACK_TOKEN {
+	// claim the cancellation first, so a concurrent cancel can't refund twice
+	ok, err := tc.Repository.UpdateStatus(trxId, transaction.Status, data, user.UserID, cancelRequest.Reason)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if !ok {
 		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
 	}
 
-	var callbackRequest common.CallbackRequest
-	if err := c.Bind(&callbackRequest); err != nil {
+	var refundError string
+	if transaction.Status == model.PENDING_STATUS {
+		// nothing paid yet, just close the invoice upstream
+		if err := tc.Payment.ExpireInvoice(transaction); err != nil {
+			log.Warn("failed to expire invoice ", transaction.InvoiceID, " upstream: ", err)
+		}
+	} else if refundAmount > 0 {
+		if err := tc.Payment.Refund(transaction, refundAmount); err != nil {
+			// the booking stays cancelled, the failed refund is kept on it
+			// so it can be refunded by hand
+			log.Error("failed to refund transaction ", transaction.InvoiceID, ": ", err)
+
+			refundError = err.Error()
+			if _, err := tc.Repository.Update(transaction.InvoiceID, model.Transaction{RefundError: refundError}); err != nil {
+				log.Error("failed to record refund error of transaction ", transaction.InvoiceID, ": ", err)
+			}
+		} else {
+			refunded, err := tc.Repository.UpdateStatus(trxId, model.CANCELLED_STATUS, model.Transaction{Status: model.REFUNDED_STATUS}, 0, fmt.Sprintf("refunded %v", refundAmount))
+			if err != nil || !refunded {
+				log.Error("failed to mark transaction ", transaction.InvoiceID, " refunded: ", err)
+			} else {
+				data.Status = model.REFUNDED_STATUS
+			}
+		}
+	}
+
+	// tell the other side of the booking
+	notifyUserId := int(transaction.HostID)
+	if cancelledBy == "host" {
+		notifyUserId = int(transaction.UserID)
+	}
+
+	tc.Events.Publish(event.Event{
+		Type:    event.BOOKING_CANCELLED,
+		UserID:  notifyUserId,
+		Message: fmt.Sprintf("Booking for %v was cancelled by the %v", transaction.House.Title, cancelledBy),
+		Data:    map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID},
+	})
+
+	response := CancelResponse{
+		ID:           int(transaction.ID),
+		InvoiceID:    transaction.InvoiceID,
+		Status:       data.Status,
+		TotalPrice:   transaction.TotalPrice,
+		RefundAmount: refundAmount,
+		RefundError:  refundError,
+		CancelledBy:  cancelledBy,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(response))
+}
+
+func (tc TransactionController) Callback(c echo.Context) error {
+	callbackRequest, err := tc.Payment.ParseCallback(c)
+	if err == payment.ErrInvalidSignature || err == payment.ErrInvalidCallbackToken {
+		return c.JSON(http.StatusNotAcceptable, common.NewStatusNotAcceptable())
+	}
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
 	}
 
-	_, err := tc.Repository.GetByInvoice(callbackRequest.ExternalID) 
+	webhookEvent, err := newWebhookEvent(callbackRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	webhookEvent, created, err := tc.Webhooks.Create(webhookEvent)
 	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	// an event delivered again is only processed once
+	if !created && webhookEvent.Result != "" {
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+
+	err = tc.ProcessWebhookEvent(webhookEvent)
+	switch err {
+	case nil, ErrStaleWebhookEvent, ErrWebhookEventClaimed:
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	case ErrUnknownInvoice:
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	case tr.ErrInvalidTransition:
+		// the other delivery of the event moved the transaction on already
+		if !created {
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		}
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
+	case ErrAmountMismatch:
+		return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(http.StatusNotAcceptable, err.Error()))
 	}
 
-	var data model.Transaction
-	data.PaidAt, _ = time.Parse(time.RFC3339, callbackRequest.PaidAt)
-	data.PaymentMethod = callbackRequest.PaymentMethod
-	data.PaymentChannel = callbackRequest.PaymentChannel
-	data.Status = callbackRequest.Status
+	return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+}
 
-	_, err = tc.Repository.Update(callbackRequest.ExternalID, data)
+// ProcessWebhookEvent applies a stored payment event to its transaction and
+// records the outcome on the event. An event older than the last one applied
+// to the invoice is ignored, so a late callback can't undo a newer one. The
+// event is claimed first, so it is applied once however often it is delivered
+// or replayed at the same time.
+func (tc TransactionController) ProcessWebhookEvent(webhookEvent model.WebhookEvent) error {
+	if webhookEvent.Result == wr.APPLIED_RESULT {
+		return ErrWebhookEventClaimed
+	}
+
+	claimed, err := tc.Webhooks.Claim(int(webhookEvent.ID), webhookEvent.Result)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
+	}
+	if !claimed {
+		return ErrWebhookEventClaimed
 	}
 
-	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	err = tc.applyWebhookEvent(webhookEvent)
+
+	result := wr.APPLIED_RESULT
+	if err != nil {
+		result = err.Error()
+	}
+
+	if markErr := tc.Webhooks.MarkProcessed(int(webhookEvent.ID), result); markErr != nil && err == nil {
+		return markErr
+	}
+
+	return err
+}
+
+func (tc TransactionController) applyWebhookEvent(webhookEvent model.WebhookEvent) error {
+	transaction, err := tc.Repository.GetByInvoice(webhookEvent.InvoiceID)
+	if err != nil {
+		return ErrUnknownInvoice
+	}
+
+	latest, err := tc.Webhooks.GetLatestApplied(webhookEvent.InvoiceID)
+	if err == nil && latest.ID != webhookEvent.ID && latest.OccurredAt.After(webhookEvent.OccurredAt) {
+		return ErrStaleWebhookEvent
+	}
+
+	status := model.TransactionStatus(webhookEvent.Status)
+
+	// settled invoices are paid ones whose money reached the balance
+	if webhookEvent.Status == "SETTLED" {
+		status = model.PAID_STATUS
+	}
+
+	// nothing to do for a status the transaction has already
+	if transaction.Status == status {
+		return nil
+	}
+
+	if status == model.PAID_STATUS && math.Abs(webhookEvent.Amount - transaction.TotalPrice) >= 0.01 {
+		return ErrAmountMismatch
+	}
+
+	data := model.Transaction{
+		Status:         status,
+		PaidAt:         webhookEvent.PaidAt,
+		PaymentMethod:  webhookEvent.PaymentMethod,
+		PaymentChannel: webhookEvent.PaymentChannel,
+	}
+
+	ok, err := tc.Repository.UpdateStatus(int(transaction.ID), transaction.Status, data, 0, "payment event " + webhookEvent.EventID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return tr.ErrInvalidTransition
+	}
+
+	eventData := map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID}
+
+	switch status {
+	case model.PAID_STATUS:
+		tc.Events.Publish(event.Event{
+			Type:    event.BOOKING_PAID,
+			UserID:  int(transaction.UserID),
+			Message: fmt.Sprintf("Your booking for %v is paid", transaction.House.Title),
+			Data:    eventData,
+		})
+		tc.Events.Publish(event.Event{
+			Type:    event.BOOKING_PAID,
+			UserID:  int(transaction.HostID),
+			Message: fmt.Sprintf("Booking for %v is paid", transaction.House.Title),
+			Data:    eventData,
+		})
+	case model.EXPIRED_STATUS:
+		tc.Events.Publish(event.Event{
+			Type:    event.BOOKING_EXPIRED,
+			UserID:  int(transaction.UserID),
+			Message: fmt.Sprintf("Your booking for %v expired before it was paid", transaction.House.Title),
+			Data:    eventData,
+		})
+	}
+
+	return nil
 }
 
 func (tc TransactionController) GetAll(c echo.Context) error {
@@ -225,7 +692,12 @@ func (tc TransactionController) GetAll(c echo.Context) error {
 
 	status := c.QueryParam("status")
 
-	transactions, err := tc.Repository.GetAll(user.UserID, status)
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	transactions, result, err := tc.Repository.GetAll(user.UserID, status, page)
 
 	if err != nil {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
@@ -248,10 +720,13 @@ func (tc TransactionController) GetAll(c echo.Context) error {
 			CheckoutDate:  fmt.Sprint(td.CheckoutDate),
 			TotalPrice:    td.TotalPrice,
 			Status:        td.Status,
+			RespondBy:     newRespondBy(td),
+			DeclineReason: td.DeclineReason,
+			Guests:        newGuestsResponse(td),
 		})
 	}
 
-	return c.JSON(http.StatusOK, common.SuccessResponse(transactionDatas))
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, transactionDatas))
 }
 
 func (tc TransactionController) GetAllHostTransaction(c echo.Context) error {
@@ -263,7 +738,12 @@ func (tc TransactionController) GetAllHostTransaction(c echo.Context) error {
 
 	status := c.QueryParam("status")
 
-	transactions, err := tc.Repository.GetAllHostTransaction(user.UserID, status)
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	transactions, result, err := tc.Repository.GetAllHostTransaction(user.UserID, status, page)
 
 	if err != nil {
 		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
@@ -286,10 +766,13 @@ func (tc TransactionController) GetAllHostTransaction(c echo.Context) error {
 			CheckoutDate:  fmt.Sprint(td.CheckoutDate),
 			TotalPrice:    td.TotalPrice,
 			Status:        td.Status,
+			RespondBy:     newRespondBy(td),
+			DeclineReason: td.DeclineReason,
+			Guests:        newGuestsResponse(td),
 		})
 	}
 
-	return c.JSON(http.StatusOK, common.SuccessResponse(transactionDatas))
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, transactionDatas))
 }
 
 func (tc TransactionController) GetByTransaction(c echo.Context) error {
@@ -322,7 +805,123 @@ func (tc TransactionController) GetByTransaction(c echo.Context) error {
 		CheckoutDate:  fmt.Sprint(transaction.CheckoutDate),
 		TotalPrice:    transaction.TotalPrice,
 		Status:        transaction.Status,
+		RespondBy:     newRespondBy(transaction),
+		DeclineReason: transaction.DeclineReason,
+		Guests:        newGuestsResponse(transaction),
+		LineItems:     newLineItemResponses(transaction.LineItems),
 	}
 
 	return c.JSON(http.StatusOK, common.SuccessResponse(transactionData))
+}
+
+// GetHistory lists the status changes of a transaction, oldest first. Guests
+// and hosts see their own transactions, admins any.
+func (tc TransactionController) GetHistory(c echo.Context) error {
+	trxId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	if user.Role == constant.ADMIN_ROLE {
+		_, err = tc.Repository.GetById(trxId)
+	} else {
+		_, err = tc.Repository.GetByParticipant(user.UserID, trxId)
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	history, err := tc.Repository.GetStatusHistory(trxId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	historyData := []StatusHistoryResponse{}
+
+	for _, change := range history {
+		historyData = append(historyData, StatusHistoryResponse{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			ChangedBy:  change.ChangedBy,
+			Note:       change.Note,
+			CreatedAt:  change.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(historyData))
+}
+
+// newWebhookEvent returns ErrMissingEventTime for a callback without the time
+// of its event, the arrival time would let a late callback pass for a new one.
+func newWebhookEvent(callbackRequest common.CallbackRequest) (model.WebhookEvent, error) {
+	webhookEvent := model.WebhookEvent{
+		EventID:        callbackRequest.EventID,
+		InvoiceID:      callbackRequest.ExternalID,
+		Status:         callbackRequest.Status,
+		Amount:         callbackRequest.Amount,
+		PaymentMethod:  callbackRequest.PaymentMethod,
+		PaymentChannel: callbackRequest.PaymentChannel,
+		Payload:        callbackRequest.Payload,
+	}
+
+	// what the guest actually paid, not what was invoiced
+	if callbackRequest.PaidAmount != 0 {
+		webhookEvent.Amount = callbackRequest.PaidAmount
+	}
+
+	webhookEvent.PaidAt, _ = time.Parse(time.RFC3339, callbackRequest.PaidAt)
+
+	occurredAt, err := time.Parse(time.RFC3339, callbackRequest.Updated)
+	if err != nil {
+		return webhookEvent, ErrMissingEventTime
+	}
+	webhookEvent.OccurredAt = occurredAt
+
+	return webhookEvent, nil
+}
+
+// requestDeadline is when the host has to answer a booking request made at
+// now, at the latest when the stay starts.
+func requestDeadline(now, checkinDate time.Time) time.Time {
+	deadline := now.Add(constant.BOOKING_REQUEST_WINDOW)
+	if checkinDate.After(now) && checkinDate.Before(deadline) {
+		return checkinDate
+	}
+
+	return deadline
+}
+
+func newRespondBy(transaction model.Transaction) *time.Time {
+	if transaction.Status != model.REQUESTED_STATUS {
+		return nil
+	}
+
+	return &transaction.RespondBy
+}
+
+func newGuestsResponse(transaction model.Transaction) GuestsResponse {
+	return GuestsResponse{
+		Adults:   transaction.Adults,
+		Children: transaction.Children,
+		Infants:  transaction.Infants,
+		Pets:     transaction.Pets,
+	}
+}
+
+func newLineItemResponses(lineItems []model.TransactionLineItem) []LineItemResponse {
+	responses := []LineItemResponse{}
+
+	for _, lineItem := range lineItems {
+		responses = append(responses, LineItemResponse{
+			Type:      lineItem.Type,
+			Name:      lineItem.Name,
+			UnitPrice: lineItem.UnitPrice,
+			Quantity:  lineItem.Quantity,
+			Amount:    lineItem.Amount,
+		})
+	}
+
+	return responses
 }
\ No newline at end of file
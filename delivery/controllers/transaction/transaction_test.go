@@ -9,13 +9,22 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/payment"
+	tr "github.com/furqonzt99/airbnb/repository/transaction"
+	wr "github.com/furqonzt99/airbnb/repository/webhook"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -27,6 +36,8 @@ import (
 
 var jwtToken string
 
+var fees = helper.Fees{ServicePercentage: 10, TaxPercentage: 11}
+
 func TestMain(m *testing.M)  {
 
 	err := godotenv.Load()
@@ -53,13 +64,13 @@ func TestMain(m *testing.M)  {
 	context := e.NewContext(req, res)
 	context.SetPath("/login")
 
-	userController := user.NewUsersControllers(mockUserRepository{})
+	userController := user.NewUsersControllers(mockUserRepository{}, mailer.NewOutboxMailer(""))
 	userController.LoginController()(context)
 
 	response := common.ResponseSuccess{}
 	json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
-	jwtToken = response.Data.(string)
+	jwtToken = response.Data.(map[string]interface{})["access_token"].(string)
 
 	m.Run()
 }
@@ -91,7 +102,7 @@ func TestBooking(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/booking")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -101,6 +112,8 @@ func TestBooking(t *testing.T)  {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, float64(421800), response.Data.(map[string]interface{})["total_price"])
+		assert.Equal(t, 4, len(response.Data.(map[string]interface{})["line_items"].([]interface{})))
 	})
 	
 	t.Run("Transaction Booking Fail Validator", func(t *testing.T) {
@@ -116,7 +129,7 @@ func TestBooking(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/booking")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -145,7 +158,7 @@ func TestBooking(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/booking")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -174,7 +187,7 @@ func TestBooking(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/booking")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -203,7 +216,36 @@ func TestBooking(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/booking")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Transaction Booking Fail Email Not Verified", func(t *testing.T) {
+
+		reqBody, _ := json.Marshal(TransactionRequest{
+			HouseID:      1,
+			CheckinDate:  checkInDate,
+			CheckoutDate: checkoutDate,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/booking")
+
+		transactionController := NewTransactionController(mockUnverifiedTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -212,8 +254,338 @@ func TestBooking(t *testing.T)  {
 		response := common.ResponseSuccess{}
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+}
+
+func TestBookingHouseRules(t *testing.T) {
+	e := echo.New()
+	e.Validator = &TransactionValidator{Validator: validator.New()}
+
+	checkInDate := fmt.Sprint(time.Now())[:10]
+
+	book := func(request TransactionRequest) common.ResponseSuccess {
+		request.HouseID = 1
+		request.CheckinDate = checkInDate
+		if request.CheckoutDate == "" {
+			request.CheckoutDate = fmt.Sprint(time.Now().AddDate(0, 0, 3))[:10]
+		}
+
+		reqBody, _ := json.Marshal(request)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/booking")
+
+		transactionController := NewTransactionController(mockRulesTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Booking)(context); err != nil {
+			log.Fatal(err)
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return response
+	}
+
+	t.Run("Booking Within Capacity", func(t *testing.T) {
+		response := book(TransactionRequest{Adults: 2, Infants: 1})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		guests := response.Data.(map[string]interface{})["guests"].(map[string]interface{})
+		assert.Equal(t, float64(2), guests["adults"])
+		assert.Equal(t, float64(1), guests["infants"])
+	})
+
+	t.Run("Booking Without Guest Count Is One Adult", func(t *testing.T) {
+		response := book(TransactionRequest{})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, float64(1), response.Data.(map[string]interface{})["guests"].(map[string]interface{})["adults"])
+	})
+
+	t.Run("Booking Fail Over Capacity", func(t *testing.T) {
+		response := book(TransactionRequest{Adults: 2, Children: 1})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, helper.ErrOverCapacity.Error(), response.Message)
+	})
+
+	t.Run("Booking Fail Pets Not Allowed", func(t *testing.T) {
+		response := book(TransactionRequest{Adults: 1, Pets: 1})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, helper.ErrPetsNotAllowed.Error(), response.Message)
+	})
+
+	t.Run("Booking Fail Shorter Than Min Stay", func(t *testing.T) {
+		response := book(TransactionRequest{CheckoutDate: fmt.Sprint(time.Now().AddDate(0, 0, 1))[:10]})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, helper.ErrStayTooShort.Error(), response.Message)
+	})
+
+	t.Run("Booking Fail Longer Than Max Stay", func(t *testing.T) {
+		response := book(TransactionRequest{CheckoutDate: fmt.Sprint(time.Now().AddDate(0, 0, 10))[:10]})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, helper.ErrStayTooLong.Error(), response.Message)
+	})
+
+	t.Run("Booking Fail Negative Guests", func(t *testing.T) {
+		response := book(TransactionRequest{Adults: 1, Children: -1})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestRequestToBook(t *testing.T) {
+	e := echo.New()
+	e.Validator = &TransactionValidator{Validator: validator.New()}
+
+	hostToken, _ := mw.CreateToken(2, "host@gmail.com", constant.HOST_ROLE, 1)
+
+	serve := func(handler func(TransactionController, echo.Context) error, token, id string, body interface{}) common.ResponseSuccess {
+		reqBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/:id")
+		context.SetParamNames("id")
+		context.SetParamValues(id)
+
+		transactionController := NewTransactionController(mockRequestTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		handlerFunc := func(c echo.Context) error {
+			return handler(*transactionController, c)
+		}
+
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(handlerFunc)(context); err != nil {
+			log.Fatal(err)
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return response
+	}
+
+	t.Run("Booking Is Requested", func(t *testing.T) {
+		response := serve(TransactionController.Booking, jwtToken, "", TransactionRequest{
+			HouseID:      1,
+			CheckinDate:  fmt.Sprint(time.Now().AddDate(0, 0, 3))[:10],
+			CheckoutDate: fmt.Sprint(time.Now().AddDate(0, 0, 5))[:10],
+		})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "REQUESTED", data["status"])
+		assert.NotNil(t, data["respond_by"])
+		assert.Equal(t, "", data["payment_url"])
+	})
+
+	t.Run("Approve Request", func(t *testing.T) {
+		response := serve(TransactionController.Approve, hostToken, "1", nil)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "PENDING", data["status"])
+		assert.NotEqual(t, "", data["payment_url"])
+	})
+
+	t.Run("Error Approve By Guest", func(t *testing.T) {
+		response := serve(TransactionController.Approve, jwtToken, "1", nil)
+
 		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
+
+	t.Run("Error Approve After Deadline", func(t *testing.T) {
+		response := serve(TransactionController.Approve, hostToken, "2", nil)
+
+		assert.Equal(t, http.StatusNotAcceptable, response.Code)
+	})
+
+	t.Run("Error Approve Answered Request", func(t *testing.T) {
+		response := serve(TransactionController.Approve, hostToken, "3", nil)
+
+		assert.Equal(t, http.StatusNotAcceptable, response.Code)
+	})
+
+	t.Run("Decline Request", func(t *testing.T) {
+		response := serve(TransactionController.Decline, hostToken, "1", DeclineRequest{Reason: "Dates are blocked"})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "DECLINED", data["status"])
+		assert.Equal(t, "Dates are blocked", data["decline_reason"])
+	})
+
+	t.Run("Error Decline Answered Request", func(t *testing.T) {
+		response := serve(TransactionController.Decline, hostToken, "3", DeclineRequest{})
+
+		assert.Equal(t, http.StatusNotAcceptable, response.Code)
+	})
+
+	t.Run("Cancel Request Without Refund", func(t *testing.T) {
+		response := serve(TransactionController.Cancel, jwtToken, "1", CancelRequest{Reason: "change of plans"})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "CANCELLED", data["status"])
+		assert.Equal(t, float64(0), data["refund_amount"])
+	})
+}
+
+func TestQuote(t *testing.T) {
+	e := echo.New()
+	e.Validator = &TransactionValidator{Validator: validator.New()}
+
+	t.Run("Quote Success", func(t *testing.T) {
+		// monday to wednesday so no weekend pricing applies
+		reqBody, _ := json.Marshal(TransactionRequest{
+			HouseID:      1,
+			CheckinDate:  "2030-01-07",
+			CheckoutDate: "2030-01-09",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/quote")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Quote(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		data := response.Data.(map[string]interface{})
+		lineItems := data["line_items"].([]interface{})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, float64(2), data["total_night"])
+		assert.Equal(t, float64(300000), data["subtotal"])
+		assert.Equal(t, float64(50000), data["cleaning_fee"])
+		assert.Equal(t, float64(30000), data["service_fee"])
+		assert.Equal(t, float64(41800), data["tax"])
+		assert.Equal(t, float64(421800), data["total_price"])
+		assert.Equal(t, 4, len(lineItems))
+		assert.Equal(t, float64(2), lineItems[0].(map[string]interface{})["quantity"])
+	})
+
+	t.Run("Quote Fail checkin date > checkout date", func(t *testing.T) {
+		reqBody, _ := json.Marshal(TransactionRequest{
+			HouseID:      1,
+			CheckinDate:  "2030-01-09",
+			CheckoutDate: "2030-01-07",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/quote")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Quote(context)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Quote Not Found", func(t *testing.T) {
+		reqBody, _ := json.Marshal(TransactionRequest{
+			HouseID:      1,
+			CheckinDate:  "2030-01-07",
+			CheckoutDate: "2030-01-09",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/quote")
+
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Quote(context)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestConcurrentBooking(t *testing.T) {
+	e := echo.New()
+	e.Validator = &TransactionValidator{Validator: validator.New()}
+
+	transactionController := NewTransactionController(mockLockingTransactionRepository{
+		mu:       &sync.Mutex{},
+		bookings: &[]model.Transaction{},
+	}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+	e.POST("/transactions/booking", transactionController.Booking, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+
+	checkInDate := fmt.Sprint(time.Now().AddDate(0, 0, 1))[:10]
+	checkoutDate := fmt.Sprint(time.Now().AddDate(0, 0, 3))[:10]
+
+	const REQUESTS = 20
+
+	var wg sync.WaitGroup
+	codes := make(chan int, REQUESTS)
+
+	for i := 0; i < REQUESTS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			reqBody, _ := json.Marshal(TransactionRequest{
+				HouseID:      1,
+				CheckinDate:  checkInDate,
+				CheckoutDate: checkoutDate,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/transactions/booking", bytes.NewBuffer(reqBody))
+			res := httptest.NewRecorder()
+
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+			e.ServeHTTP(res, req)
+			codes <- res.Code
+		}()
+	}
+
+	wg.Wait()
+	close(codes)
+
+	success := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			success++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, code)
+		}
+	}
+
+	assert.Equal(t, 1, success)
 }
 
 func TestReschedule(t *testing.T)  {
@@ -242,7 +614,7 @@ func TestReschedule(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -269,7 +641,7 @@ func TestReschedule(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -298,7 +670,7 @@ func TestReschedule(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("ada8")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -327,7 +699,36 @@ func TestReschedule(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+	
+	t.Run("Transaction Reschedule Fail Unavailable", func(t *testing.T) {
+
+		reqBody, _ := json.Marshal(RescheduleRequest{
+			CheckinDate:  checkInDate,
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+		
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/reschedule")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		transactionController := NewTransactionController(mockBookedTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -337,6 +738,7 @@ func TestReschedule(t *testing.T)  {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "House already booked at the date, please choose another date!", response.Message)
 	})
 	
 	t.Run("Transaction Reschedule Fail Prev Data Not Found", func(t *testing.T) {
@@ -356,7 +758,7 @@ func TestReschedule(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Reschedule)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -381,7 +783,7 @@ func TestGetAll(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetAll)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -393,6 +795,24 @@ func TestGetAll(t *testing.T)  {
 		assert.Equal(t, http.StatusOK, response.Code)
 	})
 
+	t.Run("Get All Invalid Cursor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?cursor=abc", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetAll)(context)
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
 	t.Run("Get All Failed", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		res := httptest.NewRecorder()
@@ -403,7 +823,7 @@ func TestGetAll(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetAll)(context)
 			
 
@@ -426,7 +846,7 @@ func TestGetAllHost(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/host")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetAllHostTransaction)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -448,7 +868,7 @@ func TestGetAllHost(t *testing.T)  {
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/host")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetAllHostTransaction)(context)
 			
 
@@ -473,7 +893,7 @@ func TestGetByTrx(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetByTransaction)(context); err != nil {
 			log.Fatal(err)
 			return
@@ -497,7 +917,7 @@ func TestGetByTrx(t *testing.T)  {
 		context.SetParamNames("id")
 		context.SetParamValues("1")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetByTransaction)(context)
 
 		response := common.ResponseSuccess{}
@@ -507,10 +927,153 @@ func TestGetByTrx(t *testing.T)  {
 	})
 }
 
-func TestCallback(t *testing.T)  {
+func TestCancel(t *testing.T) {
 	e := echo.New()
 
-	t.Run("Callback Success", func(t *testing.T) {
+	t.Run("Cancel Success With Refund", func(t *testing.T) {
+		paymentProvider := payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN)
+		paymentProvider.CreateInvoice(model.Transaction{
+			InvoiceID:    "JHAKHSHJSIWOAM",
+			CheckinDate:  time.Now().AddDate(0, 0, 3),
+			CheckoutDate: time.Now().AddDate(0, 0, 5),
+			House:        model.House{Price: 150000},
+		}, "test@gmail.com", payment.Invoice{
+			Items: []payment.InvoiceItem{{Name: "House 1", Price: 150000, Quantity: 2}},
+		})
+		paymentProvider.Pay("JHAKHSHJSIWOAM")
+
+		reqBody, _ := json.Marshal(CancelRequest{
+			Reason: "change of plans",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/:id/cancel")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, paymentProvider, fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Cancel)(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		invoice, _ := paymentProvider.Invoice("JHAKHSHJSIWOAM")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "REFUNDED", response.Data.(map[string]interface{})["status"])
+		assert.Equal(t, float64(300000), response.Data.(map[string]interface{})["refund_amount"])
+		assert.Equal(t, float64(300000), invoice.Refunded)
+	})
+
+	t.Run("Cancel Failed Refund", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/:id/cancel")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Cancel)(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		// the booking is cancelled anyway, the refund is left to be done by hand
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "CANCELLED", response.Data.(map[string]interface{})["status"])
+		assert.NotEmpty(t, response.Data.(map[string]interface{})["refund_error"])
+	})
+
+	t.Run("Cancel Concurrently Refunds Once", func(t *testing.T) {
+		paymentProvider := payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN)
+		paymentProvider.CreateInvoice(model.Transaction{
+			InvoiceID:    "JHAKHSHJSIWOAM",
+			CheckinDate:  time.Now().AddDate(0, 0, 3),
+			CheckoutDate: time.Now().AddDate(0, 0, 5),
+			House:        model.House{Price: 150000},
+		}, "test@gmail.com", payment.Invoice{
+			Items: []payment.InvoiceItem{{Name: "House 1", Price: 150000, Quantity: 2}},
+		})
+		paymentProvider.Pay("JHAKHSHJSIWOAM")
+
+		transactionController := NewTransactionController(&mockCancelOnceTransactionRepository{}, mockWebhookRepository{}, paymentProvider, fees, event.NewBus(nil))
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 2)
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				res := httptest.NewRecorder()
+
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+				context := e.NewContext(req, res)
+				context.SetPath("/transactions/:id/cancel")
+				context.SetParamNames("id")
+				context.SetParamValues("1")
+
+				middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Cancel)(context)
+				codes <- res.Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		got := []int{}
+		for code := range codes {
+			got = append(got, code)
+		}
+
+		invoice, _ := paymentProvider.Invoice("JHAKHSHJSIWOAM")
+
+		assert.ElementsMatch(t, []int{http.StatusOK, http.StatusNotAcceptable}, got)
+		assert.Equal(t, float64(300000), invoice.Refunded)
+	})
+
+	t.Run("Cancel Not Found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/:id/cancel")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.Cancel)(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestCallback(t *testing.T)  {
+	e := echo.New()
+
+	t.Run("Callback Success", func(t *testing.T) {
 
 		reqBody, _ := json.Marshal(common.CallbackRequest{
 			ExternalID:     "JHAKHSHJSIWOAM",
@@ -518,18 +1081,20 @@ func TestCallback(t *testing.T)  {
 			PaymentChannel: "BRI",
 			PaidAt:         fmt.Sprint(time.Now()),
 			Status:         "PAID",
+			Amount:         300000,
+			Updated:        time.Now().Format(time.RFC3339),
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Callback-Token", constant.XENDIT_CALLBACK_TOKEN)
+		req.Header.Set("X-Callback-Signature", payment.SignCallback(constant.XENDIT_CALLBACK_TOKEN, reqBody))
 		
 		res := httptest.NewRecorder()
 		
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/callback")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		transactionController.Callback(context)
 
 		response := common.DefaultResponse{}
@@ -546,18 +1111,19 @@ func TestCallback(t *testing.T)  {
 			PaymentChannel: "BRI",
 			PaidAt:         fmt.Sprint(time.Now()),
 			Status:         "PAID",
+			Updated:        time.Now().Format(time.RFC3339),
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Callback-Token", constant.XENDIT_CALLBACK_TOKEN)
+		req.Header.Set("X-Callback-Signature", payment.SignCallback(constant.XENDIT_CALLBACK_TOKEN, reqBody))
 		
 		res := httptest.NewRecorder()
 		
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/callback")
 
-		transactionController := NewTransactionController(mockFalseTransactionRepository{})
+		transactionController := NewTransactionController(mockFalseTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		transactionController.Callback(context)
 
 		response := common.DefaultResponse{}
@@ -574,27 +1140,309 @@ func TestCallback(t *testing.T)  {
 			PaymentChannel: "BRI",
 			PaidAt:         fmt.Sprint(time.Now()),
 			Status:         "PAID",
+			Amount:         300000,
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Callback-Token", constant.XENDIT_CALLBACK_TOKEN + "false")
+		req.Header.Set("X-Callback-Signature", payment.SignCallback(constant.XENDIT_CALLBACK_TOKEN + "false", reqBody))
 		
 		res := httptest.NewRecorder()
 		
 		context := e.NewContext(req, res)
 		context.SetPath("/transactions/callback")
 
-		transactionController := NewTransactionController(mockTransactionRepository{})
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
+		response := common.DefaultResponse{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Callback Paid From Fake Provider", func(t *testing.T) {
+		paymentProvider := payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN)
+		paymentProvider.CreateInvoice(model.Transaction{
+			InvoiceID:    "JHAKHSHJSIWOAM",
+			CheckinDate:  time.Now(),
+			CheckoutDate: time.Now().AddDate(0, 0, 2),
+			House:        model.House{Price: 150000},
+		}, "test@gmail.com", payment.Invoice{
+			Items: []payment.InvoiceItem{{Name: "House 1", Price: 150000, Quantity: 2}},
+		})
+
+		req, err := paymentProvider.Pay("JHAKHSHJSIWOAM")
+		assert.Nil(t, err)
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, paymentProvider, fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
+		invoice, _ := paymentProvider.Invoice("JHAKHSHJSIWOAM")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "PAID", invoice.Status)
+		assert.Equal(t, float64(300000), invoice.Amount)
+	})
+
+	t.Run("Callback Expired From Fake Provider", func(t *testing.T) {
+		paymentProvider := payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN)
+		paymentProvider.CreateInvoice(model.Transaction{
+			InvoiceID:    "JHAKHSHJSIWOAM",
+			CheckinDate:  time.Now(),
+			CheckoutDate: time.Now().AddDate(0, 0, 2),
+			House:        model.House{Price: 150000},
+		}, "test@gmail.com", payment.Invoice{
+			Items: []payment.InvoiceItem{{Name: "House 1", Price: 150000, Quantity: 2}},
+		})
+
+		req, err := paymentProvider.Expire("JHAKHSHJSIWOAM")
+		assert.Nil(t, err)
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, paymentProvider, fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
+		invoice, _ := paymentProvider.Invoice("JHAKHSHJSIWOAM")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "EXPIRED", invoice.Status)
+	})
+
+	callback := func(repo tr.Transaction, webhookRepo wr.WebhookInterface, callbackRequest common.CallbackRequest) *httptest.ResponseRecorder {
+		callbackRequest.ExternalID = "JHAKHSHJSIWOAM"
+		if callbackRequest.Updated == "" {
+			callbackRequest.Updated = time.Now().Format(time.RFC3339)
+		}
+		reqBody, _ := json.Marshal(callbackRequest)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Callback-Signature", payment.SignCallback(constant.XENDIT_CALLBACK_TOKEN, reqBody))
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(repo, webhookRepo, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
+		return res
+	}
+
+	t.Run("Callback Same Status Is Ignored", func(t *testing.T) {
+		res := callback(mockExpiredTransactionRepository{}, mockWebhookRepository{}, common.CallbackRequest{Status: "EXPIRED"})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback Invalid Transition", func(t *testing.T) {
+		res := callback(mockExpiredTransactionRepository{}, mockWebhookRepository{}, common.CallbackRequest{Status: "PAID", Amount: 300000})
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Callback Delivered Twice Is Processed Once", func(t *testing.T) {
+		// the transaction isn't even looked up for a processed event
+		res := callback(mockFalseTransactionRepository{}, mockProcessedWebhookRepository{}, common.CallbackRequest{Status: "PAID", Amount: 300000})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback Delivered Concurrently Is Applied Once", func(t *testing.T) {
+		marked := false
+
+		// the transaction isn't even looked up for an event claimed by the other delivery
+		res := callback(mockFalseTransactionRepository{}, mockClaimedWebhookRepository{marked: &marked}, common.CallbackRequest{Status: "PAID", Amount: 300000})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.False(t, marked)
+	})
+
+	t.Run("Callback Delivered Again After Transition", func(t *testing.T) {
+		res := callback(mockExpiredTransactionRepository{}, mockRedeliveredWebhookRepository{}, common.CallbackRequest{Status: "PAID", Amount: 300000})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback Without Event Time", func(t *testing.T) {
+		reqBody, _ := json.Marshal(common.CallbackRequest{ExternalID: "JHAKHSHJSIWOAM", Status: "PAID", Amount: 300000})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Callback-Signature", payment.SignCallback(constant.XENDIT_CALLBACK_TOKEN, reqBody))
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
 		transactionController.Callback(context)
 
 		response := common.DefaultResponse{}
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Equal(t, ErrMissingEventTime.Error(), response.Message)
+	})
+
+	t.Run("Callback Older Than Applied Event Is Ignored", func(t *testing.T) {
+		// the wrong amount would be rejected if the event was applied
+		res := callback(mockTransactionRepository{}, mockNewerWebhookRepository{}, common.CallbackRequest{
+			Status:  "PAID",
+			Amount:  1,
+			Updated: time.Now().Format(time.RFC3339),
+		})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback Settled Is Paid", func(t *testing.T) {
+		res := callback(mockTransactionRepository{}, mockWebhookRepository{}, common.CallbackRequest{Status: "SETTLED", Amount: 300000})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback Amount Mismatch", func(t *testing.T) {
+		res := callback(mockTransactionRepository{}, mockWebhookRepository{}, common.CallbackRequest{Status: "PAID", Amount: 310000, PaidAmount: 100000})
+
+		response := common.DefaultResponse{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+		assert.Equal(t, ErrAmountMismatch.Error(), response.Message)
+	})
+
+	xenditCallback := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{
+			"id": "579c8d61f23fa4ca35e52da4",
+			"external_id": "JHAKHSHJSIWOAM",
+			"user_id": "5781d19b2e2385880609791c",
+			"payment_method": "BANK_TRANSFER",
+			"status": "PAID",
+			"amount": 300000,
+			"paid_amount": 300000,
+			"bank_code": "BRI",
+			"paid_at": "2022-01-13T02:32:50.912Z",
+			"payer_email": "test@gmail.com",
+			"created": "2022-01-13T02:30:01.123Z",
+			"updated": "2022-01-13T02:32:51.256Z",
+			"currency": "IDR",
+			"payment_channel": "BRI"
+		}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CALLBACK-TOKEN", token)
+		req.Header.Set("webhook-id", "whk-1642041171256")
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewXenditProvider("", "xendit-token"), fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
+		return res
+	}
+
+	t.Run("Callback Paid From Xendit", func(t *testing.T) {
+		res := xenditCallback("xendit-token")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Callback From Xendit Wrong Token", func(t *testing.T) {
+		res := xenditCallback("wrong-token")
+
+		assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+
+	t.Run("Callback Without Signature", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"external_id":"JHAKHSHJSIWOAM","status":"PAID"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/callback")
+
+		transactionController := NewTransactionController(mockTransactionRepository{}, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		transactionController.Callback(context)
+
 		assert.Equal(t, http.StatusNotAcceptable, res.Code)
 	})
 }
 
+func TestGetHistory(t *testing.T) {
+	e := echo.New()
+
+	adminToken, _ := mw.CreateToken(3, "admin@gmail.com", constant.ADMIN_ROLE, 1)
+
+	serve := func(repo tr.Transaction, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/transactions/:id/history")
+		context.SetParamNames("id")
+		context.SetParamValues("1")
+
+		transactionController := NewTransactionController(repo, mockWebhookRepository{}, payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN), fees, event.NewBus(nil))
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(transactionController.GetHistory)(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	t.Run("Get History", func(t *testing.T) {
+		res := serve(mockTransactionRepository{}, jwtToken)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		history := response.Data.([]interface{})
+		assert.Equal(t, 2, len(history))
+
+		created := history[0].(map[string]interface{})
+		assert.Nil(t, created["from_status"])
+		assert.Equal(t, "PENDING", created["to_status"])
+		assert.Equal(t, float64(1), created["changed_by"])
+
+		paid := history[1].(map[string]interface{})
+		assert.Equal(t, "PENDING", paid["from_status"])
+		assert.Equal(t, "PAID", paid["to_status"])
+		assert.Nil(t, paid["changed_by"])
+	})
+
+	t.Run("Get History As Admin", func(t *testing.T) {
+		res := serve(mockTransactionRepository{}, adminToken)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Get History Not Found", func(t *testing.T) {
+		res := serve(mockFalseTransactionRepository{}, jwtToken)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
 type mockUserRepository struct{}
 
 func (m mockUserRepository) Register(newUser model.User) (model.User, error) {
@@ -629,9 +1477,56 @@ func (m mockUserRepository) Delete(userId int) (model.User, error) {
 	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
 }
 
+func (m mockUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return []model.User{{Email: "test@gmail.com", Name: "tester", Role: "guest"}}, pagination.Result{}, nil
+}
+
+func (m mockUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", Role: "guest", Suspended: suspended}, nil
+}
+
+func (m mockUserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	refreshToken.ID = 1
+	return refreshToken, nil
+}
+
+func (m mockUserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	return model.RefreshToken{}, errors.New("record not found")
+}
+
+func (m mockUserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	next.ID = prev.ID + 1
+	return next, nil
+}
+
+func (m mockUserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return nil
+}
+
+func (m mockUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return nil
+}
+
+func (m mockUserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	return true
+}
+
+func (m mockUserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	userToken.ID = 1
+	return userToken, nil
+}
+
+func (m mockUserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
+func (m mockUserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
 type mockTransactionRepository struct{}
 
-func (tr mockTransactionRepository) GetAll(userId int, status string) ([]model.Transaction, error) {
+func (tr mockTransactionRepository) GetAll(userId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
 	return []model.Transaction{{
 		Model:          gorm.Model{
 			ID:        1,
@@ -648,10 +1543,10 @@ func (tr mockTransactionRepository) GetAll(userId int, status string) ([]model.T
 		CheckoutDate:   time.Now().AddDate(0, 0, 2),
 		TotalPrice:     300000,
 		Status:         "PENDING",
-	}}, nil
+	}}, pagination.Result{}, nil
 }
 
-func (tr mockTransactionRepository) GetAllHostTransaction(hostId int, status string) ([]model.Transaction, error) {
+func (tr mockTransactionRepository) GetAllHostTransaction(hostId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
 	return []model.Transaction{{
 		Model:          gorm.Model{
 			ID:        1,
@@ -668,7 +1563,7 @@ func (tr mockTransactionRepository) GetAllHostTransaction(hostId int, status str
 		CheckoutDate:   time.Now().AddDate(0, 0, 2),
 		TotalPrice:     300000,
 		Status:         "PENDING",
-	}}, nil
+	}}, pagination.Result{}, nil
 }
 
 func (tr mockTransactionRepository) GetByTransactionId(userId, trxId int) (model.Transaction, error) {
@@ -691,16 +1586,57 @@ func (tr mockTransactionRepository) GetByTransactionId(userId, trxId int) (model
 	}, nil
 }
 
+func (tr mockTransactionRepository) GetByParticipant(userId, trxId int) (model.Transaction, error) {
+	return model.Transaction{
+		Model:          gorm.Model{
+			ID:        1,
+		},
+		UserID:         1,
+		HouseID:        3,
+		HostID:         2,
+		InvoiceID:      "JHAKHSHJSIWOAM",
+		PaymentUrl:     "url",
+		PaymentChannel: "",
+		PaymentMethod:  "",
+		PaidAt:         time.Time{},
+		CheckinDate:    time.Now().AddDate(0, 0, 3),
+		CheckoutDate:   time.Now().AddDate(0, 0, 5),
+		TotalPrice:     300000,
+		Status:         "PAID",
+		House:          model.House{
+			Price:              150000,
+			CancellationPolicy: "flexible",
+		},
+	}, nil
+}
+
 func (tr mockTransactionRepository) GetHostId(houseId int) (int, error) {
 	return int(1), nil
 }
 
-func (tr mockTransactionRepository) IsHouseAvailable(houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
-	return true, nil
+func (tr mockTransactionRepository) GetHouse(houseId int) (model.House, error) {
+	return model.House{
+		Model:       gorm.Model{ID: 1},
+		UserID:      1,
+		Title:       "House 1",
+		Price:       150000,
+		CleaningFee: 50000,
+	}, nil
 }
 
-func (tr mockTransactionRepository) IsHouseAvailableReschedule(trxId, houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
-	return true, nil
+func (tr mockTransactionRepository) GetUser(userId int) (model.User, error) {
+	return model.User{Model: gorm.Model{ID: uint(userId)}, Email: "test@gmail.com", EmailVerified: true}, nil
+}
+
+func (tr mockTransactionRepository) GetPendingBefore(before time.Time) ([]model.Transaction, error) {
+	return []model.Transaction{}, nil
+}
+
+func (tr mockTransactionRepository) Reschedule(trxId int, checkinDate, checkoutDate time.Time) (model.Transaction, error) {
+	transaction, _ := tr.GetByTransactionId(1, trxId)
+	transaction.CheckinDate = checkinDate
+	transaction.CheckoutDate = checkoutDate
+	return transaction, nil
 }
 
 func (tr mockTransactionRepository) Get(userId int) (model.Transaction, error) {
@@ -743,7 +1679,7 @@ func (tr mockTransactionRepository) GetByInvoice(invId string) (model.Transactio
 	}, nil
 }
 
-func (tr mockTransactionRepository) Create(transaction model.Transaction) (model.Transaction, error) {
+func (tr mockTransactionRepository) CreateIfAvailable(transaction model.Transaction) (model.Transaction, error) {
 	return model.Transaction{
 		Model:          gorm.Model{ID: 1},
 		UserID:         1,
@@ -794,30 +1730,174 @@ func (tr mockTransactionRepository) Update(invId string, transaction model.Trans
 	}, nil
 }
 
+func (tr mockTransactionRepository) ExpirePending(invId string) (bool, error) {
+	return true, nil
+}
+
+func (tr mockTransactionRepository) GetRequestedBefore(before time.Time) ([]model.Transaction, error) {
+	return []model.Transaction{}, nil
+}
+
+func (tr mockTransactionRepository) GetCheckinBefore(before time.Time) ([]model.Transaction, error) {
+	return []model.Transaction{}, nil
+}
+
+func (tr mockTransactionRepository) GetCheckoutBefore(before time.Time) ([]model.Transaction, error) {
+	return []model.Transaction{}, nil
+}
+
+func (tr mockTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	return from.CanTransitionTo(transaction.Status), nil
+}
+
+func (tr mockTransactionRepository) GetStatusHistory(trxId int) ([]model.TransactionStatusHistory, error) {
+	guestId := uint(1)
+
+	return []model.TransactionStatusHistory{
+		{TransactionID: uint(trxId), ToStatus: model.PENDING_STATUS, ChangedBy: &guestId},
+		{TransactionID: uint(trxId), FromStatus: model.PENDING_STATUS, ToStatus: model.PAID_STATUS},
+	}, nil
+}
+
+func (tr mockTransactionRepository) GetById(trxId int) (model.Transaction, error) {
+	return tr.Get(1)
+}
+
+func (tr mockTransactionRepository) ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error) {
+	transaction, _ := tr.Get(1)
+	transaction.Status = status
+	return transaction, nil
+}
+
+type mockUnverifiedTransactionRepository struct {
+	mockTransactionRepository
+}
+
+func (tr mockUnverifiedTransactionRepository) GetUser(userId int) (model.User, error) {
+	return model.User{Model: gorm.Model{ID: uint(userId)}, Email: "test@gmail.com"}, nil
+}
+
+// mockExpiredTransactionRepository returns a booking that expired before it
+// was paid.
+type mockExpiredTransactionRepository struct {
+	mockTransactionRepository
+}
+
+func (tr mockExpiredTransactionRepository) GetByInvoice(invId string) (model.Transaction, error) {
+	transaction, _ := tr.mockTransactionRepository.GetByInvoice(invId)
+	transaction.Status = model.EXPIRED_STATUS
+
+	return transaction, nil
+}
+
+// mockRulesTransactionRepository books a house for two guests without pets,
+// for 2 to 5 nights.
+type mockRulesTransactionRepository struct {
+	mockTransactionRepository
+}
+
+func (tr mockRulesTransactionRepository) GetHouse(houseId int) (model.House, error) {
+	return model.House{
+		Model:     gorm.Model{ID: 1},
+		UserID:    1,
+		Title:     "House 1",
+		Price:     150000,
+		MaxGuests: 2,
+		MinStay:   2,
+		MaxStay:   5,
+	}, nil
+}
+
+// mockRequestTransactionRepository books a house that is not instant book.
+// Request 1 is open, request 2 is past its deadline and request 3 was already
+// answered.
+type mockRequestTransactionRepository struct {
+	mockTransactionRepository
+}
+
+func (tr mockRequestTransactionRepository) GetHouse(houseId int) (model.House, error) {
+	instantBook := false
+
+	return model.House{
+		Model:       gorm.Model{ID: 1},
+		UserID:      2,
+		Title:       "House 1",
+		Price:       150000,
+		InstantBook: &instantBook,
+	}, nil
+}
+
+func (tr mockRequestTransactionRepository) GetByParticipant(userId, trxId int) (model.Transaction, error) {
+	transaction := model.Transaction{
+		Model:        gorm.Model{ID: uint(trxId)},
+		UserID:       1,
+		HouseID:      1,
+		HostID:       2,
+		InvoiceID:    "REQUESTED-" + fmt.Sprint(trxId),
+		CheckinDate:  time.Now().AddDate(0, 0, 3),
+		CheckoutDate: time.Now().AddDate(0, 0, 5),
+		TotalPrice:   300000,
+		Status:       "REQUESTED",
+		RespondBy:    time.Now().Add(time.Hour),
+		User:         model.User{Email: "test@gmail.com"},
+		House:        model.House{Title: "House 1", Price: 150000},
+		LineItems:    []model.TransactionLineItem{{Type: helper.NIGHT_LINE, Name: "House 1", UnitPrice: 150000, Quantity: 2, Amount: 300000}},
+	}
+
+	switch trxId {
+	case 2:
+		transaction.RespondBy = time.Now().Add(-time.Hour)
+	case 3:
+		transaction.Status = "DECLINED"
+	}
+
+	return transaction, nil
+}
+
+func (m mockRequestTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	if !from.CanTransitionTo(transaction.Status) {
+		return false, tr.ErrInvalidTransition
+	}
+
+	return true, nil
+}
+
 type mockFalseTransactionRepository struct{}
 
-func (tr mockFalseTransactionRepository) GetAll(userId int, status string) ([]model.Transaction, error) {
-	return []model.Transaction{{}}, errors.New("Error")
+func (tr mockFalseTransactionRepository) GetAll(userId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
+	return []model.Transaction{{}}, pagination.Result{}, errors.New("Error")
 }
 
-func (tr mockFalseTransactionRepository) GetAllHostTransaction(hostId int, status string) ([]model.Transaction, error) {
-	return []model.Transaction{{}}, errors.New("Error")
+func (tr mockFalseTransactionRepository) GetAllHostTransaction(hostId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
+	return []model.Transaction{{}}, pagination.Result{}, errors.New("Error")
 }
 
 func (tr mockFalseTransactionRepository) GetByTransactionId(userId, trxId int) (model.Transaction, error) {
 	return model.Transaction{}, errors.New("Error")
 }
 
+func (tr mockFalseTransactionRepository) GetByParticipant(userId, trxId int) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
+}
+
 func (tr mockFalseTransactionRepository) GetHostId(houseId int) (int, error) {
 	return int(0), errors.New("Error")
 }
 
-func (tr mockFalseTransactionRepository) IsHouseAvailable(houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
-	return false, errors.New("Error")
+func (tr mockFalseTransactionRepository) GetHouse(houseId int) (model.House, error) {
+	return model.House{}, errors.New("Error")
 }
 
-func (tr mockFalseTransactionRepository) IsHouseAvailableReschedule(trxId, houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
-	return false, errors.New("Error")
+func (tr mockFalseTransactionRepository) GetUser(userId int) (model.User, error) {
+	return model.User{Model: gorm.Model{ID: uint(userId)}, Email: "test@gmail.com", EmailVerified: true}, nil
+}
+
+func (tr mockFalseTransactionRepository) GetPendingBefore(before time.Time) ([]model.Transaction, error) {
+	return nil, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) Reschedule(trxId int, checkinDate, checkoutDate time.Time) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
 }
 
 func (tr mockFalseTransactionRepository) Get(userId int) (model.Transaction, error) {
@@ -828,10 +1908,177 @@ func (tr mockFalseTransactionRepository) GetByInvoice(invId string) (model.Trans
 	return model.Transaction{}, errors.New("Error")
 }
 
-func (tr mockFalseTransactionRepository) Create(transaction model.Transaction) (model.Transaction, error) {
+func (tr mockFalseTransactionRepository) CreateIfAvailable(transaction model.Transaction) (model.Transaction, error) {
 	return model.Transaction{}, errors.New("Error")
 }
 
 func (tr mockFalseTransactionRepository) Update(invId string, transaction model.Transaction) (model.Transaction, error) {
 	return model.Transaction{}, errors.New("Error")
-}
\ No newline at end of file
+}
+
+func (tr mockFalseTransactionRepository) ExpirePending(invId string) (bool, error) {
+	return false, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) GetRequestedBefore(before time.Time) ([]model.Transaction, error) {
+	return nil, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) GetCheckinBefore(before time.Time) ([]model.Transaction, error) {
+	return nil, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) GetCheckoutBefore(before time.Time) ([]model.Transaction, error) {
+	return nil, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	return false, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) GetStatusHistory(trxId int) ([]model.TransactionStatusHistory, error) {
+	return nil, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) GetById(trxId int) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
+}
+
+func (tr mockFalseTransactionRepository) ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error) {
+	return model.Transaction{}, errors.New("Error")
+}
+
+// mockBookedTransactionRepository has the new dates of every reschedule taken
+type mockBookedTransactionRepository struct {
+	mockTransactionRepository
+}
+
+func (m mockBookedTransactionRepository) Reschedule(trxId int, checkinDate, checkoutDate time.Time) (model.Transaction, error) {
+	return model.Transaction{}, tr.ErrHouseNotAvailable
+}
+
+// mockLockingTransactionRepository keeps bookings in memory and guards
+// CreateIfAvailable with a mutex, the same guarantee the house row lock gives.
+type mockLockingTransactionRepository struct {
+	mockTransactionRepository
+	mu       *sync.Mutex
+	bookings *[]model.Transaction
+}
+
+func (m mockLockingTransactionRepository) CreateIfAvailable(transaction model.Transaction) (model.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, booking := range *m.bookings {
+		if booking.HouseID == transaction.HouseID && booking.CheckoutDate.After(transaction.CheckinDate) && booking.CheckinDate.Before(transaction.CheckoutDate) {
+			return transaction, tr.ErrHouseNotAvailable
+		}
+	}
+
+	transaction.ID = uint(len(*m.bookings) + 1)
+	transaction.House = model.House{Title: "House 1", Price: 150000}
+	*m.bookings = append(*m.bookings, transaction)
+
+	return transaction, nil
+}
+
+// mockCancelOnceTransactionRepository keeps the status so only the first of
+// concurrent status updates from PAID wins, like the conditional update does
+type mockCancelOnceTransactionRepository struct {
+	mockTransactionRepository
+	mu        sync.Mutex
+	cancelled bool
+}
+
+func (m *mockCancelOnceTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	if transaction.Status != model.CANCELLED_STATUS {
+		return true, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancelled {
+		return false, nil
+	}
+
+	m.cancelled = true
+
+	return true, nil
+}
+
+type mockWebhookRepository struct{}
+
+func (m mockWebhookRepository) Create(webhookEvent model.WebhookEvent) (model.WebhookEvent, bool, error) {
+	webhookEvent.ID = 1
+	return webhookEvent, true, nil
+}
+
+func (m mockWebhookRepository) Get(webhookEventId int) (model.WebhookEvent, error) {
+	return model.WebhookEvent{Model: gorm.Model{ID: uint(webhookEventId)}, EventID: "EVENT1", InvoiceID: "JHAKHSHJSIWOAM", Status: "PAID", Amount: 300000}, nil
+}
+
+func (m mockWebhookRepository) GetAll(invId string, page pagination.Page) ([]model.WebhookEvent, pagination.Result, error) {
+	return []model.WebhookEvent{}, pagination.Result{}, nil
+}
+
+func (m mockWebhookRepository) GetLatestApplied(invId string) (model.WebhookEvent, error) {
+	return model.WebhookEvent{}, gorm.ErrRecordNotFound
+}
+
+func (m mockWebhookRepository) Claim(webhookEventId int, result string) (bool, error) {
+	return true, nil
+}
+
+func (m mockWebhookRepository) MarkProcessed(webhookEventId int, result string) error {
+	return nil
+}
+
+// mockProcessedWebhookRepository has every event stored and processed
+// already, like a callback delivered twice.
+type mockProcessedWebhookRepository struct {
+	mockWebhookRepository
+}
+
+func (m mockProcessedWebhookRepository) Create(webhookEvent model.WebhookEvent) (model.WebhookEvent, bool, error) {
+	webhookEvent.ID = 1
+	webhookEvent.ProcessedAt = time.Now()
+	webhookEvent.Result = wr.APPLIED_RESULT
+	return webhookEvent, false, nil
+}
+
+// mockRedeliveredWebhookRepository has every event stored but not processed
+// yet, like a callback delivered again while the first delivery is handled.
+type mockRedeliveredWebhookRepository struct {
+	mockWebhookRepository
+}
+
+func (m mockRedeliveredWebhookRepository) Create(webhookEvent model.WebhookEvent) (model.WebhookEvent, bool, error) {
+	webhookEvent.ID = 1
+	return webhookEvent, false, nil
+}
+
+// mockClaimedWebhookRepository loses every claim to the other delivery and
+// records whether the event was marked processed anyway.
+type mockClaimedWebhookRepository struct {
+	mockRedeliveredWebhookRepository
+	marked *bool
+}
+
+func (m mockClaimedWebhookRepository) Claim(webhookEventId int, result string) (bool, error) {
+	return false, nil
+}
+
+func (m mockClaimedWebhookRepository) MarkProcessed(webhookEventId int, result string) error {
+	*m.marked = true
+	return nil
+}
+
+// mockNewerWebhookRepository has an event from an hour from now applied.
+type mockNewerWebhookRepository struct {
+	mockWebhookRepository
+}
+
+func (m mockNewerWebhookRepository) GetLatestApplied(invId string) (model.WebhookEvent, error) {
+	return model.WebhookEvent{Model: gorm.Model{ID: 2}, InvoiceID: invId, Status: "EXPIRED", OccurredAt: time.Now().Add(time.Hour)}, nil
+}
@@ -11,6 +11,7 @@ type RegisterUserRequestFormat struct {
 	Name     string `json:"name" form:"name" validate:"required"`
 	Email    string `json:"email" form:"email" validate:"required,email"`
 	Password string `json:"password" form:"password" validate:"required,min=8"`
+	Role     string `json:"role" form:"role" validate:"omitempty,oneof=guest host"`
 }
 
 type PutUserRequestFormat struct {
@@ -24,6 +25,23 @@ type UserLoginRequestFormat struct {
 	Password string `json:"password" form:"password" validate:"required,min=8"`
 }
 
+type RefreshTokenRequestFormat struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required"`
+}
+
+type ForgotPasswordRequestFormat struct {
+	Email string `json:"email" form:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequestFormat struct {
+	Token    string `json:"token" form:"token" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required,min=8"`
+}
+
+type UpdateRoleRequestFormat struct {
+	Role string `json:"role" form:"role" validate:"required,oneof=guest host admin"`
+}
+
 type UserValidator struct {
 	Validator *validator.Validate
 }
@@ -1,23 +1,29 @@
 package user
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
 	"github.com/furqonzt99/airbnb/model"
-	"github.com/furqonzt99/airbnb/repository/user"
+	ur "github.com/furqonzt99/airbnb/repository/user"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserController struct {
-	Repo user.UserInterface
+	Repo   ur.UserInterface
+	Mailer mailer.Mailer
 }
 
-func NewUsersControllers(usrep user.UserInterface) *UserController {
-	return &UserController{Repo: usrep}
+func NewUsersControllers(usrep ur.UserInterface, mail mailer.Mailer) *UserController {
+	return &UserController{Repo: usrep, Mailer: mail}
 }
 
 func (uscon UserController) RegisterController() echo.HandlerFunc {
@@ -29,11 +35,17 @@ func (uscon UserController) RegisterController() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
 		}
 
+		role := newUserReq.Role
+		if role == "" {
+			role = constant.GUEST_ROLE
+		}
+
 		hash, _ := bcrypt.GenerateFromPassword([]byte(newUserReq.Password), 14)
 		newUser := model.User{
 			Name:     newUserReq.Name,
 			Email:    newUserReq.Email,
 			Password: string(hash),
+			Role:     role,
 		}
 
 		res, err := uscon.Repo.Register(newUser)
@@ -41,10 +53,17 @@ func (uscon UserController) RegisterController() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.ErrorResponse(406, "Email already exist"))
 		}
 
+		// the account is usable without it, only booking waits for verification
+		if err := uscon.sendVerificationEmail(res); err != nil {
+			c.Logger().Error(err)
+		}
+
 		data := UserResponse{
-			ID:    res.ID,
-			Name:  res.Name,
-			Email: res.Email,
+			ID:            res.ID,
+			Name:          res.Name,
+			Email:         res.Email,
+			Role:          res.Role,
+			EmailVerified: res.EmailVerified,
 		}
 		return c.JSON(http.StatusOK, common.SuccessResponse(data))
 	}
@@ -69,13 +88,108 @@ func (uscon UserController) LoginController() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.ErrorResponse(403, "Wrong Password"))
 		}
 
-		var token string
+		if user.Suspended {
+			return c.JSON(http.StatusForbidden, common.ErrorResponse(403, "Account suspended"))
+		}
+
+		if !hash {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(403, "Wrong Password"))
+		}
+
+		refreshToken, err := helper.GenerateToken()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		session, err := uscon.Repo.CreateRefreshToken(model.RefreshToken{
+			UserID:    user.ID,
+			TokenHash: helper.HashToken(refreshToken),
+			ExpiresAt: time.Now().Add(constant.REFRESH_TOKEN_TTL),
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		token, _ := middleware.CreateToken(int(user.ID), user.Email, user.Role, int(session.ID))
 
-		if hash {
-			token, _ = middleware.CreateToken(int(user.ID), user.Email)
+		data := TokenResponse{
+			AccessToken:  token,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int(constant.ACCESS_TOKEN_TTL.Seconds()),
 		}
 
-		return c.JSON(http.StatusOK, common.SuccessResponse(token))
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (uscon UserController) RefreshTokenController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		refreshReq := RefreshTokenRequestFormat{}
+		c.Bind(&refreshReq)
+
+		if err := c.Validate(refreshReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		session, err := uscon.Repo.GetRefreshToken(helper.HashToken(refreshReq.RefreshToken))
+		if err != nil || session.ExpiresAt.Before(time.Now()) {
+			return c.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "Invalid refresh token"))
+		}
+
+		// a revoked token coming back means it leaked, end every session
+		if !session.RevokedAt.IsZero() {
+			uscon.Repo.RevokeAllRefreshTokens(int(session.UserID))
+			return c.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "Invalid refresh token"))
+		}
+
+		user, err := uscon.Repo.Get(int(session.UserID))
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "Invalid refresh token"))
+		}
+
+		if user.Suspended {
+			return c.JSON(http.StatusForbidden, common.ErrorResponse(403, "Account suspended"))
+		}
+
+		refreshToken, err := helper.GenerateToken()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		newSession, err := uscon.Repo.RotateRefreshToken(session, model.RefreshToken{
+			UserID:    user.ID,
+			TokenHash: helper.HashToken(refreshToken),
+			ExpiresAt: time.Now().Add(constant.REFRESH_TOKEN_TTL),
+		})
+		if err == ur.ErrRefreshTokenReused {
+			uscon.Repo.RevokeAllRefreshTokens(int(session.UserID))
+			return c.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "Invalid refresh token"))
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		token, _ := middleware.CreateToken(int(user.ID), user.Email, user.Role, int(newSession.ID))
+
+		data := TokenResponse{
+			AccessToken:  token,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int(constant.ACCESS_TOKEN_TTL.Seconds()),
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (uscon UserController) LogoutController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, _ := middleware.ExtractTokenUser(c)
+
+		if err := uscon.Repo.RevokeRefreshToken(user.UserID, user.SessionID); err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 	}
 }
 
@@ -86,9 +200,11 @@ func (uscon UserController) GetUserController() echo.HandlerFunc {
 		user, _ := uscon.Repo.Get(userJwt.UserID)
 
 		data := UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
+			ID:            user.ID,
+			Name:          user.Name,
+			Email:         user.Email,
+			Role:          user.Role,
+			EmailVerified: user.EmailVerified,
 		}
 		return c.JSON(http.StatusOK, common.SuccessResponse(data))
 	}
@@ -106,6 +222,11 @@ func (uscon UserController) UpdateUserController() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
 		}
 
+		current, err := uscon.Repo.Get(user.UserID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.ErrorResponse(404, "User not found"))
+		}
+
 		updateUser := model.User{}
 		updateUser.Email = updateUserReq.Email
 		updateUser.Name = updateUserReq.Name
@@ -121,10 +242,23 @@ func (uscon UserController) UpdateUserController() echo.HandlerFunc {
 			return c.JSON(http.StatusNotFound, common.ErrorResponse(404, "User not found"))
 		}
 
+		// a new password logs out every session, including this one
+		if updateUserReq.Password != "" {
+			uscon.Repo.RevokeAllRefreshTokens(user.UserID)
+		}
+
+		// booking waits until the new email is verified
+		if userData.Email != current.Email {
+			if err := uscon.sendVerificationEmail(userData); err != nil {
+				c.Logger().Error(err)
+			}
+		}
+
 		data := UserResponse{
-			ID:    uint(user.UserID),
-			Name:  userData.Name,
-			Email: userData.Email,
+			ID:            uint(user.UserID),
+			Name:          userData.Name,
+			Email:         userData.Email,
+			EmailVerified: userData.EmailVerified,
 		}
 
 		return c.JSON(http.StatusOK, common.SuccessResponse(data))
@@ -137,7 +271,178 @@ func (uscon UserController) DeleteUserController() echo.HandlerFunc {
 		userId, _ := middleware.ExtractTokenUser(c)
 
 		uscon.Repo.Delete(userId.UserID)
+		uscon.Repo.RevokeAllRefreshTokens(userId.UserID)
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+func (uscon UserController) UpdateRoleController() echo.HandlerFunc {
+
+	return func(c echo.Context) error {
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		updateRoleReq := UpdateRoleRequestFormat{}
+		c.Bind(&updateRoleReq)
+
+		if err := c.Validate(updateRoleReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		_, err = uscon.Repo.Update(model.User{Role: updateRoleReq.Role}, userId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.ErrorResponse(404, "User not found"))
+		}
+
+		// the role is baked into access tokens, make the user log in again
+		uscon.Repo.RevokeAllRefreshTokens(userId)
+
+		user, _ := uscon.Repo.Get(userId)
+
+		data := UserResponse{
+			ID:    user.ID,
+			Name:  user.Name,
+			Email: user.Email,
+			Role:  user.Role,
+		}
+
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (uscon UserController) VerifyEmailController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.QueryParam("token")
+		if token == "" {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		user, err := uscon.Repo.VerifyEmail(helper.HashToken(token))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(400, "Invalid or expired token"))
+		}
+
+		data := UserResponse{
+			ID:            user.ID,
+			Name:          user.Name,
+			Email:         user.Email,
+			Role:          user.Role,
+			EmailVerified: user.EmailVerified,
+		}
+		return c.JSON(http.StatusOK, common.SuccessResponse(data))
+	}
+}
+
+func (uscon UserController) ResendVerificationController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userJwt, _ := middleware.ExtractTokenUser(c)
+
+		user, err := uscon.Repo.Get(userJwt.UserID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, common.ErrorResponse(404, "User not found"))
+		}
+
+		if user.EmailVerified {
+			return c.JSON(http.StatusNotAcceptable, common.ErrorResponse(406, "Email already verified"))
+		}
+
+		if err := uscon.sendVerificationEmail(user); err != nil {
+			return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+		}
 
 		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 	}
 }
+
+func (uscon UserController) ForgotPasswordController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		forgotReq := ForgotPasswordRequestFormat{}
+		c.Bind(&forgotReq)
+
+		if err := c.Validate(forgotReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		// answer the same whether or not the email is registered so the
+		// endpoint can't be used to look up accounts
+		user, err := uscon.Repo.Login(forgotReq.Email)
+		if err != nil || user.Suspended {
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		}
+
+		token, err := uscon.createUserToken(user, constant.PASSWORD_RESET_TOKEN, constant.PASSWORD_RESET_TTL)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		}
+
+		err = uscon.Mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %v,\n\nWe received a request to reset your password. Open the link below to choose a new one. It expires in %v.\n\n%v/password/reset?token=%v\n\nIf you didn't ask for this you can ignore this email.\n",
+				user.Name, constant.PASSWORD_RESET_TTL, constant.APP_URL, token),
+		})
+		if err != nil {
+			c.Logger().Error(err)
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+func (uscon UserController) ResetPasswordController() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		resetReq := ResetPasswordRequestFormat{}
+		c.Bind(&resetReq)
+
+		if err := c.Validate(resetReq); err != nil {
+			return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		}
+
+		hash, _ := bcrypt.GenerateFromPassword([]byte(resetReq.Password), 14)
+
+		if _, err := uscon.Repo.ResetPassword(helper.HashToken(resetReq.Token), string(hash)); err != nil {
+			return c.JSON(http.StatusBadRequest, common.ErrorResponse(400, "Invalid or expired token"))
+		}
+
+		return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+	}
+}
+
+func (uscon UserController) sendVerificationEmail(user model.User) error {
+	token, err := uscon.createUserToken(user, constant.EMAIL_VERIFICATION_TOKEN, constant.EMAIL_VERIFICATION_TTL)
+	if err != nil {
+		return err
+	}
+
+	return uscon.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %v,\n\nConfirm your email address by opening the link below. It expires in %v.\n\n%v/verify-email?token=%v\n",
+			user.Name, constant.EMAIL_VERIFICATION_TTL, constant.APP_URL, token),
+	})
+}
+
+// createUserToken stores the hash of a new token and returns the plain token
+// for the email.
+func (uscon UserController) createUserToken(user model.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := helper.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = uscon.Repo.CreateUserToken(model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: helper.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
@@ -7,21 +7,29 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	ur "github.com/furqonzt99/airbnb/repository/user"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var jwtToken string
+var outbox = mailer.NewOutboxMailer("")
 
 func TestRegisterUser(t *testing.T) {
 	t.Run("Test Register", func(t *testing.T) {
@@ -42,7 +50,7 @@ func TestRegisterUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/register")
 
-		userController := NewUsersControllers(mockUserRepository{})
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		userController.RegisterController()(context)
 
 		response := RegisterUserResponseFormat{}
@@ -69,7 +77,35 @@ func TestRegisterUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/register")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
+		userController.RegisterController()(context)
+
+		response := RegisterUserResponseFormat{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Bad Request", response.Message)
+	})
+
+	t.Run("Error Test Register As Admin", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"email":    "test@gmail.com",
+			"password": "test1234",
+			"name":     "tester",
+			"role":     "admin",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/register")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		userController.RegisterController()(context)
 
 		response := RegisterUserResponseFormat{}
@@ -96,7 +132,7 @@ func TestRegisterUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/register")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
 		userController.RegisterController()(context)
 
 		response := RegisterUserResponseFormat{}
@@ -123,13 +159,13 @@ func TestLoginUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/login")
 
-		userController := NewUsersControllers(mockUserRepository{})
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		userController.LoginController()(context)
 
 		response := common.ResponseSuccess{}
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
-		jwtToken = response.Data.(string)
+		jwtToken = response.Data.(map[string]interface{})["access_token"].(string)
 		assert.Equal(t, "Successful Operation", response.Message)
 		assert.NotNil(t, jwtToken)
 	})
@@ -150,7 +186,7 @@ func TestLoginUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/login")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
 		userController.LoginController()(context)
 
 		response := common.ResponseSuccess{}
@@ -175,7 +211,7 @@ func TestLoginUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/login")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
 		userController.LoginController()(context)
 
 		response := common.ResponseSuccess{}
@@ -185,6 +221,251 @@ func TestLoginUser(t *testing.T) {
 	})
 }
 
+func TestRefreshToken(t *testing.T) {
+	refresh := func(userRepo ur.UserInterface, token string) (*httptest.ResponseRecorder, common.ResponseSuccess) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"refresh_token": token,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+		context.SetPath("/auth/refresh")
+
+		userController := NewUsersControllers(userRepo, outbox)
+		userController.RefreshTokenController()(context)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		return res, response
+	}
+
+	t.Run("Test Refresh Token", func(t *testing.T) {
+		res, response := refresh(mockUserRepository{}, "valid-token")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		data := response.Data.(map[string]interface{})
+		assert.NotEmpty(t, data["access_token"])
+		assert.NotEqual(t, "valid-token", data["refresh_token"])
+	})
+
+	t.Run("Error Test Refresh Token Missing", func(t *testing.T) {
+		res, _ := refresh(mockUserRepository{}, "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Refresh Token Unknown", func(t *testing.T) {
+		res, _ := refresh(mockUserRepository{}, "unknown-token")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("Error Test Refresh Token Expired", func(t *testing.T) {
+		res, _ := refresh(mockUserRepository{}, "expired-token")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("Error Test Refresh Token Revoked", func(t *testing.T) {
+		res, _ := refresh(mockUserRepository{}, "revoked-token")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("Error Test Refresh Token Reused", func(t *testing.T) {
+		res, _ := refresh(mockFalseUserRepository{}, "valid-token")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	t.Run("Test Logout", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/logout")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.LogoutController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Revoked Session", func(t *testing.T) {
+		mw.SetSessionChecker(mockFalseUserRepository{})
+		defer mw.SetSessionChecker(nil)
+
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/profile")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		mw.JWT()(userController.GetUserController())(context)
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	verify := func(userRepo ur.UserInterface, token string) *httptest.ResponseRecorder {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/verify-email?token="+token, nil)
+		res := httptest.NewRecorder()
+
+		context := e.NewContext(req, res)
+		context.SetPath("/verify-email")
+
+		userController := NewUsersControllers(userRepo, outbox)
+		userController.VerifyEmailController()(context)
+
+		return res
+	}
+
+	t.Run("Test Register Sends Verification Email", func(t *testing.T) {
+		message, ok := outbox.Last("test@gmail.com")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "Verify your email", message.Subject)
+		assert.Equal(t, true, strings.Contains(message.Body, "/verify-email?token="))
+	})
+
+	t.Run("Test Verify Email", func(t *testing.T) {
+		res := verify(mockUserRepository{}, "valid-token")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["email_verified"])
+	})
+
+	t.Run("Error Test Verify Email Missing Token", func(t *testing.T) {
+		res := verify(mockUserRepository{}, "")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Verify Email Invalid Token", func(t *testing.T) {
+		res := verify(mockFalseUserRepository{}, "used-token")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Test Resend Verification Email", func(t *testing.T) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/verify-email/resend")
+
+		sent := len(outbox.Messages())
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.ResendVerificationController())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, sent+1, len(outbox.Messages()))
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	post := func(userRepo ur.UserInterface, handler func(uscon *UserController) echo.HandlerFunc, body map[string]string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+
+		context := e.NewContext(req, res)
+
+		userController := NewUsersControllers(userRepo, outbox)
+		handler(userController)(context)
+
+		return res
+	}
+
+	forgot := func(uscon *UserController) echo.HandlerFunc { return uscon.ForgotPasswordController() }
+	reset := func(uscon *UserController) echo.HandlerFunc { return uscon.ResetPasswordController() }
+
+	t.Run("Test Forgot Password", func(t *testing.T) {
+		res := post(mockUserRepository{}, forgot, map[string]string{"email": "test@gmail.com"})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		message, ok := outbox.Last("test@gmail.com")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "Reset your password", message.Subject)
+		assert.Equal(t, true, strings.Contains(message.Body, "/password/reset?token="))
+	})
+
+	t.Run("Test Forgot Password Unknown Email", func(t *testing.T) {
+		sent := len(outbox.Messages())
+
+		res := post(mockFalseUserRepository{}, forgot, map[string]string{"email": "unknown@gmail.com"})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, sent, len(outbox.Messages()))
+	})
+
+	t.Run("Error Test Forgot Password Invalid Email", func(t *testing.T) {
+		res := post(mockUserRepository{}, forgot, map[string]string{"email": "test"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Test Reset Password", func(t *testing.T) {
+		res := post(mockUserRepository{}, reset, map[string]string{"token": "valid-token", "password": "newpass1234"})
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Test Reset Password Short", func(t *testing.T) {
+		res := post(mockUserRepository{}, reset, map[string]string{"token": "valid-token", "password": "new"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Reset Password Invalid Token", func(t *testing.T) {
+		res := post(mockFalseUserRepository{}, reset, map[string]string{"token": "used-token", "password": "newpass1234"})
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
 func TestGetUser(t *testing.T) {
 	t.Run("Test Get User", func(t *testing.T) {
 		e := echo.New()
@@ -197,7 +478,7 @@ func TestGetUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/profile")
 
-		userController := NewUsersControllers(mockUserRepository{})
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.GetUserController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -230,7 +511,7 @@ func TestUpdateUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/users")
 
-		userController := NewUsersControllers(mockUserRepository{})
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.UpdateUserController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -242,6 +523,60 @@ func TestUpdateUser(t *testing.T) {
 		assert.Equal(t, "Successful Operation", response.Message)
 	})
 
+	update := func(email string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": "test4321",
+			"name":     "tester2",
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwtToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/users")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.UpdateUserController())(context); err != nil {
+			log.Fatal(err)
+		}
+
+		return res
+	}
+
+	t.Run("Test Update Email Needs Verification", func(t *testing.T) {
+		res := update("changed@gmail.com")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, false, response.Data.(map[string]interface{})["email_verified"])
+
+		message, ok := outbox.Last("changed@gmail.com")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "Verify your email", message.Subject)
+	})
+
+	t.Run("Test Update Same Email Sends No Verification", func(t *testing.T) {
+		sent := len(outbox.Messages())
+
+		res := update("test@gmail.com")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, true, response.Data.(map[string]interface{})["email_verified"])
+		assert.Equal(t, sent, len(outbox.Messages()))
+	})
+
 	t.Run("Error Test Update Password Length Below 8", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = &UserValidator{Validator: validator.New()}
@@ -261,7 +596,7 @@ func TestUpdateUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/users")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.UpdateUserController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -292,7 +627,7 @@ func TestUpdateUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/users")
 
-		userController := NewUsersControllers(mockFalseUserRepository{})
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.UpdateUserController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -318,7 +653,7 @@ func TestDeleteUser(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/users")
 
-		userController := NewUsersControllers(mockUserRepository{})
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
 		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(userController.DeleteUserController())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -331,6 +666,127 @@ func TestDeleteUser(t *testing.T) {
 	})
 }
 
+func TestUpdateRole(t *testing.T) {
+	adminToken, _ := mw.CreateToken(1, "admin@gmail.com", constant.ADMIN_ROLE, 1)
+
+	t.Run("Test Update Role", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"role": "host",
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", adminToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/admin/users/:id/role")
+		context.SetParamNames("id")
+		context.SetParamValues("2")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(mw.RequireRole(constant.ADMIN_ROLE)(userController.UpdateRoleController()))(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, "Successful Operation", response.Message)
+	})
+
+	t.Run("Error Test Update Role Invalid Role", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"role": "superuser",
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", adminToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/admin/users/:id/role")
+		context.SetParamNames("id")
+		context.SetParamValues("2")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(mw.RequireRole(constant.ADMIN_ROLE)(userController.UpdateRoleController()))(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Error Test Update Role Forbidden For Guest", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		guestToken, _ := mw.CreateToken(5, "guest@gmail.com", constant.GUEST_ROLE, 1)
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"role": "admin",
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", guestToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/admin/users/:id/role")
+		context.SetParamNames("id")
+		context.SetParamValues("5")
+
+		userController := NewUsersControllers(mockUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(mw.RequireRole(constant.ADMIN_ROLE)(userController.UpdateRoleController()))(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Error Test Update Role User Not Found", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &UserValidator{Validator: validator.New()}
+
+		requestBody, _ := json.Marshal(map[string]string{
+			"role": "host",
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer(requestBody))
+		res := httptest.NewRecorder()
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", adminToken))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/admin/users/:id/role")
+		context.SetParamNames("id")
+		context.SetParamValues("100")
+
+		userController := NewUsersControllers(mockFalseUserRepository{}, outbox)
+		if err := middleware.JWT([]byte(constant.JWT_SECRET_KEY))(mw.RequireRole(constant.ADMIN_ROLE)(userController.UpdateRoleController()))(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
 type mockUserRepository struct{}
 
 func (m mockUserRepository) Register(newUser model.User) (model.User, error) {
@@ -350,7 +806,7 @@ func (m mockUserRepository) Get(userid int) (model.User, error) {
 
 func (m mockUserRepository) Update(newUser model.User, userId int) (model.User, error) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
-	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
+	return model.User{Email: newUser.Email, Password: string(hash), Name: "tester2", EmailVerified: newUser.Email == "test@gmail.com"}, nil
 }
 
 func (m mockUserRepository) Delete(userId int) (model.User, error) {
@@ -358,6 +814,61 @@ func (m mockUserRepository) Delete(userId int) (model.User, error) {
 	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, nil
 }
 
+func (m mockUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return []model.User{{Email: "test@gmail.com", Name: "tester", Role: "guest"}}, pagination.Result{}, nil
+}
+
+func (m mockUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", Role: "guest", Suspended: suspended}, nil
+}
+
+func (m mockUserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	refreshToken.ID = 1
+	return refreshToken, nil
+}
+
+func (m mockUserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	switch tokenHash {
+	case helper.HashToken("valid-token"):
+		return model.RefreshToken{Model: gorm.Model{ID: 1}, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+	case helper.HashToken("expired-token"):
+		return model.RefreshToken{Model: gorm.Model{ID: 2}, UserID: 1, ExpiresAt: time.Now().Add(-time.Hour)}, nil
+	case helper.HashToken("revoked-token"):
+		return model.RefreshToken{Model: gorm.Model{ID: 3}, UserID: 1, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: time.Now()}, nil
+	}
+	return model.RefreshToken{}, errors.New("record not found")
+}
+
+func (m mockUserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	next.ID = prev.ID + 1
+	return next, nil
+}
+
+func (m mockUserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return nil
+}
+
+func (m mockUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return nil
+}
+
+func (m mockUserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	return true
+}
+
+func (m mockUserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	userToken.ID = 1
+	return userToken, nil
+}
+
+func (m mockUserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
+func (m mockUserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	return model.User{Email: "test@gmail.com", Name: "tester", EmailVerified: true}, nil
+}
+
 type mockFalseUserRepository struct{}
 
 func (m mockFalseUserRepository) Register(newUser model.User) (model.User, error) {
@@ -384,3 +895,47 @@ func (m mockFalseUserRepository) Delete(userId int) (model.User, error) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("test4321"), 14)
 	return model.User{Email: "test2@gmail.com", Password: string(hash), Name: "tester2"}, errors.New("False Login Object")
 }
+
+func (m mockFalseUserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	return nil, pagination.Result{}, errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	return model.User{}, errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	return model.RefreshToken{}, errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	return model.RefreshToken{Model: gorm.Model{ID: 1}, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (m mockFalseUserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	return model.RefreshToken{}, ur.ErrRefreshTokenReused
+}
+
+func (m mockFalseUserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) RevokeAllRefreshTokens(userId int) error {
+	return errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	return false
+}
+
+func (m mockFalseUserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	return model.UserToken{}, errors.New("False Login Object")
+}
+
+func (m mockFalseUserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	return model.User{}, ur.ErrUserTokenInvalid
+}
+
+func (m mockFalseUserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	return model.User{}, ur.ErrUserTokenInvalid
+}
@@ -0,0 +1,28 @@
+package wishlist
+
+import (
+	"net/http"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type WishlistRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type AddHouseRequest struct {
+	HouseID int `json:"house_id" validate:"required"`
+}
+
+type WishlistValidator struct {
+	Validator *validator.Validate
+}
+
+func (wv *WishlistValidator) Validate(i interface{}) error {
+	if err := wv.Validator.Struct(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+	return nil
+}
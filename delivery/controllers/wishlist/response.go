@@ -0,0 +1,11 @@
+package wishlist
+
+import "time"
+
+type WishlistResponse struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	HouseCount int64     `json:"house_count"`
+	ShareURL   *string   `json:"share_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
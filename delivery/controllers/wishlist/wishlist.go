@@ -0,0 +1,319 @@
+package wishlist
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/delivery/controllers/house"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	wr "github.com/furqonzt99/airbnb/repository/wishlist"
+	"github.com/labstack/echo/v4"
+)
+
+// WishlistController manages the wishlists of the caller. Houses lists the
+// saved houses with the same projection as the house search.
+type WishlistController struct {
+	Repository wr.WishlistInterface
+	Houses     *house.HouseController
+}
+
+func NewWishlistController(repo wr.WishlistInterface, houses *house.HouseController) *WishlistController {
+	return &WishlistController{Repository: repo, Houses: houses}
+}
+
+func (wc WishlistController) GetAll(c echo.Context) error {
+	user, _ := mw.ExtractTokenUser(c)
+
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	wishlists, result, err := wc.Repository.GetAll(user.UserID, page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	wishlistIds := []uint{}
+	for _, wishlist := range wishlists {
+		wishlistIds = append(wishlistIds, wishlist.ID)
+	}
+
+	houseCounts, err := wc.Repository.CountHouses(wishlistIds)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	data := []WishlistResponse{}
+	for _, wishlist := range wishlists {
+		data = append(data, newWishlistResponse(wishlist, houseCounts[wishlist.ID]))
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, data))
+}
+
+func (wc WishlistController) Create(c echo.Context) error {
+	var wishlistRequest WishlistRequest
+
+	if err := c.Bind(&wishlistRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&wishlistRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Create(model.Wishlist{UserID: uint(user.UserID), Name: wishlistRequest.Name})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(newWishlistResponse(wishlist, 0)))
+}
+
+func (wc WishlistController) Get(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+func (wc WishlistController) Update(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	var wishlistRequest WishlistRequest
+
+	if err := c.Bind(&wishlistRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&wishlistRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Rename(user.UserID, wishlistId, wishlistRequest.Name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+func (wc WishlistController) Delete(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	if _, err := wc.Repository.Delete(user.UserID, wishlistId); err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (wc WishlistController) GetHouses(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.housesResponse(c, wishlist)
+}
+
+func (wc WishlistController) AddHouse(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	var addHouseRequest AddHouseRequest
+
+	if err := c.Bind(&addHouseRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := c.Validate(&addHouseRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if _, err := wc.Repository.GetHouse(addHouseRequest.HouseID); err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	if err := wc.Repository.AddHouse(int(wishlist.ID), addHouseRequest.HouseID); err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+func (wc WishlistController) RemoveHouse(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	houseId, err := strconv.Atoi(c.Param("houseId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	if err := wc.Repository.RemoveHouse(int(wishlist.ID), houseId); err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+// Share issues a new share link for the wishlist. Links handed out before
+// stop working.
+func (wc WishlistController) Share(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	shareToken, err := helper.GenerateToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	wishlist, err = wc.Repository.SetShareToken(user.UserID, wishlistId, &shareToken)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+func (wc WishlistController) Unshare(c echo.Context) error {
+	wishlistId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+	}
+
+	user, _ := mw.ExtractTokenUser(c)
+
+	wishlist, err := wc.Repository.Get(user.UserID, wishlistId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	wishlist, err = wc.Repository.SetShareToken(user.UserID, wishlistId, nil)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+// GetShared is the read-only view of a wishlist behind its share link.
+func (wc WishlistController) GetShared(c echo.Context) error {
+	wishlist, err := wc.Repository.GetByShareToken(c.Param("token"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.wishlistResponse(c, wishlist)
+}
+
+func (wc WishlistController) GetSharedHouses(c echo.Context) error {
+	wishlist, err := wc.Repository.GetByShareToken(c.Param("token"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+	}
+
+	return wc.housesResponse(c, wishlist)
+}
+
+func (wc WishlistController) wishlistResponse(c echo.Context, wishlist model.Wishlist) error {
+	houseCounts, err := wc.Repository.CountHouses([]uint{wishlist.ID})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponse(newWishlistResponse(wishlist, houseCounts[wishlist.ID])))
+}
+
+func (wc WishlistController) housesResponse(c echo.Context, wishlist model.Wishlist) error {
+	page, err := pagination.NewPage(c.QueryParam("limit"), c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, common.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	houses, result, err := wc.Repository.GetHouses(int(wishlist.ID), page)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, common.NewInternalServerErrorResponse())
+	}
+
+	return c.JSON(http.StatusOK, common.CursorPaginationResponse(result, wc.Houses.NewHouseResponses(c, houses)))
+}
+
+func newWishlistResponse(wishlist model.Wishlist, houseCount int64) WishlistResponse {
+	response := WishlistResponse{
+		ID:         wishlist.ID,
+		Name:       wishlist.Name,
+		HouseCount: houseCount,
+		CreatedAt:  wishlist.CreatedAt,
+	}
+
+	if wishlist.ShareToken != nil {
+		shareURL := fmt.Sprintf("%v/shared/wishlists/%v", constant.APP_URL, *wishlist.ShareToken)
+		response.ShareURL = &shareURL
+	}
+
+	return response
+}
@@ -0,0 +1,296 @@
+package wishlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/delivery/controllers/house"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	hr "github.com/furqonzt99/airbnb/repository/house"
+	wr "github.com/furqonzt99/airbnb/repository/wishlist"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var userToken, _ = mw.CreateToken(1, "guest@gmail.com", constant.GUEST_ROLE, 1)
+var otherToken, _ = mw.CreateToken(2, "other@gmail.com", constant.GUEST_ROLE, 1)
+
+func serve(repo wr.WishlistInterface, handler func(WishlistController, echo.Context) error, token, body string, params ...string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = &WishlistValidator{Validator: validator.New()}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	res := httptest.NewRecorder()
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+	}
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("id", "houseId", "token")
+	context.SetParamValues(append(params, "", "", "")[:3]...)
+
+	wishlistController := NewWishlistController(repo, house.NewHouseControllers(mockHouseRepository{}, nil))
+	handlerFunc := func(c echo.Context) error {
+		return handler(*wishlistController, c)
+	}
+
+	mw.OptionalJWT()(handlerFunc)(context)
+
+	return res
+}
+
+func TestWishlist(t *testing.T) {
+	t.Run("Get Wishlists", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.GetAll, userToken, "")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		wishlists := response.Data.([]interface{})
+		assert.Equal(t, 1, len(wishlists))
+		assert.Equal(t, "Bali", wishlists[0].(map[string]interface{})["name"])
+		assert.Equal(t, float64(2), wishlists[0].(map[string]interface{})["house_count"])
+		assert.Nil(t, wishlists[0].(map[string]interface{})["share_url"])
+	})
+
+	t.Run("Create Wishlist", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Create, userToken, `{"name": "Lombok"}`)
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "Lombok", response.Data.(map[string]interface{})["name"])
+	})
+
+	t.Run("Error Create Wishlist Without Name", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Create, userToken, `{"name": ""}`)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Rename Wishlist", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Update, userToken, `{"name": "Bali 2025"}`, "1")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "Bali 2025", response.Data.(map[string]interface{})["name"])
+	})
+
+	t.Run("Error Get Wishlist Of Someone Else", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Get, otherToken, "", "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Get Wishlist Invalid Id", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Get, userToken, "", "abc")
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Delete Wishlist", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Delete, userToken, "", "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+}
+
+func TestWishlistHouses(t *testing.T) {
+	t.Run("Get Wishlist Houses", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.GetHouses, userToken, "", "1")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		houses := response.Data.([]interface{})
+		assert.Equal(t, 2, len(houses))
+		assert.Equal(t, "Rumah Bagus", houses[0].(map[string]interface{})["title"])
+		assert.Equal(t, true, houses[0].(map[string]interface{})["is_saved"])
+		assert.NotNil(t, houses[0].(map[string]interface{})["rating_summary"])
+	})
+
+	t.Run("Add House", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.AddHouse, userToken, `{"house_id": 3}`, "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Add Missing House", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.AddHouse, userToken, `{"house_id": 9}`, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Error Add House To Wishlist Of Someone Else", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.AddHouse, otherToken, `{"house_id": 3}`, "1")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Remove House", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.RemoveHouse, userToken, "", "1", "1")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Error Remove House Not In Wishlist", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.RemoveHouse, userToken, "", "1", "3")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestShare(t *testing.T) {
+	t.Run("Share Wishlist", func(t *testing.T) {
+		constant.APP_URL = "http://localhost:8000"
+
+		res := serve(mockWishlistRepository{}, WishlistController.Share, userToken, "", "1")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Regexp(t, `^http://localhost:8000/shared/wishlists/[0-9a-f]{64}$`, response.Data.(map[string]interface{})["share_url"])
+	})
+
+	t.Run("Unshare Wishlist", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.Unshare, userToken, "", "1")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Nil(t, response.Data.(map[string]interface{})["share_url"])
+	})
+
+	t.Run("Get Shared Wishlist Anonymously", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.GetShared, "", "", "", "", "shared-token")
+
+		response := common.ResponseSuccess{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "Bali", response.Data.(map[string]interface{})["name"])
+	})
+
+	t.Run("Get Shared Wishlist Houses Anonymously", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.GetSharedHouses, "", "", "", "", "shared-token")
+
+		response := common.ResponseCursorPagination{}
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		houses := response.Data.([]interface{})
+		assert.Equal(t, 2, len(houses))
+		assert.Equal(t, false, houses[0].(map[string]interface{})["is_saved"])
+	})
+
+	t.Run("Error Get Shared Wishlist Unknown Token", func(t *testing.T) {
+		res := serve(mockWishlistRepository{}, WishlistController.GetShared, "", "", "", "", "unknown")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+type mockHouseRepository struct {
+	hr.HouseInterface
+}
+
+func (m mockHouseRepository) GetSavedHouseIDs(userId int, houseIds []uint) (map[uint]bool, error) {
+	return map[uint]bool{1: true, 2: true}, nil
+}
+
+type mockWishlistRepository struct{}
+
+func (m mockWishlistRepository) Create(wishlist model.Wishlist) (model.Wishlist, error) {
+	wishlist.ID = 2
+	return wishlist, nil
+}
+
+func (m mockWishlistRepository) GetAll(userId int, page pagination.Page) ([]model.Wishlist, pagination.Result, error) {
+	return []model.Wishlist{{Model: gorm.Model{ID: 1}, UserID: uint(userId), Name: "Bali"}}, pagination.Result{Total: 1}, nil
+}
+
+func (m mockWishlistRepository) CountHouses(wishlistIds []uint) (map[uint]int64, error) {
+	return map[uint]int64{1: 2}, nil
+}
+
+func (m mockWishlistRepository) Get(userId, wishlistId int) (model.Wishlist, error) {
+	if userId != 1 || wishlistId != 1 {
+		return model.Wishlist{}, gorm.ErrRecordNotFound
+	}
+
+	return model.Wishlist{Model: gorm.Model{ID: 1}, UserID: 1, Name: "Bali"}, nil
+}
+
+func (m mockWishlistRepository) GetByShareToken(shareToken string) (model.Wishlist, error) {
+	if shareToken != "shared-token" {
+		return model.Wishlist{}, gorm.ErrRecordNotFound
+	}
+
+	return model.Wishlist{Model: gorm.Model{ID: 1}, UserID: 1, Name: "Bali", ShareToken: &shareToken}, nil
+}
+
+func (m mockWishlistRepository) Rename(userId, wishlistId int, name string) (model.Wishlist, error) {
+	wishlist, err := m.Get(userId, wishlistId)
+	wishlist.Name = name
+	return wishlist, err
+}
+
+func (m mockWishlistRepository) SetShareToken(userId, wishlistId int, shareToken *string) (model.Wishlist, error) {
+	wishlist, err := m.Get(userId, wishlistId)
+	wishlist.ShareToken = shareToken
+	return wishlist, err
+}
+
+func (m mockWishlistRepository) Delete(userId, wishlistId int) (model.Wishlist, error) {
+	return m.Get(userId, wishlistId)
+}
+
+func (m mockWishlistRepository) GetHouse(houseId int) (model.House, error) {
+	if houseId != 3 {
+		return model.House{}, gorm.ErrRecordNotFound
+	}
+
+	return model.House{Model: gorm.Model{ID: 3}, Title: "Rumah Baru"}, nil
+}
+
+func (m mockWishlistRepository) AddHouse(wishlistId, houseId int) error {
+	return nil
+}
+
+func (m mockWishlistRepository) RemoveHouse(wishlistId, houseId int) error {
+	if houseId != 1 {
+		return errors.New("house is not in the wishlist")
+	}
+
+	return nil
+}
+
+func (m mockWishlistRepository) GetHouses(wishlistId int, page pagination.Page) ([]model.House, pagination.Result, error) {
+	return []model.House{
+		{Model: gorm.Model{ID: 1}, UserID: 2, Title: "Rumah Bagus", City: "Bali", Price: 100000, Features: []model.Feature{{Name: "wifi"}}},
+		{Model: gorm.Model{ID: 2}, UserID: 2, Title: "Rumah Indah", City: "Bali", Price: 150000},
+	}, pagination.Result{Total: 2}, nil
+}
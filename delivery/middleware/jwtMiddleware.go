@@ -2,33 +2,119 @@ package middleware
 
 import (
 	"errors"
+	"net/http"
 	"time"
 
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/common"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
-func CreateToken(userId int, email string) (string, error) {
+// SessionChecker reports whether the login session an access token was
+// issued for has not been revoked.
+type SessionChecker interface {
+	IsSessionActive(userId, sessionId int) bool
+}
+
+var sessionChecker SessionChecker
+
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+// IsSessionActive reports whether the session of a signed in user was not
+// revoked since the request was let through, for connections that stay open
+// like the notification stream.
+func IsSessionActive(user common.JWTPayload) bool {
+	return sessionChecker == nil || sessionChecker.IsSessionActive(user.UserID, user.SessionID)
+}
+
+func CreateToken(userId int, email, role string, sessionId int) (string, error) {
 	claims := jwt.MapClaims{}
 	claims["authorized"] = true
 	claims["userId"] = int(userId)
 	claims["email"] = email
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+	claims["role"] = role
+	claims["sid"] = sessionId
+	claims["exp"] = time.Now().Add(constant.ACCESS_TOKEN_TTL).Unix()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(constant.JWT_SECRET_KEY))
 }
 
+// JWT validates the access token like echo's JWT middleware and then rejects
+// it when its session was revoked by logout, password change or account
+// deletion.
+func JWT() echo.MiddlewareFunc {
+	return withSession(middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+}
+
+// StreamJWT is JWT that also takes the token from the access_token query
+// param, since browsers can't set headers on an EventSource.
+func StreamJWT() echo.MiddlewareFunc {
+	return withSession(middleware.JWTWithConfig(middleware.JWTConfig{
+		SigningKey:  []byte(constant.JWT_SECRET_KEY),
+		TokenLookup: "header:" + echo.HeaderAuthorization + ",query:access_token",
+	}))
+}
+
+// OptionalJWT is JWT for public routes that show more to signed in users.
+// Requests without a token go through anonymously, but a token that is sent
+// still has to be valid.
+func OptionalJWT() echo.MiddlewareFunc {
+	jwtMiddleware := JWT()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withToken := jwtMiddleware(next)
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+				return next(c)
+			}
+
+			return withToken(c)
+		}
+	}
+}
+
+func withSession(jwtMiddleware echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtMiddleware(func(c echo.Context) error {
+			if sessionChecker == nil {
+				return next(c)
+			}
+
+			user, err := ExtractTokenUser(c)
+			if err != nil || !IsSessionActive(user) {
+				return c.JSON(http.StatusUnauthorized, common.ErrorResponse(http.StatusUnauthorized, "Token has been revoked"))
+			}
+
+			return next(c)
+		})
+	}
+}
+
 func ExtractTokenUser(e echo.Context) (common.JWTPayload, error) {
-	user := e.Get("user").(*jwt.Token)
-	if user.Valid {
+	user, ok := e.Get("user").(*jwt.Token)
+	if ok && user.Valid {
 		claims := user.Claims.(jwt.MapClaims)
 		userId := claims["userId"].(float64)
 		email := claims["email"]
+
+		// tokens issued before roles existed are treated as guests
+		role, ok := claims["role"].(string)
+		if !ok || role == "" {
+			role = constant.GUEST_ROLE
+		}
+
+		sessionId, _ := claims["sid"].(float64)
+
 		return common.JWTPayload{
-			UserID: int(userId),
-			Email:  email.(string),
+			UserID:    int(userId),
+			Email:     email.(string),
+			Role:      role,
+			SessionID: int(sessionId),
 		}, nil
 	}
 	return common.JWTPayload{}, errors.New("invalid token")
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole only lets the request through when the role in the JWT is one
+// of roles. It must be registered after the JWT middleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, err := ExtractTokenUser(c)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, common.ErrorResponse(http.StatusUnauthorized, "Unauthorized"))
+			}
+
+			for _, role := range roles {
+				if user.Role == role {
+					return next(c)
+				}
+			}
+
+			return c.JSON(http.StatusForbidden, common.ErrorResponse(http.StatusForbidden, "Forbidden"))
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/controllers/admin"
+	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAdminPath(e *echo.Echo, userCtrl *user.UserController, adminCtrl *admin.AdminController) {
+
+	admin := e.Group("/admin", mw.JWT(), mw.RequireRole(constant.ADMIN_ROLE))
+
+	admin.GET("/users", adminCtrl.GetUsers)
+	admin.PUT("/users/:id/role", userCtrl.UpdateRoleController())
+	admin.PUT("/users/:id/suspend", adminCtrl.SuspendUser)
+	admin.PUT("/users/:id/restore", adminCtrl.RestoreUser)
+	admin.GET("/houses", adminCtrl.GetHouses)
+	admin.PUT("/houses/:id/suspend", adminCtrl.SuspendHouse)
+	admin.PUT("/houses/:id/restore", adminCtrl.RestoreHouse)
+	admin.PUT("/transactions/:id/status", adminCtrl.ForceTransactionStatus)
+	admin.GET("/webhook-events", adminCtrl.GetWebhookEvents)
+	admin.POST("/webhook-events/:id/replay", adminCtrl.ReplayWebhookEvent)
+	admin.GET("/ratings", adminCtrl.GetRatings)
+	admin.DELETE("/ratings/:transactionId", adminCtrl.DeleteRating)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/furqonzt99/airbnb/delivery/controllers/conversation"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterConversationPath(e *echo.Echo, conversationCtrl *conversation.ConversationController) {
+
+	e.GET("/conversations", conversationCtrl.GetAll, mw.JWT())
+	e.POST("/conversations", conversationCtrl.Start, mw.JWT())
+	e.GET("/conversations/unread", conversationCtrl.GetUnread, mw.JWT())
+	e.GET("/conversations/:id/messages", conversationCtrl.GetMessages, mw.JWT())
+	e.POST("/conversations/:id/messages", conversationCtrl.SendMessage, mw.JWT())
+}
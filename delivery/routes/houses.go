@@ -1,18 +1,34 @@
 package routes
 
 import (
+	"fmt"
+
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/controllers/house"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 func RegisterHousePath(e *echo.Echo, houseCtrl *house.HouseController) {
 
-	e.POST("/houses", houseCtrl.CreateHouseController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.GET("/houses", houseCtrl.GetAllHouseController())
-	e.GET("/myhouses", houseCtrl.GetMyHouseController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.GET("/houses/:id", houseCtrl.GetHouseController())
-	e.PUT("/houses/:id", houseCtrl.UpdateHouseController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.DELETE("/houses/:id", houseCtrl.DeleteHouseController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+	e.POST("/houses", houseCtrl.CreateHouseController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.GET("/houses", houseCtrl.GetAllHouseController(), mw.OptionalJWT())
+	e.GET("/myhouses", houseCtrl.GetMyHouseController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.GET("/houses/:id", houseCtrl.GetHouseController(), mw.OptionalJWT())
+	e.PUT("/houses/:id", houseCtrl.UpdateHouseController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.DELETE("/houses/:id", houseCtrl.DeleteHouseController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.GET("/houses/:id/availability", houseCtrl.GetAvailabilityController())
+	e.POST("/houses/:id/blocked-dates", houseCtrl.BlockDateController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.GET("/houses/:id/quote", houseCtrl.GetQuoteController())
+	e.GET("/houses/:id/pricing-rules", houseCtrl.GetPricingRulesController())
+	e.POST("/houses/:id/pricing-rules", houseCtrl.CreatePricingRuleController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.DELETE("/houses/:id/pricing-rules/:ruleId", houseCtrl.DeletePricingRuleController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	// a full batch of photos plus room for the multipart framing
+	photosLimit := constant.PHOTO_MAX_SIZE*int64(constant.PHOTO_MAX_FILES) + 1<<20
+	e.POST("/houses/:id/photos", houseCtrl.UploadPhotoController(), middleware.BodyLimit(fmt.Sprintf("%vB", photosLimit)), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.PUT("/houses/:id/photos/order", houseCtrl.ReorderPhotosController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.PUT("/houses/:id/photos/:photoId/cover", houseCtrl.SetCoverPhotoController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.DELETE("/houses/:id/photos/:photoId", houseCtrl.DeletePhotoController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.DELETE("/houses/:id/blocked-dates/:blockId", houseCtrl.UnblockDateController(), mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
 }
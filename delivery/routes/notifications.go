@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/furqonzt99/airbnb/delivery/controllers/notification"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterNotificationPath(e *echo.Echo, notificationCtrl *notification.NotificationController) {
+
+	e.GET("/notifications", notificationCtrl.GetAll, mw.JWT())
+	e.GET("/notifications/unread", notificationCtrl.GetUnread, mw.JWT())
+	e.GET("/notifications/stream", notificationCtrl.Stream, mw.StreamJWT())
+	e.PUT("/notifications/read", notificationCtrl.MarkAllRead, mw.JWT())
+	e.PUT("/notifications/:id/read", notificationCtrl.MarkRead, mw.JWT())
+}
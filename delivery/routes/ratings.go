@@ -1,15 +1,20 @@
 package routes
 
 import (
-	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/controllers/rating"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 )
 
 func RegisterRatingPath(e *echo.Echo, RatingController *rating.RatingController) {
 
-	e.POST("/ratings", RatingController.Create, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.PUT("/ratings/:houseId", RatingController.Update, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.DELETE("/ratings/:houseId", RatingController.Delete, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+	e.POST("/ratings", RatingController.Create, mw.JWT())
+	e.PUT("/ratings/:transactionId", RatingController.Update, mw.JWT())
+	e.DELETE("/ratings/:transactionId", RatingController.Delete, mw.JWT())
+	e.PUT("/ratings/:transactionId/reply", RatingController.Reply, mw.JWT())
+	e.DELETE("/ratings/:transactionId/reply", RatingController.DeleteReply, mw.JWT())
+	e.POST("/ratings/:transactionId/helpful", RatingController.MarkHelpful, mw.JWT())
+	e.DELETE("/ratings/:transactionId/helpful", RatingController.UnmarkHelpful, mw.JWT())
+	e.POST("/guest-ratings", RatingController.RateGuest, mw.JWT())
+	e.GET("/guest-ratings/:guestId", RatingController.GetGuestRatings, mw.JWT())
 }
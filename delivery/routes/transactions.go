@@ -3,16 +3,21 @@ package routes
 import (
 	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/controllers/transaction"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 )
 
 func RegisterTransactionPath(e *echo.Echo, TransactionController *transaction.TransactionController) {
 
-	e.POST("/transactions/booking", TransactionController.Booking, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.PUT("/transactions/reschedule/:id", TransactionController.Reschedule, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+	e.POST("/transactions/quote", TransactionController.Quote)
+	e.POST("/transactions/booking", TransactionController.Booking, mw.JWT())
+	e.PUT("/transactions/reschedule/:id", TransactionController.Reschedule, mw.JWT())
+	e.POST("/transactions/:id/cancel", TransactionController.Cancel, mw.JWT())
+	e.POST("/transactions/:id/approve", TransactionController.Approve, mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
+	e.POST("/transactions/:id/decline", TransactionController.Decline, mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
 	e.POST("/transactions/callback", TransactionController.Callback)
-	e.GET("/transactions", TransactionController.GetAll, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.GET("/transactions/:id", TransactionController.GetByTransaction, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.GET("/transactions/host", TransactionController.GetAllHostTransaction, middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+	e.GET("/transactions", TransactionController.GetAll, mw.JWT())
+	e.GET("/transactions/:id", TransactionController.GetByTransaction, mw.JWT())
+	e.GET("/transactions/:id/history", TransactionController.GetHistory, mw.JWT())
+	e.GET("/transactions/host", TransactionController.GetAllHostTransaction, mw.JWT(), mw.RequireRole(constant.HOST_ROLE, constant.ADMIN_ROLE))
 }
@@ -1,17 +1,22 @@
 package routes
 
 import (
-	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 )
 
 func RegisterUserPath(e *echo.Echo, userCtrl *user.UserController) {
 
 	e.POST("/register", userCtrl.RegisterController())
 	e.POST("/login", userCtrl.LoginController())
-	e.GET("/profile", userCtrl.GetUserController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.PUT("/users", userCtrl.UpdateUserController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
-	e.DELETE("/users", userCtrl.DeleteUserController(), middleware.JWT([]byte(constant.JWT_SECRET_KEY)))
+	e.POST("/auth/refresh", userCtrl.RefreshTokenController())
+	e.POST("/logout", userCtrl.LogoutController(), mw.JWT())
+	e.POST("/password/forgot", userCtrl.ForgotPasswordController())
+	e.POST("/password/reset", userCtrl.ResetPasswordController())
+	e.GET("/verify-email", userCtrl.VerifyEmailController())
+	e.POST("/verify-email/resend", userCtrl.ResendVerificationController(), mw.JWT())
+	e.GET("/profile", userCtrl.GetUserController(), mw.JWT())
+	e.PUT("/users", userCtrl.UpdateUserController(), mw.JWT())
+	e.DELETE("/users", userCtrl.DeleteUserController(), mw.JWT())
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/furqonzt99/airbnb/delivery/controllers/wishlist"
+	mw "github.com/furqonzt99/airbnb/delivery/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterWishlistPath(e *echo.Echo, wishlistCtrl *wishlist.WishlistController) {
+
+	e.GET("/wishlists", wishlistCtrl.GetAll, mw.JWT())
+	e.POST("/wishlists", wishlistCtrl.Create, mw.JWT())
+	e.GET("/wishlists/:id", wishlistCtrl.Get, mw.JWT())
+	e.PUT("/wishlists/:id", wishlistCtrl.Update, mw.JWT())
+	e.DELETE("/wishlists/:id", wishlistCtrl.Delete, mw.JWT())
+	e.GET("/wishlists/:id/houses", wishlistCtrl.GetHouses, mw.JWT())
+	e.POST("/wishlists/:id/houses", wishlistCtrl.AddHouse, mw.JWT())
+	e.DELETE("/wishlists/:id/houses/:houseId", wishlistCtrl.RemoveHouse, mw.JWT())
+	e.POST("/wishlists/:id/share", wishlistCtrl.Share, mw.JWT())
+	e.DELETE("/wishlists/:id/share", wishlistCtrl.Unshare, mw.JWT())
+	e.GET("/shared/wishlists/:token", wishlistCtrl.GetShared)
+	e.GET("/shared/wishlists/:token/houses", wishlistCtrl.GetSharedHouses, mw.OptionalJWT())
+}
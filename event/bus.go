@@ -0,0 +1,115 @@
+package event
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/labstack/gommon/log"
+)
+
+// SUBSCRIBER_BUFFER is how many events a stream may fall behind before it
+// starts missing live events. Missed events are still in the inbox.
+const SUBSCRIBER_BUFFER = 16
+
+// Store is the part of the notification repository the bus needs.
+type Store interface {
+	Create(notification model.Notification) (model.Notification, error)
+}
+
+// Bus is an in-process event bus. Every published event is saved to the inbox
+// of its user first and then handed to the streams that user has open, so a
+// user without an open stream finds it in the inbox later.
+type Bus struct {
+	Store Store
+
+	mu          sync.Mutex
+	subscribers map[int]map[chan Event]bool
+	closed      bool
+}
+
+func NewBus(store Store) *Bus {
+	return &Bus{
+		Store:       store,
+		subscribers: map[int]map[chan Event]bool{},
+	}
+}
+
+func (b *Bus) Publish(event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if b.Store != nil {
+		data, _ := json.Marshal(event.Data)
+
+		notification, err := b.Store.Create(model.Notification{
+			UserID:  uint(event.UserID),
+			Type:    event.Type,
+			Message: event.Message,
+			Data:    string(data),
+		})
+		if err != nil {
+			log.Errorf("saving %v notification for user %v: %v", event.Type, event.UserID, err)
+		} else {
+			event.ID = notification.ID
+			event.CreatedAt = notification.CreatedAt
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber := range b.subscribers[event.UserID] {
+		// never block the request publishing on a slow stream
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe opens a stream of the events published to userId. The returned
+// func ends the subscription; the channel is closed by it or by Close.
+func (b *Bus) Subscribe(userId int) (<-chan Event, func()) {
+	subscriber := make(chan Event, SUBSCRIBER_BUFFER)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+
+	if b.subscribers[userId] == nil {
+		b.subscribers[userId] = map[chan Event]bool{}
+	}
+	b.subscribers[userId][subscriber] = true
+
+	return subscriber, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.subscribers[userId][subscriber] {
+			delete(b.subscribers[userId], subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// Close ends every open stream, letting the server shut down without waiting
+// for clients to disconnect.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for userId, subscribers := range b.subscribers {
+		for subscriber := range subscribers {
+			close(subscriber)
+		}
+		delete(b.subscribers, userId)
+	}
+}
@@ -0,0 +1,119 @@
+package event
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockStore struct {
+	saved *[]model.Notification
+	err   error
+}
+
+func (m mockStore) Create(notification model.Notification) (model.Notification, error) {
+	if m.err != nil {
+		return notification, m.err
+	}
+
+	*m.saved = append(*m.saved, notification)
+	notification.Model = gorm.Model{ID: uint(len(*m.saved)), CreatedAt: time.Now()}
+	return notification, nil
+}
+
+func TestBus(t *testing.T) {
+	t.Run("Publish To Subscriber", func(t *testing.T) {
+		saved := []model.Notification{}
+		bus := NewBus(mockStore{saved: &saved})
+
+		events, unsubscribe := bus.Subscribe(2)
+		defer unsubscribe()
+
+		bus.Publish(Event{Type: BOOKING_CREATED, UserID: 2, Message: "New booking", Data: map[string]interface{}{"transaction_id": 1}})
+
+		event := <-events
+		assert.Equal(t, uint(1), event.ID)
+		assert.Equal(t, BOOKING_CREATED, event.Type)
+		assert.Equal(t, 1, len(saved))
+		assert.Equal(t, `{"transaction_id":1}`, saved[0].Data)
+	})
+
+	t.Run("Publish Only To Recipient", func(t *testing.T) {
+		bus := NewBus(nil)
+
+		events, unsubscribe := bus.Subscribe(3)
+		defer unsubscribe()
+
+		bus.Publish(Event{Type: BOOKING_CREATED, UserID: 2})
+
+		select {
+		case <-events:
+			t.Error("event delivered to another user")
+		default:
+		}
+	})
+
+	t.Run("Publish Without Subscriber", func(t *testing.T) {
+		saved := []model.Notification{}
+		bus := NewBus(mockStore{saved: &saved})
+
+		bus.Publish(Event{Type: RATING_CREATED, UserID: 2})
+
+		assert.Equal(t, 1, len(saved))
+	})
+
+	t.Run("Publish When Store Fails", func(t *testing.T) {
+		bus := NewBus(mockStore{err: errors.New("Error")})
+
+		events, unsubscribe := bus.Subscribe(2)
+		defer unsubscribe()
+
+		bus.Publish(Event{Type: RATING_CREATED, UserID: 2})
+
+		event := <-events
+		assert.Equal(t, uint(0), event.ID)
+	})
+
+	t.Run("Slow Subscriber Does Not Block", func(t *testing.T) {
+		bus := NewBus(nil)
+
+		events, unsubscribe := bus.Subscribe(2)
+		defer unsubscribe()
+
+		for i := 0; i < SUBSCRIBER_BUFFER+5; i++ {
+			bus.Publish(Event{Type: MESSAGE_RECEIVED, UserID: 2})
+		}
+
+		assert.Equal(t, SUBSCRIBER_BUFFER, len(events))
+	})
+
+	t.Run("Unsubscribe Closes Stream", func(t *testing.T) {
+		bus := NewBus(nil)
+
+		events, unsubscribe := bus.Subscribe(2)
+		unsubscribe()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("Close Ends Every Stream", func(t *testing.T) {
+		bus := NewBus(nil)
+
+		events, unsubscribe := bus.Subscribe(2)
+		bus.Close()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+
+		events, _ = bus.Subscribe(2)
+		_, ok = <-events
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,32 @@
+package event
+
+import "time"
+
+const BOOKING_CREATED = "booking.created"
+const BOOKING_REQUESTED = "booking.requested"
+const BOOKING_APPROVED = "booking.approved"
+const BOOKING_DECLINED = "booking.declined"
+const BOOKING_PAID = "booking.paid"
+const BOOKING_EXPIRED = "booking.expired"
+const BOOKING_RESCHEDULED = "booking.rescheduled"
+const BOOKING_CANCELLED = "booking.cancelled"
+const RATING_CREATED = "rating.created"
+const RATING_REPLIED = "rating.replied"
+const GUEST_RATED = "guest.rated"
+const MESSAGE_RECEIVED = "message.received"
+
+// Event is something UserID should hear about. Data carries the ids a client
+// needs to load the details. ID is the inbox notification once stored.
+type Event struct {
+	ID        uint
+	Type      string
+	UserID    int
+	Message   string
+	Data      map[string]interface{}
+	CreatedAt time.Time
+}
+
+// Publisher is what controllers publish events to.
+type Publisher interface {
+	Publish(event Event)
+}
@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+const AVAILABLE_DAY = "available"
+const BOOKED_DAY = "booked"
+const BLOCKED_DAY = "blocked"
+const MIN_STAY_VIOLATION_DAY = "min_stay_violation"
+
+type CalendarDay struct {
+	Date   time.Time
+	Status string
+}
+
+// BuildCalendar returns one entry per day from `from` to `to` inclusive.
+// A free day is reported as a min stay violation when it sits in a gap
+// between bookings or blocks that is shorter than minStay nights, so a guest
+// could never book it. bookings and blocks should cover minStay days on each
+// side of the range for the gap check to be accurate at the edges.
+func BuildCalendar(from, to time.Time, minStay int, bookings []model.Transaction, blocks []model.HouseBlockedDate) []CalendarDay {
+	start := from.AddDate(0, 0, -minStay)
+	end := to.AddDate(0, 0, minStay)
+
+	days := []CalendarDay{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		days = append(days, CalendarDay{Date: date, Status: dayStatus(date, bookings, blocks)})
+	}
+
+	// mark free runs that are closed on both sides and too short to book
+	for i := 0; i < len(days); {
+		if days[i].Status != AVAILABLE_DAY {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(days) && days[j].Status == AVAILABLE_DAY {
+			j++
+		}
+
+		if i > 0 && j < len(days) && j-i < minStay {
+			for k := i; k < j; k++ {
+				days[k].Status = MIN_STAY_VIOLATION_DAY
+			}
+		}
+
+		i = j
+	}
+
+	calendar := []CalendarDay{}
+	for _, day := range days {
+		if !day.Date.Before(from) && !day.Date.After(to) {
+			calendar = append(calendar, day)
+		}
+	}
+
+	return calendar
+}
+
+func dayStatus(date time.Time, bookings []model.Transaction, blocks []model.HouseBlockedDate) string {
+	for _, booking := range bookings {
+		if !date.Before(booking.CheckinDate) && date.Before(booking.CheckoutDate) {
+			return BOOKED_DAY
+		}
+	}
+
+	for _, block := range blocks {
+		if !date.Before(block.StartDate) && !date.After(block.EndDate) {
+			return BLOCKED_DAY
+		}
+	}
+
+	return AVAILABLE_DAY
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+const NIGHT_LINE = "night"
+const DISCOUNT_LINE = "discount"
+const CLEANING_FEE_LINE = "cleaning_fee"
+const SERVICE_FEE_LINE = "service_fee"
+const TAX_LINE = "tax"
+
+// Fees are the platform wide charges added on top of the nightly price.
+type Fees struct {
+	ServicePercentage float64
+	TaxPercentage     float64
+}
+
+type Charges struct {
+	Quote       Quote
+	CleaningFee float64
+	ServiceFee  float64
+	Tax         float64
+	Total       float64
+	LineItems   []model.TransactionLineItem
+}
+
+// CalculateCharges turns a nightly quote into the full price of a stay. The
+// service fee is taken from the discounted nightly total and the tax from
+// everything before it. Nights with the same price are grouped into one line.
+func CalculateCharges(title string, quote Quote, cleaningFee float64, fees Fees) Charges {
+	charges := Charges{
+		Quote:       quote,
+		CleaningFee: cleaningFee,
+		LineItems:   []model.TransactionLineItem{},
+	}
+
+	index := map[string]int{}
+	for _, night := range quote.Nights {
+		name := title
+		if night.Seasonal {
+			name += " (seasonal)"
+		}
+		if night.Weekend {
+			name += " (weekend)"
+		}
+
+		key := fmt.Sprint(name, night.Price)
+		if i, ok := index[key]; ok {
+			charges.LineItems[i].Quantity++
+			charges.LineItems[i].Amount += night.Price
+			continue
+		}
+
+		index[key] = len(charges.LineItems)
+		charges.LineItems = append(charges.LineItems, model.TransactionLineItem{
+			Type:      NIGHT_LINE,
+			Name:      name,
+			UnitPrice: night.Price,
+			Quantity:  1,
+			Amount:    night.Price,
+		})
+	}
+
+	if quote.Discount > 0 {
+		charges.LineItems = append(charges.LineItems, model.TransactionLineItem{
+			Type:      DISCOUNT_LINE,
+			Name:      fmt.Sprintf("Length of stay discount %v%%", quote.DiscountPercentage),
+			UnitPrice: -quote.Discount,
+			Quantity:  1,
+			Amount:    -quote.Discount,
+		})
+	}
+
+	if cleaningFee > 0 {
+		charges.LineItems = append(charges.LineItems, model.TransactionLineItem{
+			Type:      CLEANING_FEE_LINE,
+			Name:      "Cleaning fee",
+			UnitPrice: cleaningFee,
+			Quantity:  1,
+			Amount:    cleaningFee,
+		})
+	}
+
+	charges.ServiceFee = math.Round(quote.Total * fees.ServicePercentage / 100)
+	if charges.ServiceFee > 0 {
+		charges.LineItems = append(charges.LineItems, model.TransactionLineItem{
+			Type:      SERVICE_FEE_LINE,
+			Name:      fmt.Sprintf("Service fee %v%%", fees.ServicePercentage),
+			UnitPrice: charges.ServiceFee,
+			Quantity:  1,
+			Amount:    charges.ServiceFee,
+		})
+	}
+
+	charges.Tax = math.Round((quote.Total + cleaningFee + charges.ServiceFee) * fees.TaxPercentage / 100)
+	if charges.Tax > 0 {
+		charges.LineItems = append(charges.LineItems, model.TransactionLineItem{
+			Type:      TAX_LINE,
+			Name:      fmt.Sprintf("Tax %v%%", fees.TaxPercentage),
+			UnitPrice: charges.Tax,
+			Quantity:  1,
+			Amount:    charges.Tax,
+		})
+	}
+
+	charges.Total = quote.Total + cleaningFee + charges.ServiceFee + charges.Tax
+
+	return charges
+}
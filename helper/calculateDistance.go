@@ -0,0 +1,54 @@
+package helper
+
+import "math"
+
+const EARTH_RADIUS_KM = 6371.0
+const DEFAULT_SEARCH_RADIUS_KM = 25.0
+const MAX_SEARCH_RADIUS_KM = 500.0
+
+func IsValidCoordinate(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// CalculateDistance returns the great circle distance in km between two
+// points using the haversine formula.
+func CalculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return EARTH_RADIUS_KM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// BoundingBox returns the smallest latitude/longitude box holding the circle
+// around a point, used to narrow a radius search before the exact distance
+// check. minLng is greater than maxLng when the box crosses the antimeridian,
+// and a circle that reaches a pole covers every longitude.
+func BoundingBox(latitude, longitude, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	angle := radiusKm / EARTH_RADIUS_KM * 180 / math.Pi
+
+	minLat = latitude - angle
+	maxLat = latitude + angle
+	if minLat <= -90 || maxLat >= 90 {
+		return math.Max(minLat, -90), math.Min(maxLat, 90), -180, 180
+	}
+
+	lngAngle := math.Asin(math.Sin(toRadians(angle))/math.Cos(toRadians(latitude))) * 180 / math.Pi
+
+	minLng = longitude - lngAngle
+	maxLng = longitude + lngAngle
+	if minLng < -180 {
+		minLng += 360
+	}
+	if maxLng > 180 {
+		maxLng -= 360
+	}
+
+	return minLat, maxLat, minLng, maxLng
+}
+
+func toRadians(degree float64) float64 {
+	return degree * math.Pi / 180
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+const WEEKEND_RULE = "weekend"
+const SEASONAL_RULE = "seasonal"
+const LENGTH_OF_STAY_RULE = "length_of_stay"
+
+func IsValidPricingRule(ruleType string) bool {
+	return ruleType == WEEKEND_RULE || ruleType == SEASONAL_RULE || ruleType == LENGTH_OF_STAY_RULE
+}
+
+type NightPrice struct {
+	Date     time.Time
+	Price    float64
+	Seasonal bool
+	Weekend  bool
+}
+
+type Quote struct {
+	Nights             []NightPrice
+	Subtotal           float64
+	Discount           float64
+	DiscountPercentage float64
+	Total              float64
+}
+
+// CalculateQuote prices every night between checkin and checkout. A seasonal
+// rule replaces the base price, a weekend rule is then added on top for
+// friday and saturday nights, and the longest matching length of stay rule
+// is taken off the subtotal.
+func CalculateQuote(basePrice float64, checkinDate, checkoutDate time.Time, rules []model.HousePricingRule) Quote {
+	quote := Quote{Nights: []NightPrice{}}
+
+	for date := checkinDate; date.Before(checkoutDate); date = date.AddDate(0, 0, 1) {
+		night := NightPrice{Date: date, Price: basePrice}
+
+		for _, rule := range rules {
+			if rule.Type == SEASONAL_RULE && !date.Before(rule.StartDate) && !date.After(rule.EndDate) {
+				night.Price = rule.Price
+				night.Seasonal = true
+			}
+		}
+
+		if date.Weekday() == time.Friday || date.Weekday() == time.Saturday {
+			for _, rule := range rules {
+				if rule.Type == WEEKEND_RULE {
+					night.Price += night.Price * rule.Percentage / 100
+					night.Weekend = true
+					break
+				}
+			}
+		}
+
+		quote.Nights = append(quote.Nights, night)
+		quote.Subtotal += night.Price
+	}
+
+	minNights := 0
+	for _, rule := range rules {
+		if rule.Type == LENGTH_OF_STAY_RULE && len(quote.Nights) >= rule.MinNights && rule.MinNights > minNights {
+			minNights = rule.MinNights
+			quote.DiscountPercentage = rule.Percentage
+		}
+	}
+
+	quote.Discount = quote.Subtotal * quote.DiscountPercentage / 100
+	quote.Total = quote.Subtotal - quote.Discount
+
+	return quote
+}
@@ -1,16 +1,56 @@
 package helper
 
-func CalculateRatings(ratings []int) (rating int) {
+import (
+	"math"
 
-	if len(ratings) < 1 {
+	"github.com/furqonzt99/airbnb/model"
+)
+
+// CalculateRatings averages the scores to two decimals. Zero scores are sub-
+// scores the guest left out and don't count.
+func CalculateRatings(ratings []int) float64 {
+	total, count := 0, 0
+
+	for _, r := range ratings {
+		if r > 0 {
+			total += r
+			count++
+		}
+	}
+
+	if count < 1 {
 		return 0
 	}
-	
+
+	return math.Round(float64(total)/float64(count)*100) / 100
+}
+
+func SummarizeRatings(ratings []model.Rating) model.RatingSummary {
+	var overall, cleanliness, accuracy, location, communication, value []int
+	distribution := map[int]int{}
+
 	for _, r := range ratings {
-		rating += r
+		overall = append(overall, r.Rating)
+		cleanliness = append(cleanliness, r.Cleanliness)
+		accuracy = append(accuracy, r.Accuracy)
+		location = append(location, r.Location)
+		communication = append(communication, r.Communication)
+		value = append(value, r.Value)
+		distribution[r.Rating]++
 	}
 
-	rating = rating / len(ratings)
-
-	return
-}
\ No newline at end of file
+	return model.RatingSummary{
+		Count:         len(ratings),
+		Average:       CalculateRatings(overall),
+		Cleanliness:   CalculateRatings(cleanliness),
+		Accuracy:      CalculateRatings(accuracy),
+		Location:      CalculateRatings(location),
+		Communication: CalculateRatings(communication),
+		Value:         CalculateRatings(value),
+		OneStar:       distribution[1],
+		TwoStar:       distribution[2],
+		ThreeStar:     distribution[3],
+		FourStar:      distribution[4],
+		FiveStar:      distribution[5],
+	}
+}
@@ -0,0 +1,43 @@
+package helper
+
+import "time"
+
+const FLEXIBLE_POLICY = "flexible"
+const MODERATE_POLICY = "moderate"
+const STRICT_POLICY = "strict"
+
+func IsValidCancellationPolicy(policy string) bool {
+	return policy == FLEXIBLE_POLICY || policy == MODERATE_POLICY || policy == STRICT_POLICY
+}
+
+// CalculateRefund returns the amount given back to the guest when a paid
+// booking is cancelled at cancelDate, based on the days left before checkin.
+//
+//	flexible: full refund up to 1 day before checkin
+//	moderate: full refund up to 5 days before checkin, 50% after that
+//	strict:   50% refund up to 7 days before checkin, nothing after that
+func CalculateRefund(policy string, totalPrice float64, checkinDate, cancelDate time.Time) float64 {
+	if !cancelDate.Before(checkinDate) {
+		return 0
+	}
+
+	daysLeft := CountNight(cancelDate, checkinDate)
+
+	switch policy {
+	case MODERATE_POLICY:
+		if daysLeft >= 5 {
+			return totalPrice
+		}
+		return totalPrice / 2
+	case STRICT_POLICY:
+		if daysLeft >= 7 {
+			return totalPrice / 2
+		}
+		return 0
+	default:
+		if daysLeft >= 1 {
+			return totalPrice
+		}
+		return 0
+	}
+}
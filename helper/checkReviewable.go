@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+var ErrStayNotCompleted = errors.New("stay is not completed yet")
+var ErrReviewWindowClosed = errors.New("review window has closed")
+
+// CheckReviewable reports whether the stay can be reviewed at now. Only paid
+// stays can be reviewed, from checkout until the window after it has passed.
+func CheckReviewable(transaction model.Transaction, now time.Time, window time.Duration) error {
+	switch transaction.Status {
+	case model.PAID_STATUS, model.CHECKED_IN_STATUS, model.COMPLETED_STATUS:
+	default:
+		return ErrStayNotCompleted
+	}
+
+	if now.Before(transaction.CheckoutDate) {
+		return ErrStayNotCompleted
+	}
+
+	if now.After(transaction.CheckoutDate.Add(window)) {
+		return ErrReviewWindowClosed
+	}
+
+	return nil
+}
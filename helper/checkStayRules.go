@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/furqonzt99/airbnb/model"
+)
+
+var ErrNoAdults = errors.New("a booking needs at least one adult")
+var ErrOverCapacity = errors.New("too many guests for this house")
+var ErrPetsNotAllowed = errors.New("pets are not allowed in this house")
+var ErrStayTooShort = errors.New("stay is shorter than the minimum stay of this house")
+var ErrStayTooLong = errors.New("stay is longer than the maximum stay of this house")
+
+// Guests is the party of a booking. Infants don't count toward the capacity
+// of a house.
+type Guests struct {
+	Adults   int
+	Children int
+	Infants  int
+	Pets     int
+}
+
+// CheckStayRules reports whether guests can stay the nights at the house. A
+// capacity or max stay of 0 leaves that rule out.
+func CheckStayRules(house model.House, guests Guests, nights int) error {
+	if guests.Adults < 1 {
+		return ErrNoAdults
+	}
+
+	if house.MaxGuests > 0 && guests.Adults+guests.Children > house.MaxGuests {
+		return ErrOverCapacity
+	}
+
+	if guests.Pets > 0 && !house.PetsAllowed {
+		return ErrPetsNotAllowed
+	}
+
+	if nights < house.MinStay {
+		return ErrStayTooShort
+	}
+
+	if house.MaxStay > 0 && nights > house.MaxStay {
+		return ErrStayTooLong
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+const THUMBNAIL_WIDTH = 320
+
+// PHOTO_MAX_PIXELS keeps decoding within memory, a small file can declare a
+// huge image.
+const PHOTO_MAX_PIXELS = 40000000
+
+var ErrImageTooLarge = errors.New("image has too many pixels")
+
+// PHOTO_CONTENT_TYPES are the uploads we can decode for thumbnails, mapped to
+// the extension the stored file gets.
+var PHOTO_CONTENT_TYPES = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// GenerateThumbnail decodes a jpeg or png and returns it as a jpeg scaled down
+// to width, keeping the aspect ratio. Images already narrower keep their size.
+// Each thumbnail pixel is the average of the source pixels it covers. Images
+// over PHOTO_MAX_PIXELS return ErrImageTooLarge before they are decoded.
+func GenerateThumbnail(src io.Reader, width int) ([]byte, error) {
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > PHOTO_MAX_PIXELS {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	if srcWidth < width {
+		width = srcWidth
+	}
+	height := srcHeight * width / srcWidth
+	if height == 0 {
+		height = 1
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			thumbnail.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,10 @@
+package helper
+
+const PRICE_SORT = "price"
+const RATING_SORT = "rating"
+const NEWEST_SORT = "newest"
+const DISTANCE_SORT = "distance"
+
+func IsValidHouseSort(sort string) bool {
+	return sort == PRICE_SORT || sort == RATING_SORT || sort == NEWEST_SORT || sort == DISTANCE_SORT
+}
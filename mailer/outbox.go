@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OutboxMailer keeps every message in memory instead of sending it, for local
+// development and tests. When Dir is set each message is also written there
+// as a text file so links in it can be opened by hand.
+type OutboxMailer struct {
+	Dir string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutboxMailer(dir string) *OutboxMailer {
+	return &OutboxMailer{Dir: dir}
+}
+
+func (om *OutboxMailer) Send(message Message) error {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	if om.Dir != "" {
+		if err := os.MkdirAll(om.Dir, 0755); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%v-%03d.txt", time.Now().Format("20060102150405"), len(om.messages)+1)
+		content := fmt.Sprintf("To: %v\nSubject: %v\n\n%v\n", message.To, message.Subject, message.Body)
+		if err := os.WriteFile(filepath.Join(om.Dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	om.messages = append(om.messages, message)
+
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (om *OutboxMailer) Messages() []Message {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	return append([]Message{}, om.messages...)
+}
+
+// Last returns the most recent message sent to the address.
+func (om *OutboxMailer) Last(to string) (Message, bool) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+
+	for i := len(om.messages) - 1; i >= 0; i-- {
+		if om.messages[i].To == to {
+			return om.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
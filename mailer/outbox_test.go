@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxMailer(t *testing.T) {
+	t.Run("Send To Memory", func(t *testing.T) {
+		outbox := NewOutboxMailer("")
+
+		err := outbox.Send(Message{To: "test@gmail.com", Subject: "First", Body: "1"})
+		assert.Nil(t, err)
+		err = outbox.Send(Message{To: "other@gmail.com", Subject: "Second", Body: "2"})
+		assert.Nil(t, err)
+		err = outbox.Send(Message{To: "test@gmail.com", Subject: "Third", Body: "3"})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3, len(outbox.Messages()))
+
+		message, ok := outbox.Last("test@gmail.com")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "Third", message.Subject)
+	})
+
+	t.Run("Error Last No Message", func(t *testing.T) {
+		outbox := NewOutboxMailer("")
+
+		_, ok := outbox.Last("test@gmail.com")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("Send To Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		outbox := NewOutboxMailer(dir)
+
+		err := outbox.Send(Message{To: "test@gmail.com", Subject: "Hello", Body: "World"})
+		assert.Nil(t, err)
+
+		files, _ := os.ReadDir(dir)
+		assert.Equal(t, 1, len(files))
+
+		content, _ := os.ReadFile(dir + "/" + files[0].Name())
+		assert.Equal(t, true, strings.Contains(string(content), "Subject: Hello"))
+	})
+}
@@ -1,20 +1,42 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/delivery/controllers/admin"
+	"github.com/furqonzt99/airbnb/delivery/controllers/conversation"
 	"github.com/furqonzt99/airbnb/delivery/controllers/feature"
 	"github.com/furqonzt99/airbnb/delivery/controllers/house"
+	"github.com/furqonzt99/airbnb/delivery/controllers/notification"
 	"github.com/furqonzt99/airbnb/delivery/controllers/rating"
 	"github.com/furqonzt99/airbnb/delivery/controllers/transaction"
 	"github.com/furqonzt99/airbnb/delivery/controllers/user"
+	"github.com/furqonzt99/airbnb/delivery/controllers/wishlist"
 	mw "github.com/furqonzt99/airbnb/delivery/middleware"
 	"github.com/furqonzt99/airbnb/delivery/routes"
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/mailer"
+	"github.com/furqonzt99/airbnb/payment"
+	cr "github.com/furqonzt99/airbnb/repository/conversation"
 	fr "github.com/furqonzt99/airbnb/repository/feature"
 	hr "github.com/furqonzt99/airbnb/repository/house"
+	nr "github.com/furqonzt99/airbnb/repository/notification"
 	rr "github.com/furqonzt99/airbnb/repository/rating"
 	tr "github.com/furqonzt99/airbnb/repository/transaction"
 	ur "github.com/furqonzt99/airbnb/repository/user"
+	whr "github.com/furqonzt99/airbnb/repository/webhook"
+	wr "github.com/furqonzt99/airbnb/repository/wishlist"
+	"github.com/furqonzt99/airbnb/storage"
 	"github.com/furqonzt99/airbnb/util"
+	"github.com/furqonzt99/airbnb/worker"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -32,28 +54,87 @@ func main() {
 	featureRepo := fr.NewFeatureRepo(db)
 	transactionRepo := tr.NewTransactionRepository(db)
 	ratingRepo := rr.NewRatingRepository(db)
+	conversationRepo := cr.NewConversationRepository(db)
+	notificationRepo := nr.NewNotificationRepository(db)
+	wishlistRepo := wr.NewWishlistRepository(db)
+	webhookRepo := whr.NewWebhookRepository(db)
+
+	bus := event.NewBus(notificationRepo)
+
+	var paymentProvider payment.PaymentProvider
+	if config.Payment.Provider == "fake" {
+		paymentProvider = payment.NewFakeProvider(constant.XENDIT_CALLBACK_TOKEN)
+	} else {
+		paymentProvider = payment.NewXenditProvider(config.Payment.XenditSecretKey, constant.XENDIT_CALLBACK_TOKEN)
+	}
+
+	var mail mailer.Mailer
+	if config.Mail.Provider == "smtp" {
+		mail = mailer.NewSMTPMailer(config.Mail.Host, config.Mail.Port, config.Mail.Username, config.Mail.Password, config.Mail.From)
+	} else {
+		mail = mailer.NewOutboxMailer(config.Mail.OutboxDir)
+	}
+
+	userCtrl := user.NewUsersControllers(userRepo, mail)
+	photoStorage := storage.NewLocalStorage(config.Storage.Dir, constant.APP_URL+"/uploads")
 
-	userCtrl := user.NewUsersControllers(userRepo)
-	houseCtrl := house.NewHouseControllers(houseRepo)
+	houseCtrl := house.NewHouseControllers(houseRepo, photoStorage)
 	featureCtrl := feature.NewFeatureControllers(featureRepo)
-	transactionCtrl := transaction.NewTransactionController(transactionRepo)
-	ratingCtrl := rating.NewRatingController(ratingRepo)
+	transactionCtrl := transaction.NewTransactionController(transactionRepo, webhookRepo, paymentProvider, helper.Fees{
+		ServicePercentage: config.Fee.ServicePercentage,
+		TaxPercentage:     config.Fee.TaxPercentage,
+	}, bus)
+	ratingCtrl := rating.NewRatingController(ratingRepo, bus)
+	adminCtrl := admin.NewAdminController(userRepo, houseRepo, transactionRepo, ratingRepo, webhookRepo, transactionCtrl)
+	conversationCtrl := conversation.NewConversationController(conversationRepo, bus)
+	notificationCtrl := notification.NewNotificationController(notificationRepo, bus)
+	wishlistCtrl := wishlist.NewWishlistController(wishlistRepo, houseCtrl)
 
 	e := echo.New()
 	mw.LogMiddleware(e)
+	mw.SetSessionChecker(userRepo)
 
 	e.Pre(middleware.RemoveTrailingSlash())
 
+	e.Static("/uploads", config.Storage.Dir)
+
 	e.Validator = &user.UserValidator{Validator: validator.New()}
 	e.Validator = &house.HouseValidator{Validator: validator.New()}
 	e.Validator = &transaction.TransactionValidator{Validator: validator.New()}
+	e.Validator = &admin.AdminValidator{Validator: validator.New()}
 	e.Validator = &rating.RatingValidator{Validator: validator.New()}
+	e.Validator = &conversation.ConversationValidator{Validator: validator.New()}
+	e.Validator = &wishlist.WishlistValidator{Validator: validator.New()}
 
 	routes.RegisterUserPath(e, userCtrl)
 	routes.RegisterHousePath(e, houseCtrl)
 	routes.RegisterFeaturePath(e, featureCtrl)
 	routes.RegisterTransactionPath(e, transactionCtrl)
 	routes.RegisterRatingPath(e, ratingCtrl)
+	routes.RegisterAdminPath(e, userCtrl, adminCtrl)
+	routes.RegisterConversationPath(e, conversationCtrl)
+	routes.RegisterNotificationPath(e, notificationCtrl)
+	routes.RegisterWishlistPath(e, wishlistCtrl)
+
+	expirySweeper := worker.NewExpirySweeper(transactionRepo, paymentProvider, bus, config.Payment.Window, config.Payment.SweepInterval)
+	expirySweeper.Start()
+
+	go func() {
+		if err := e.Start(":" + config.Port); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	expirySweeper.Stop()
+	bus.Close()
 
-	e.Logger.Fatal(e.Start(":" + config.Port))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		e.Logger.Fatal(err)
+	}
 }
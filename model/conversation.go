@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Conversation is the message thread between a guest and the host of a house.
+// There is one thread per guest, host and house; TransactionID points at the
+// booking the thread was last started from, if any.
+type Conversation struct {
+	gorm.Model
+	GuestID       uint      `gorm:"NOT NULL;uniqueIndex:idx_conversation_participants"`
+	HostID        uint      `gorm:"NOT NULL;uniqueIndex:idx_conversation_participants;index"`
+	HouseID       uint      `gorm:"NOT NULL;uniqueIndex:idx_conversation_participants"`
+	TransactionID *uint     `gorm:"default:null"`
+	LastMessageAt time.Time `gorm:"default:null"`
+	Guest         User      `gorm:"foreignKey:GuestID"`
+	Host          User      `gorm:"foreignKey:HostID"`
+	House         House
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// GuestRating is the review the host of the stay TransactionID leaves for the
+// guest, so other hosts can see how the guest treated a home.
+type GuestRating struct {
+	TransactionID uint `gorm:"primaryKey;autoIncrement:false"`
+	HostID        uint `gorm:"NOT NULL;index"`
+	GuestID       uint `gorm:"NOT NULL;index"`
+	Rating        int  `gorm:"NOT NULL"`
+	Comment       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Host          User `gorm:"foreignKey:HostID"`
+}
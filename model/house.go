@@ -6,15 +6,41 @@ import (
 
 type House struct {
 	gorm.Model
-	UserID   uint    `gorm:"NOT NULL"`
-	Title    string  `gorm:"NOT NULL"`
-	Address  string  `gorm:"NOT NULL"`
-	City     string  `gorm:"NOT NULL"`
-	Price    float64 `gorm:"NOT NULL"`
-	Status   string  `gorm:"NOT NULL;default:open"`
-	User     User
-	Features []Feature `gorm:"many2many:house_has_features;"`
-	Ratings  []Rating
+	UserID             uint          `gorm:"NOT NULL"`
+	Title              string        `gorm:"NOT NULL"`
+	Address            string        `gorm:"NOT NULL"`
+	City               string        `gorm:"NOT NULL"`
+	Price              float64       `gorm:"NOT NULL"`
+	Status             string        `gorm:"NOT NULL;default:open"`
+	CancellationPolicy string        `gorm:"NOT NULL;default:flexible"`
+	MinStay            int           `gorm:"NOT NULL;default:1"`
+	MaxStay            int           `gorm:"NOT NULL;default:0"`
+	MaxGuests          int           `gorm:"NOT NULL;default:1"`
+	Bedrooms           int           `gorm:"NOT NULL;default:0"`
+	Bathrooms          float64       `gorm:"NOT NULL;default:0"`
+	CheckinTime        string        `gorm:"NOT NULL;size:5;default:15:00"`
+	CheckoutTime       string        `gorm:"NOT NULL;size:5;default:11:00"`
+	PetsAllowed        bool          `gorm:"NOT NULL;default:false"`
+	SmokingAllowed     bool          `gorm:"NOT NULL;default:false"`
+	EventsAllowed      bool          `gorm:"NOT NULL;default:false"`
+	AdditionalRules    string        `gorm:"type:text"`
+	CleaningFee        float64       `gorm:"NOT NULL;default:0"`
+	InstantBook        *bool         `gorm:"NOT NULL;default:true"`
+	Suspended          bool          `gorm:"NOT NULL;default:false"`
+	Latitude           *float64      `gorm:"default:null;index:idx_house_location"`
+	Longitude          *float64      `gorm:"default:null;index:idx_house_location"`
+	RatingSummary      RatingSummary `gorm:"embedded;embeddedPrefix:rating_"`
+	User               User
+	Features           []Feature `gorm:"many2many:house_has_features;"`
+	Ratings            []Rating
+	PricingRules       []HousePricingRule
+	Photos             []HousePhoto
+}
+
+// IsInstantBook reports whether bookings are confirmed without the host
+// approving them first. Houses are instant book unless turned off.
+func (h House) IsInstantBook() bool {
+	return h.InstantBook == nil || *h.InstantBook
 }
 
 type HouseHasFeatures struct {
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HouseBlockedDate is a date range the host took off the calendar, e.g. for
+// maintenance. Both StartDate and EndDate nights are blocked.
+type HouseBlockedDate struct {
+	gorm.Model
+	HouseID   uint      `gorm:"NOT NULL;index"`
+	StartDate time.Time `gorm:"NOT NULL"`
+	EndDate   time.Time `gorm:"NOT NULL"`
+	Reason    string
+}
@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+// HousePhoto is an uploaded listing photo. Key and ThumbnailKey point into the
+// configured storage; Position orders the gallery and one photo is the cover.
+type HousePhoto struct {
+	gorm.Model
+	HouseID      uint   `gorm:"NOT NULL;index"`
+	Key          string `gorm:"NOT NULL"`
+	ThumbnailKey string `gorm:"NOT NULL"`
+	ContentType  string `gorm:"NOT NULL"`
+	Size         int64  `gorm:"NOT NULL"`
+	Position     int    `gorm:"NOT NULL;default:0"`
+	IsCover      bool   `gorm:"NOT NULL;default:false"`
+}
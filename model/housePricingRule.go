@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HousePricingRule adjusts the flat House.Price for some nights of a stay.
+//
+//	weekend:        Percentage surcharge on friday and saturday nights
+//	seasonal:       Price replaces the base price between StartDate and EndDate
+//	length_of_stay: Percentage discount when the stay is at least MinNights long
+type HousePricingRule struct {
+	gorm.Model
+	HouseID    uint   `gorm:"NOT NULL;index"`
+	Type       string `gorm:"NOT NULL"`
+	Price      float64
+	Percentage float64
+	MinNights  int
+	StartDate  time.Time `gorm:"default:null"`
+	EndDate    time.Time `gorm:"default:null"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Message is one message of a conversation. ReadAt is set once the other
+// participant has opened the thread.
+type Message struct {
+	gorm.Model
+	ConversationID uint      `gorm:"NOT NULL;index"`
+	SenderID       uint      `gorm:"NOT NULL"`
+	Body           string    `gorm:"type:text;NOT NULL"`
+	ReadAt         time.Time `gorm:"default:null"`
+	Sender         User      `gorm:"foreignKey:SenderID"`
+}
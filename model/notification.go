@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification is an event kept in the inbox of UserID. Data is the JSON the
+// event carried, mostly ids of the booking, house or conversation it is about.
+type Notification struct {
+	gorm.Model
+	UserID  uint      `gorm:"NOT NULL;index"`
+	Type    string    `gorm:"NOT NULL"`
+	Message string    `gorm:"NOT NULL"`
+	Data    string    `gorm:"type:text"`
+	ReadAt  time.Time `gorm:"default:null"`
+}
@@ -1,9 +1,22 @@
 package model
 
+import "time"
+
 type Rating struct {
-	HouseID uint `gorm:"primaryKey"`
-	UserID uint `gorm:"primaryKey"`
+	TransactionID uint `gorm:"primaryKey;autoIncrement:false"`
+	HouseID uint `gorm:"NOT NULL;index"`
+	UserID uint `gorm:"NOT NULL;index"`
 	Rating int
+	Cleanliness int `gorm:"NOT NULL;default:0"`
+	Accuracy int `gorm:"NOT NULL;default:0"`
+	Location int `gorm:"NOT NULL;default:0"`
+	Communication int `gorm:"NOT NULL;default:0"`
+	Value int `gorm:"NOT NULL;default:0"`
 	Comment string
+	Reply string
+	RepliedAt time.Time `gorm:"default:null"`
+	HelpfulCount int `gorm:"NOT NULL;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
 	User User
-}
\ No newline at end of file
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RatingHelpfulVote is a user marking the rating of the stay TransactionID as
+// helpful. Rating.HelpfulCount keeps the total so listings don't count votes.
+type RatingHelpfulVote struct {
+	TransactionID uint `gorm:"primaryKey;autoIncrement:false"`
+	VoterID       uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt     time.Time
+}
@@ -0,0 +1,20 @@
+package model
+
+// RatingSummary is the aggregate of the ratings of a house, stored on the
+// house and refreshed whenever one of its ratings changes. Sub-score averages
+// only count the ratings that gave that sub-score. Distribution counts the
+// overall scores from one to five stars.
+type RatingSummary struct {
+	Count         int     `gorm:"NOT NULL;default:0"`
+	Average       float64 `gorm:"NOT NULL;default:0;index"`
+	Cleanliness   float64 `gorm:"NOT NULL;default:0"`
+	Accuracy      float64 `gorm:"NOT NULL;default:0"`
+	Location      float64 `gorm:"NOT NULL;default:0"`
+	Communication float64 `gorm:"NOT NULL;default:0"`
+	Value         float64 `gorm:"NOT NULL;default:0"`
+	OneStar       int     `gorm:"NOT NULL;default:0"`
+	TwoStar       int     `gorm:"NOT NULL;default:0"`
+	ThreeStar     int     `gorm:"NOT NULL;default:0"`
+	FourStar      int     `gorm:"NOT NULL;default:0"`
+	FiveStar      int     `gorm:"NOT NULL;default:0"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one login session. Only the sha256 of the token handed to
+// the client is stored, and access tokens carry the row ID so revoking the
+// row also cuts off the access tokens issued for it.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"NOT NULL;index"`
+	TokenHash string    `gorm:"type:varchar(64);NOT NULL;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"NOT NULL"`
+	RevokedAt time.Time `gorm:"default:null"`
+}
@@ -8,18 +8,31 @@ import (
 
 type Transaction struct {
 	gorm.Model
-	UserID uint `gorm:"not null"`
-	HouseID uint `gorm:"not null"`
-	HostID uint `gorm:"not null"`
-	InvoiceID string
-	PaymentUrl string
+	UserID         uint `gorm:"not null"`
+	HouseID        uint `gorm:"not null"`
+	HostID         uint `gorm:"not null"`
+	InvoiceID      string
+	PaymentID      string
+	PaymentUrl     string
 	PaymentChannel string
-	PaymentMethod string
-	PaidAt time.Time `gorm:"default:null"`
-	CheckinDate time.Time
-	CheckoutDate time.Time
-	TotalPrice float64
-	Status string `gorm:"not null;default:PENDING"`
-	User User
-	House House
-}
\ No newline at end of file
+	PaymentMethod  string
+	PaidAt         time.Time `gorm:"default:null"`
+	CheckinDate    time.Time
+	CheckoutDate   time.Time
+	Adults         int `gorm:"not null;default:1"`
+	Children       int `gorm:"not null;default:0"`
+	Infants        int `gorm:"not null;default:0"`
+	Pets           int `gorm:"not null;default:0"`
+	TotalPrice     float64
+	RefundAmount   float64
+	RefundError    string
+	CancelReason   string
+	CancelledAt    time.Time `gorm:"default:null"`
+	RespondBy      time.Time `gorm:"default:null"`
+	ApprovedAt     time.Time `gorm:"default:null"`
+	DeclineReason  string
+	Status         TransactionStatus `gorm:"not null;default:PENDING"`
+	User           User
+	House          House
+	LineItems      []TransactionLineItem
+}
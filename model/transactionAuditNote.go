@@ -0,0 +1,14 @@
+package model
+
+import "gorm.io/gorm"
+
+// TransactionAuditNote records a status change an admin forced on a
+// transaction and why.
+type TransactionAuditNote struct {
+	gorm.Model
+	TransactionID uint              `gorm:"NOT NULL;index"`
+	AdminID       uint              `gorm:"NOT NULL"`
+	FromStatus    TransactionStatus `gorm:"NOT NULL"`
+	ToStatus      TransactionStatus `gorm:"NOT NULL"`
+	Note          string            `gorm:"NOT NULL"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "gorm.io/gorm"
+
+// TransactionLineItem is one line of the price breakdown stored at booking
+// time so invoices, receipts and host payouts read the same numbers.
+type TransactionLineItem struct {
+	gorm.Model
+	TransactionID uint   `gorm:"NOT NULL;index"`
+	Type          string `gorm:"NOT NULL"`
+	Name          string
+	UnitPrice     float64
+	Quantity      int
+	Amount        float64
+}
@@ -0,0 +1,70 @@
+package model
+
+import "gorm.io/gorm"
+
+// TransactionStatus is where a booking is in its lifecycle. Statuses only move
+// along the transitions below, see CanTransitionTo.
+type TransactionStatus string
+
+const (
+	REQUESTED_STATUS  TransactionStatus = "REQUESTED"
+	PENDING_STATUS    TransactionStatus = "PENDING"
+	PAID_STATUS       TransactionStatus = "PAID"
+	CHECKED_IN_STATUS TransactionStatus = "CHECKED_IN"
+	COMPLETED_STATUS  TransactionStatus = "COMPLETED"
+	CANCELLED_STATUS  TransactionStatus = "CANCELLED"
+	EXPIRED_STATUS    TransactionStatus = "EXPIRED"
+	DECLINED_STATUS   TransactionStatus = "DECLINED"
+	REFUNDED_STATUS   TransactionStatus = "REFUNDED"
+)
+
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	REQUESTED_STATUS:  {PENDING_STATUS, DECLINED_STATUS, CANCELLED_STATUS},
+	PENDING_STATUS:    {PAID_STATUS, EXPIRED_STATUS, CANCELLED_STATUS},
+	PAID_STATUS:       {CHECKED_IN_STATUS, CANCELLED_STATUS, REFUNDED_STATUS},
+	CHECKED_IN_STATUS: {COMPLETED_STATUS, REFUNDED_STATUS},
+	COMPLETED_STATUS:  {REFUNDED_STATUS},
+	CANCELLED_STATUS:  {REFUNDED_STATUS},
+}
+
+// RELEASED_STATUSES no longer hold the dates of the house.
+var RELEASED_STATUSES = []TransactionStatus{EXPIRED_STATUS, CANCELLED_STATUS, DECLINED_STATUS, REFUNDED_STATUS}
+
+// IsValid reports whether status is one of the known statuses.
+func (status TransactionStatus) IsValid() bool {
+	switch status {
+	case REQUESTED_STATUS, PENDING_STATUS, PAID_STATUS, CHECKED_IN_STATUS, COMPLETED_STATUS, CANCELLED_STATUS, EXPIRED_STATUS, DECLINED_STATUS, REFUNDED_STATUS:
+		return true
+	}
+
+	return false
+}
+
+// CanTransitionTo reports whether a transaction in status may move to next.
+// Expired, declined and refunded transactions are final, a cancelled one only
+// becomes refunded once its refund went through.
+func (status TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range transactionTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransactionStatusHistory records one status change of a transaction. The
+// first entry of a transaction has no FromStatus, ChangedBy is empty when the
+// system made the change, like a payment callback or the expiry worker.
+type TransactionStatusHistory struct {
+	gorm.Model
+	TransactionID uint              `gorm:"NOT NULL;index"`
+	FromStatus    TransactionStatus `gorm:"size:20"`
+	ToStatus      TransactionStatus `gorm:"NOT NULL;size:20"`
+	ChangedBy     *uint             `gorm:"default:null"`
+	Note          string
+}
+
+func (TransactionStatusHistory) TableName() string {
+	return "transaction_status_history"
+}
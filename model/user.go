@@ -4,7 +4,10 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Name     string
-	Email    string `gorm:"unique"`
-	Password string
+	Name          string
+	Email         string `gorm:"unique"`
+	Password      string
+	Role          string `gorm:"NOT NULL;default:guest"`
+	Suspended     bool   `gorm:"NOT NULL;default:false"`
+	EmailVerified bool   `gorm:"NOT NULL;default:false"`
 }
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserToken is a single use token mailed to a user to verify their email or
+// reset their password. Like refresh tokens only the sha256 is stored.
+type UserToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"NOT NULL;index"`
+	Purpose   string    `gorm:"type:varchar(32);NOT NULL"`
+	TokenHash string    `gorm:"type:varchar(64);NOT NULL;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"NOT NULL"`
+	UsedAt    time.Time `gorm:"default:null"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is a payment callback as the provider sent it. EventID is the
+// provider's id of the event, so a callback delivered again is stored once.
+// ProcessedAt and Result are set once the event was applied to its
+// transaction, or why it wasn't.
+type WebhookEvent struct {
+	gorm.Model
+	EventID        string `gorm:"NOT NULL;size:128;uniqueIndex"`
+	InvoiceID      string `gorm:"NOT NULL;index"`
+	Status         string `gorm:"NOT NULL"`
+	Amount         float64
+	PaymentMethod  string
+	PaymentChannel string
+	PaidAt         time.Time `gorm:"default:null"`
+	OccurredAt     time.Time `gorm:"NOT NULL"`
+	Payload        string    `gorm:"type:text"`
+	ProcessedAt    time.Time `gorm:"default:null"`
+	Result         string
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wishlist is a named collection of houses a user saved for later. ShareToken
+// is set while the wishlist is shared; anyone holding it can view the houses
+// but not change them.
+type Wishlist struct {
+	gorm.Model
+	UserID     uint    `gorm:"NOT NULL;index"`
+	Name       string  `gorm:"NOT NULL"`
+	ShareToken *string `gorm:"default:null;size:64;uniqueIndex"`
+}
+
+type WishlistHouse struct {
+	WishlistID uint `gorm:"primaryKey"`
+	HouseID    uint `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+const DEFAULT_LIMIT = 10
+const MAX_LIMIT = 100
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// Cursor is where a page starts. Lists ordered by id continue after LastID,
+// so rows created while a client pages through don't shift the pages; lists
+// with an order of their own skip Offset rows instead. Clients only ever see
+// the encoded form.
+type Cursor struct {
+	LastID uint `json:"id,omitempty"`
+	Offset int  `json:"offset,omitempty"`
+}
+
+type Page struct {
+	Limit  int
+	Cursor Cursor
+}
+
+// Result describes the page that was loaded. NextCursor is empty on the last
+// page.
+type Result struct {
+	Total      int64
+	NextCursor string
+	HasMore    bool
+}
+
+// NewPage reads the limit and cursor query params. An empty limit falls back
+// to DEFAULT_LIMIT and an empty cursor starts at the first page.
+func NewPage(limit, cursor string) (Page, error) {
+	page := Page{Limit: DEFAULT_LIMIT}
+
+	if limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MAX_LIMIT {
+			return page, ErrInvalidLimit
+		}
+		page.Limit = value
+	}
+
+	if cursor != "" {
+		value, err := DecodeCursor(cursor)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = value
+	}
+
+	return page, nil
+}
+
+func EncodeCursor(cursor Cursor) string {
+	value, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func DecodeCursor(cursor string) (Cursor, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	result := Cursor{}
+	if err := json.Unmarshal(value, &result); err != nil || result.Offset < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("Encode And Decode Cursor", func(t *testing.T) {
+		cursor, err := DecodeCursor(EncodeCursor(Cursor{LastID: 42}))
+
+		assert.Nil(t, err)
+		assert.Equal(t, uint(42), cursor.LastID)
+		assert.Equal(t, 0, cursor.Offset)
+	})
+
+	t.Run("Error Decode Invalid Cursor", func(t *testing.T) {
+		_, err := DecodeCursor("not a cursor")
+
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+
+	t.Run("Error Decode Negative Offset", func(t *testing.T) {
+		_, err := DecodeCursor(EncodeCursor(Cursor{Offset: -10}))
+
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+}
+
+func TestNewPage(t *testing.T) {
+	t.Run("Default Page", func(t *testing.T) {
+		page, err := NewPage("", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, DEFAULT_LIMIT, page.Limit)
+		assert.Equal(t, Cursor{}, page.Cursor)
+	})
+
+	t.Run("Page With Cursor", func(t *testing.T) {
+		page, err := NewPage("5", EncodeCursor(Cursor{Offset: 5}))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 5, page.Limit)
+		assert.Equal(t, 5, page.Cursor.Offset)
+	})
+
+	t.Run("Error Limit Too Large", func(t *testing.T) {
+		_, err := NewPage("1000", "")
+
+		assert.Equal(t, ErrInvalidLimit, err)
+	})
+
+	t.Run("Error Invalid Limit", func(t *testing.T) {
+		_, err := NewPage("ten", "")
+
+		assert.Equal(t, ErrInvalidLimit, err)
+	})
+
+	t.Run("Error Invalid Cursor", func(t *testing.T) {
+		_, err := NewPage("", "abc")
+
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+}
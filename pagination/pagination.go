@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// Find loads one page of query into dest, a pointer to a slice of models with
+// an ID field, in ascending id order. Preloads are applied after counting so
+// the count stays a single query.
+func Find(query *gorm.DB, page Page, dest interface{}, preloads ...string) (Result, error) {
+	return find(query, page, dest, preloads, true)
+}
+
+// FindOrdered is Find for a query that already carries its own order, or
+// whose models have no single id to continue after.
+func FindOrdered(query *gorm.DB, page Page, dest interface{}, preloads ...string) (Result, error) {
+	return find(query, page, dest, preloads, false)
+}
+
+func find(query *gorm.DB, page Page, dest interface{}, preloads []string, byId bool) (Result, error) {
+	result := Result{}
+
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	if byId {
+		query = query.Where("id > ?", page.Cursor.LastID).Order("id")
+	} else {
+		query = query.Offset(page.Cursor.Offset)
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	// one extra row tells whether another page follows
+	if err := query.Limit(page.Limit + 1).Find(dest).Error; err != nil {
+		return result, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() <= page.Limit {
+		return result, nil
+	}
+
+	rows.Set(rows.Slice(0, page.Limit))
+	result.HasMore = true
+
+	next := Cursor{Offset: page.Cursor.Offset + page.Limit}
+	if byId {
+		last := reflect.Indirect(rows.Index(page.Limit - 1))
+		next = Cursor{LastID: uint(last.FieldByName("ID").Uint())}
+	}
+	result.NextCursor = EncodeCursor(next)
+
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/labstack/echo/v4"
+)
+
+// FakeProvider is an in-process payment provider for local development and
+// tests. It never talks to the network and keeps every invoice in memory.
+type FakeProvider struct {
+	CallbackToken string
+	BaseUrl       string
+
+	mu       sync.Mutex
+	invoices map[string]*FakeInvoice
+}
+
+type FakeInvoice struct {
+	ExternalID string
+	PaymentID  string
+	Amount     float64
+	Refunded   float64
+	Items      []InvoiceItem
+	Fees       []InvoiceFee
+	Status     string
+}
+
+func NewFakeProvider(callbackToken string) *FakeProvider {
+	return &FakeProvider{
+		CallbackToken: callbackToken,
+		BaseUrl:       "http://localhost/fake-invoices/",
+		invoices:      map[string]*FakeInvoice{},
+	}
+}
+
+func (fp *FakeProvider) CreateInvoice(transaction model.Transaction, email string, invoice Invoice) (model.Transaction, error) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	inv := &FakeInvoice{
+		ExternalID: transaction.InvoiceID,
+		PaymentID:  "fake-" + transaction.InvoiceID,
+		Amount:     invoice.Amount(),
+		Items:      invoice.Items,
+		Fees:       invoice.Fees,
+		Status:     "PENDING",
+	}
+	fp.invoices[transaction.InvoiceID] = inv
+
+	return model.Transaction{
+		UserID:       transaction.UserID,
+		HouseID:      transaction.HouseID,
+		InvoiceID:    transaction.InvoiceID,
+		PaymentID:    inv.PaymentID,
+		PaymentUrl:   fp.BaseUrl + transaction.InvoiceID,
+		CheckinDate:  transaction.CheckinDate,
+		CheckoutDate: transaction.CheckoutDate,
+		TotalPrice:   inv.Amount,
+		Status:       model.TransactionStatus(inv.Status),
+	}, nil
+}
+
+func (fp *FakeProvider) ExpireInvoice(transaction model.Transaction) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	inv, ok := fp.invoices[transaction.InvoiceID]
+	if !ok {
+		return errors.New("invoice not found")
+	}
+	if inv.Status != "PENDING" {
+		return errors.New("invoice is not pending")
+	}
+
+	inv.Status = "EXPIRED"
+
+	return nil
+}
+
+func (fp *FakeProvider) Refund(transaction model.Transaction, amount float64) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	inv, ok := fp.invoices[transaction.InvoiceID]
+	if !ok {
+		return errors.New("invoice not found")
+	}
+	if inv.Status != "PAID" {
+		return errors.New("invoice is not paid")
+	}
+	if inv.Refunded+amount > inv.Amount {
+		return errors.New("refund exceeds paid amount")
+	}
+
+	inv.Refunded += amount
+
+	return nil
+}
+
+func (fp *FakeProvider) ParseCallback(c echo.Context) (common.CallbackRequest, error) {
+	return readSignedCallback(c, fp.CallbackToken)
+}
+
+// Invoice returns a copy of the stored invoice so tests can assert on it.
+func (fp *FakeProvider) Invoice(invoiceId string) (FakeInvoice, bool) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	inv, ok := fp.invoices[invoiceId]
+	if !ok {
+		return FakeInvoice{}, false
+	}
+
+	return *inv, true
+}
+
+// Pay marks the invoice as paid and returns the callback request the gateway
+// would send, ready to be served to TransactionController.Callback.
+func (fp *FakeProvider) Pay(invoiceId string) (*http.Request, error) {
+	return fp.settle(invoiceId, "PAID")
+}
+
+// Expire marks the invoice as expired and returns the matching callback request.
+func (fp *FakeProvider) Expire(invoiceId string) (*http.Request, error) {
+	return fp.settle(invoiceId, "EXPIRED")
+}
+
+func (fp *FakeProvider) settle(invoiceId, status string) (*http.Request, error) {
+	fp.mu.Lock()
+	inv, ok := fp.invoices[invoiceId]
+	if ok {
+		inv.Status = status
+	}
+	fp.mu.Unlock()
+
+	if !ok {
+		return nil, errors.New("invoice not found")
+	}
+
+	now := time.Now()
+
+	callbackRequest := common.CallbackRequest{
+		ID:             inv.PaymentID,
+		ExternalID:     invoiceId,
+		PaymentMethod:  "BANK_TRANSFER",
+		PaymentChannel: "FAKE",
+		Status:         status,
+		Amount:         inv.Amount,
+		Updated:        now.Format(time.RFC3339Nano),
+	}
+	if status == "PAID" {
+		callbackRequest.PaidAmount = inv.Amount
+		callbackRequest.PaidAt = now.Format(time.RFC3339)
+	}
+
+	body, _ := json.Marshal(callbackRequest)
+
+	req, err := http.NewRequest(http.MethodPost, "/transactions/callback", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Webhook-Id", inv.PaymentID+"-"+status)
+	req.Header.Set("X-Callback-Signature", SignCallback(fp.CallbackToken, body))
+
+	return req, nil
+}
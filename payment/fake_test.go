@@ -0,0 +1,91 @@
+package payment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeProvider(t *testing.T) {
+	mockTransaction := model.Transaction{
+		InvoiceID:    "JHAKHSHJSIWOAM",
+		CheckinDate:  time.Now(),
+		CheckoutDate: time.Now().AddDate(0, 0, 2),
+		House:        model.House{Price: 150000},
+	}
+
+	mockInvoice := Invoice{
+		Items: []InvoiceItem{{Name: "House 1", Price: 150000, Quantity: 2}},
+	}
+
+	t.Run("Create Invoice", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+
+		res, err := fakeProvider.CreateInvoice(mockTransaction, "test@gmail.com", mockInvoice)
+		assert.Nil(t, err)
+		assert.Equal(t, model.PENDING_STATUS, res.Status)
+		assert.Equal(t, float64(300000), res.TotalPrice)
+		assert.Equal(t, "http://localhost/fake-invoices/JHAKHSHJSIWOAM", res.PaymentUrl)
+	})
+
+	t.Run("Expire Invoice", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+		fakeProvider.CreateInvoice(mockTransaction, "test@gmail.com", mockInvoice)
+
+		assert.Nil(t, fakeProvider.ExpireInvoice(mockTransaction))
+		assert.NotNil(t, fakeProvider.ExpireInvoice(mockTransaction))
+	})
+
+	t.Run("Refund Paid Invoice", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+		fakeProvider.CreateInvoice(mockTransaction, "test@gmail.com", mockInvoice)
+
+		assert.NotNil(t, fakeProvider.Refund(mockTransaction, 100000))
+
+		req, err := fakeProvider.Pay(mockTransaction.InvoiceID)
+		assert.Nil(t, err)
+		assert.Equal(t, "fake-JHAKHSHJSIWOAM-PAID", req.Header.Get("Webhook-Id"))
+
+		assert.Nil(t, fakeProvider.Refund(mockTransaction, 100000))
+		assert.NotNil(t, fakeProvider.Refund(mockTransaction, 250000))
+
+		invoice, _ := fakeProvider.Invoice(mockTransaction.InvoiceID)
+		assert.Equal(t, float64(100000), invoice.Refunded)
+	})
+
+	t.Run("Parse Signed Callback", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+		fakeProvider.CreateInvoice(mockTransaction, "test@gmail.com", mockInvoice)
+
+		req, _ := fakeProvider.Pay(mockTransaction.InvoiceID)
+
+		callbackRequest, err := fakeProvider.ParseCallback(echo.New().NewContext(req, httptest.NewRecorder()))
+		assert.Nil(t, err)
+		assert.Equal(t, "fake-JHAKHSHJSIWOAM-PAID", callbackRequest.EventID)
+		assert.Equal(t, "PAID", callbackRequest.Status)
+		assert.Equal(t, float64(300000), callbackRequest.PaidAmount)
+	})
+
+	t.Run("Parse Tampered Callback", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+
+		req := httptest.NewRequest(http.MethodPost, "/transactions/callback", strings.NewReader(`{"external_id":"JHAKHSHJSIWOAM","status":"PAID"}`))
+		req.Header.Set("X-Callback-Signature", SignCallback("token", []byte(`{"external_id":"JHAKHSHJSIWOAM","status":"EXPIRED"}`)))
+
+		_, err := fakeProvider.ParseCallback(echo.New().NewContext(req, httptest.NewRecorder()))
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("Pay Unknown Invoice", func(t *testing.T) {
+		fakeProvider := NewFakeProvider("token")
+
+		_, err := fakeProvider.Pay("UNKNOWN")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,16 @@
+package payment
+
+import (
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/labstack/echo/v4"
+)
+
+type PaymentProvider interface {
+	CreateInvoice(transaction model.Transaction, email string, invoice Invoice) (model.Transaction, error)
+	ExpireInvoice(transaction model.Transaction) error
+	Refund(transaction model.Transaction, amount float64) error
+	// ParseCallback returns ErrInvalidSignature or ErrInvalidCallbackToken
+	// when the callback can't be verified as sent by the provider.
+	ParseCallback(c echo.Context) (common.CallbackRequest, error)
+}
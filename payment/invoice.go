@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/model"
+)
+
+type InvoiceItem struct {
+	Name     string
+	Price    float64
+	Quantity int
+}
+
+// InvoiceFee is an extra line on top of the items. Discounts are fees with a
+// negative value.
+type InvoiceFee struct {
+	Type  string
+	Value float64
+}
+
+type Invoice struct {
+	Items []InvoiceItem
+	Fees  []InvoiceFee
+}
+
+func (i Invoice) Amount() float64 {
+	var amount float64
+
+	for _, item := range i.Items {
+		amount += item.Price * float64(item.Quantity)
+	}
+
+	for _, fee := range i.Fees {
+		amount += fee.Value
+	}
+
+	return amount
+}
+
+// NewInvoiceFromLineItems sends the nights of a booking as invoice items and
+// every other line (discount, cleaning fee, service fee, tax) as a fee.
+func NewInvoiceFromLineItems(lineItems []model.TransactionLineItem) Invoice {
+	invoice := Invoice{Items: []InvoiceItem{}, Fees: []InvoiceFee{}}
+
+	for _, lineItem := range lineItems {
+		if lineItem.Type == helper.NIGHT_LINE {
+			invoice.Items = append(invoice.Items, InvoiceItem{
+				Name:     lineItem.Name,
+				Price:    lineItem.UnitPrice,
+				Quantity: lineItem.Quantity,
+			})
+			continue
+		}
+
+		invoice.Fees = append(invoice.Fees, InvoiceFee{
+			Type:  lineItem.Name,
+			Value: lineItem.Amount,
+		})
+	}
+
+	return invoice
+}
@@ -0,0 +1,65 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/labstack/echo/v4"
+)
+
+var ErrInvalidSignature = errors.New("invalid callback signature")
+var ErrInvalidCallbackToken = errors.New("invalid callback token")
+
+// SignCallback signs a callback body with the shared callback secret. The
+// signature is sent hex encoded in the X-Callback-Signature header.
+func SignCallback(secret string, body []byte) string {
+	return hex.EncodeToString(signMAC(secret, body))
+}
+
+// readSignedCallback verifies the signature of a callback against its raw
+// body before decoding it.
+func readSignedCallback(c echo.Context, secret string) (common.CallbackRequest, error) {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return common.CallbackRequest{}, err
+	}
+
+	signature, err := hex.DecodeString(c.Request().Header.Get("X-Callback-Signature"))
+	if err != nil || !hmac.Equal(signature, signMAC(secret, body)) {
+		return common.CallbackRequest{}, ErrInvalidSignature
+	}
+
+	return decodeCallback(c, body)
+}
+
+// decodeCallback decodes a verified callback body. The event id comes from
+// the Webhook-Id header, a callback without one is identified by the hash of
+// its body so the same callback sent again still maps to the same event.
+func decodeCallback(c echo.Context, body []byte) (common.CallbackRequest, error) {
+	var callbackRequest common.CallbackRequest
+
+	if err := json.Unmarshal(body, &callbackRequest); err != nil {
+		return callbackRequest, err
+	}
+
+	callbackRequest.EventID = c.Request().Header.Get("Webhook-Id")
+	if callbackRequest.EventID == "" {
+		hash := sha256.Sum256(body)
+		callbackRequest.EventID = hex.EncodeToString(hash[:])
+	}
+	callbackRequest.Payload = string(body)
+
+	return callbackRequest, nil
+}
+
+func signMAC(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,139 @@
+package payment
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/furqonzt99/airbnb/delivery/common"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/labstack/echo/v4"
+	"github.com/xendit/xendit-go"
+	"github.com/xendit/xendit-go/invoice"
+)
+
+type XenditProvider struct {
+	option        xendit.Option
+	callbackToken string
+}
+
+func NewXenditProvider(secretKey, callbackToken string) *XenditProvider {
+	return &XenditProvider{
+		option: xendit.Option{
+			SecretKey: secretKey,
+			XenditURL: xendit.Opt.XenditURL,
+		},
+		callbackToken: callbackToken,
+	}
+}
+
+func (xp *XenditProvider) client() *invoice.Client {
+	return &invoice.Client{Opt: &xp.option, APIRequester: xendit.GetAPIRequester()}
+}
+
+func (xp *XenditProvider) CreateInvoice(transaction model.Transaction, email string, inv Invoice) (model.Transaction, error) {
+	items := []xendit.InvoiceItem{}
+	for _, item := range inv.Items {
+		items = append(items, xendit.InvoiceItem{
+			Name:     item.Name,
+			Price:    item.Price,
+			Quantity: item.Quantity,
+		})
+	}
+
+	fees := []xendit.InvoiceFee{}
+	for _, fee := range inv.Fees {
+		fees = append(fees, xendit.InvoiceFee{
+			Type:  fee.Type,
+			Value: fee.Value,
+		})
+	}
+
+	data := invoice.CreateParams{
+		ExternalID:  transaction.InvoiceID,
+		Amount:      inv.Amount(),
+		Description: "Invoice " + transaction.InvoiceID + " for " + email,
+		PayerEmail:  email,
+		Items:       items,
+		Fees:        fees,
+	}
+
+	resp, err := xp.client().Create(&data)
+	if err != nil {
+		return transaction, err
+	}
+
+	transactionSuccess := model.Transaction{
+		UserID:       transaction.UserID,
+		HouseID:      transaction.HouseID,
+		InvoiceID:    transaction.InvoiceID,
+		PaymentID:    resp.ID,
+		PaymentUrl:   resp.InvoiceURL,
+		CheckinDate:  transaction.CheckinDate,
+		CheckoutDate: transaction.CheckoutDate,
+		TotalPrice:   resp.Amount,
+		Status:       model.TransactionStatus(resp.Status),
+	}
+
+	return transactionSuccess, nil
+}
+
+func (xp *XenditProvider) ExpireInvoice(transaction model.Transaction) error {
+	if transaction.PaymentID == "" {
+		return errors.New("transaction has no payment id")
+	}
+
+	if _, err := xp.client().Expire(&invoice.ExpireParams{ID: transaction.PaymentID}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (xp *XenditProvider) Refund(transaction model.Transaction, amount float64) error {
+	if transaction.PaymentID == "" {
+		return errors.New("transaction has no payment id")
+	}
+
+	body := map[string]interface{}{
+		"invoice_id":   transaction.PaymentID,
+		"reference_id": transaction.InvoiceID,
+		"amount":       amount,
+		"reason":       "CANCELLATION",
+	}
+
+	var result map[string]interface{}
+	if err := xendit.GetAPIRequester().Call(
+		context.Background(),
+		http.MethodPost,
+		fmt.Sprintf("%s/refunds", xp.option.XenditURL),
+		xp.option.SecretKey,
+		&http.Header{},
+		body,
+		&result,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ParseCallback checks the X-Callback-Token header against the verification
+// token of the Xendit dashboard. Xendit doesn't sign invoice callbacks, the
+// static token is all it sends.
+func (xp *XenditProvider) ParseCallback(c echo.Context) (common.CallbackRequest, error) {
+	token := c.Request().Header.Get("X-Callback-Token")
+	if xp.callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(xp.callbackToken)) != 1 {
+		return common.CallbackRequest{}, ErrInvalidCallbackToken
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return common.CallbackRequest{}, err
+	}
+
+	return decodeCallback(c, body)
+}
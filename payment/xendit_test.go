@@ -0,0 +1,73 @@
+package payment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// xenditCallback is an invoice callback the way Xendit sends it.
+const xenditCallback = `{
+	"id": "579c8d61f23fa4ca35e52da4",
+	"external_id": "JHAKHSHJSIWOAM",
+	"user_id": "5781d19b2e2385880609791c",
+	"is_high": true,
+	"payment_method": "BANK_TRANSFER",
+	"status": "PAID",
+	"merchant_name": "Airbnb",
+	"amount": 300000,
+	"paid_amount": 300000,
+	"bank_code": "BRI",
+	"paid_at": "2022-01-13T02:32:50.912Z",
+	"payer_email": "test@gmail.com",
+	"description": "Invoice JHAKHSHJSIWOAM for test@gmail.com",
+	"adjusted_received_amount": 297500,
+	"fees_paid_amount": 2500,
+	"created": "2022-01-13T02:30:01.123Z",
+	"updated": "2022-01-13T02:32:51.256Z",
+	"currency": "IDR",
+	"payment_channel": "BRI",
+	"payment_destination": "8808999939380502"
+}`
+
+func TestXenditProvider(t *testing.T) {
+	newCallbackRequest := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/transactions/callback", strings.NewReader(xenditCallback))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CALLBACK-TOKEN", token)
+		req.Header.Set("webhook-id", "whk-1642041171256")
+
+		return req
+	}
+
+	t.Run("Parse Callback", func(t *testing.T) {
+		xenditProvider := NewXenditProvider("", "token")
+
+		callbackRequest, err := xenditProvider.ParseCallback(echo.New().NewContext(newCallbackRequest("token"), httptest.NewRecorder()))
+		assert.Nil(t, err)
+		assert.Equal(t, "whk-1642041171256", callbackRequest.EventID)
+		assert.Equal(t, "JHAKHSHJSIWOAM", callbackRequest.ExternalID)
+		assert.Equal(t, "PAID", callbackRequest.Status)
+		assert.Equal(t, float64(300000), callbackRequest.PaidAmount)
+		assert.Equal(t, "2022-01-13T02:32:51.256Z", callbackRequest.Updated)
+		assert.Equal(t, xenditCallback, callbackRequest.Payload)
+	})
+
+	t.Run("Parse Callback Wrong Token", func(t *testing.T) {
+		xenditProvider := NewXenditProvider("", "token")
+
+		_, err := xenditProvider.ParseCallback(echo.New().NewContext(newCallbackRequest("wrong"), httptest.NewRecorder()))
+		assert.Equal(t, ErrInvalidCallbackToken, err)
+	})
+
+	t.Run("Parse Callback Without Token Configured", func(t *testing.T) {
+		xenditProvider := NewXenditProvider("", "")
+
+		_, err := xenditProvider.ParseCallback(echo.New().NewContext(newCallbackRequest(""), httptest.NewRecorder()))
+		assert.Equal(t, ErrInvalidCallbackToken, err)
+	})
+}
@@ -0,0 +1,172 @@
+package conversation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"gorm.io/gorm"
+)
+
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+func (cr *ConversationRepository) GetHouse(houseId int) (model.House, error) {
+	var house model.House
+
+	if err := cr.db.Where("suspended = ?", false).First(&house, houseId).Error; err != nil {
+		return house, err
+	}
+
+	return house, nil
+}
+
+func (cr *ConversationRepository) GetTransaction(userId, trxId int) (model.Transaction, error) {
+	var transaction model.Transaction
+
+	if err := cr.db.Where("user_id = ? OR host_id = ?", userId, userId).First(&transaction, trxId).Error; err != nil {
+		return transaction, err
+	}
+
+	return transaction, nil
+}
+
+// FindOrCreate returns the thread of the guest, host and house, creating it
+// when they haven't talked yet. A TransactionID on conversation moves the
+// link of an existing thread to that booking.
+func (cr *ConversationRepository) FindOrCreate(conversation model.Conversation) (model.Conversation, error) {
+	var existing model.Conversation
+
+	query := cr.db.Where("guest_id = ? AND host_id = ? AND house_id = ?", conversation.GuestID, conversation.HostID, conversation.HouseID).Session(&gorm.Session{})
+
+	err := query.First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := cr.db.Create(&conversation).Error; err == nil {
+			return cr.Get(int(conversation.ID))
+		}
+
+		// the other participant may have opened the thread meanwhile
+		err = query.First(&existing).Error
+	}
+
+	if err != nil {
+		return existing, err
+	}
+
+	if conversation.TransactionID != nil {
+		if err := cr.db.Model(&existing).Update("transaction_id", *conversation.TransactionID).Error; err != nil {
+			return existing, err
+		}
+	}
+
+	return cr.Get(int(existing.ID))
+}
+
+func (cr *ConversationRepository) Get(conversationId int) (model.Conversation, error) {
+	var conversation model.Conversation
+
+	if err := cr.db.Preload("Guest").Preload("Host").Preload("House").First(&conversation, conversationId).Error; err != nil {
+		return conversation, err
+	}
+
+	return conversation, nil
+}
+
+func (cr *ConversationRepository) GetAllByUser(userId int, page pagination.Page) ([]model.Conversation, pagination.Result, error) {
+	conversations := []model.Conversation{}
+
+	query := cr.db.Model(&model.Conversation{}).Where("guest_id = ? OR host_id = ?", userId, userId).Order("last_message_at DESC").Order("id DESC")
+	result, err := pagination.FindOrdered(query, page, &conversations, "Guest", "Host", "House")
+
+	return conversations, result, err
+}
+
+func (cr *ConversationRepository) GetLastMessages(conversationIds []uint) (map[uint]model.Message, error) {
+	messages := []model.Message{}
+	lastMessages := map[uint]model.Message{}
+
+	if len(conversationIds) == 0 {
+		return lastMessages, nil
+	}
+
+	lastIds := cr.db.Model(&model.Message{}).Select("MAX(id)").Where("conversation_id IN ?", conversationIds).Group("conversation_id")
+	if err := cr.db.Preload("Sender").Where("id IN (?)", lastIds).Find(&messages).Error; err != nil {
+		return lastMessages, err
+	}
+
+	for _, message := range messages {
+		lastMessages[message.ConversationID] = message
+	}
+
+	return lastMessages, nil
+}
+
+// CountUnread counts, per conversation, the messages the other participant
+// sent that userId hasn't read yet.
+func (cr *ConversationRepository) CountUnread(userId int, conversationIds []uint) (map[uint]int64, error) {
+	rows := []struct {
+		ConversationID uint
+		Unread         int64
+	}{}
+	unread := map[uint]int64{}
+
+	if len(conversationIds) == 0 {
+		return unread, nil
+	}
+
+	if err := cr.db.Model(&model.Message{}).Select("conversation_id, COUNT(*) AS unread").Where("conversation_id IN ? AND sender_id <> ? AND read_at IS NULL", conversationIds, userId).Group("conversation_id").Scan(&rows).Error; err != nil {
+		return unread, err
+	}
+
+	for _, row := range rows {
+		unread[row.ConversationID] = row.Unread
+	}
+
+	return unread, nil
+}
+
+func (cr *ConversationRepository) CountAllUnread(userId int) (int64, error) {
+	var unread int64
+
+	conversationIds := cr.db.Model(&model.Conversation{}).Select("id").Where("guest_id = ? OR host_id = ?", userId, userId)
+	if err := cr.db.Model(&model.Message{}).Where("conversation_id IN (?) AND sender_id <> ? AND read_at IS NULL", conversationIds, userId).Count(&unread).Error; err != nil {
+		return unread, err
+	}
+
+	return unread, nil
+}
+
+func (cr *ConversationRepository) GetMessages(conversationId int, page pagination.Page) ([]model.Message, pagination.Result, error) {
+	messages := []model.Message{}
+
+	result, err := pagination.Find(cr.db.Model(&model.Message{}).Where("conversation_id = ?", conversationId), page, &messages, "Sender")
+
+	return messages, result, err
+}
+
+func (cr *ConversationRepository) CreateMessage(message model.Message) (model.Message, error) {
+	err := cr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Conversation{}).Where("id = ?", message.ConversationID).Update("last_message_at", message.CreatedAt).Error
+	})
+	if err != nil {
+		return message, err
+	}
+
+	cr.db.Preload("Sender").First(&message, message.ID)
+
+	return message, nil
+}
+
+func (cr *ConversationRepository) MarkRead(conversationId, userId int) error {
+	return cr.db.Model(&model.Message{}).Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationId, userId).Update("read_at", time.Now()).Error
+}
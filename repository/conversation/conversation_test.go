@@ -0,0 +1,123 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/seed"
+	"github.com/furqonzt99/airbnb/util"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var configTest *config.AppConfig
+var db *gorm.DB
+var conversationRepo *ConversationRepository
+
+func TestConversation(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Conversation{})
+	db.Migrator().DropTable(&model.Message{})
+
+	conversationRepo = NewConversationRepository(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Conversation{})
+	db.AutoMigrate(&model.Message{})
+
+	seed.UserSeed(db)
+
+	db.Create(&model.House{UserID: 2, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Jakarta", Price: 100000})
+	db.Create(&model.Transaction{UserID: 5, HouseID: 1, HostID: 2, CheckinDate: time.Now(), CheckoutDate: time.Now().AddDate(0, 0, 2)})
+
+	t.Run("Get Transaction Of Participant", func(t *testing.T) {
+		_, err := conversationRepo.GetTransaction(2, 1)
+		assert.Nil(t, err)
+
+		_, err = conversationRepo.GetTransaction(3, 1)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Create Conversation", func(t *testing.T) {
+		res, err := conversationRepo.FindOrCreate(model.Conversation{GuestID: 5, HostID: 2, HouseID: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), res.ID)
+		assert.Equal(t, "Rumah Bagus", res.House.Title)
+		assert.Nil(t, res.TransactionID)
+	})
+
+	t.Run("Find Conversation And Link Booking", func(t *testing.T) {
+		transactionId := uint(1)
+		res, err := conversationRepo.FindOrCreate(model.Conversation{GuestID: 5, HostID: 2, HouseID: 1, TransactionID: &transactionId})
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), res.ID)
+		assert.Equal(t, uint(1), *res.TransactionID)
+	})
+
+	t.Run("Send Messages", func(t *testing.T) {
+		_, err := conversationRepo.CreateMessage(model.Message{ConversationID: 1, SenderID: 5, Body: "Is there parking?"})
+		assert.Nil(t, err)
+
+		res, err := conversationRepo.CreateMessage(model.Message{ConversationID: 1, SenderID: 5, Body: "And wifi?"})
+		assert.Nil(t, err)
+		assert.Equal(t, "User 5", res.Sender.Name)
+
+		conversation, _ := conversationRepo.Get(1)
+		assert.False(t, conversation.LastMessageAt.IsZero())
+	})
+
+	t.Run("Count Unread", func(t *testing.T) {
+		unread, err := conversationRepo.CountUnread(2, []uint{1})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), unread[1])
+
+		total, err := conversationRepo.CountAllUnread(2)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), total)
+
+		total, _ = conversationRepo.CountAllUnread(5)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("Get Last Messages", func(t *testing.T) {
+		res, err := conversationRepo.GetLastMessages([]uint{1})
+		assert.Nil(t, err)
+		assert.Equal(t, "And wifi?", res[1].Body)
+	})
+
+	t.Run("Mark Read", func(t *testing.T) {
+		err := conversationRepo.MarkRead(1, 2)
+		assert.Nil(t, err)
+
+		total, _ := conversationRepo.CountAllUnread(2)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("Get Conversations Of User", func(t *testing.T) {
+		res, result, err := conversationRepo.GetAllByUser(2, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, int64(1), result.Total)
+
+		res, _, _ = conversationRepo.GetAllByUser(3, pagination.Page{Limit: 10})
+		assert.Equal(t, 0, len(res))
+	})
+
+	t.Run("Get Messages", func(t *testing.T) {
+		res, result, err := conversationRepo.GetMessages(1, pagination.Page{Limit: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Is there parking?", res[0].Body)
+		assert.True(t, result.HasMore)
+	})
+}
@@ -0,0 +1,20 @@
+package conversation
+
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
+
+type ConversationInterface interface {
+	GetHouse(houseId int) (model.House, error)
+	GetTransaction(userId, trxId int) (model.Transaction, error)
+	FindOrCreate(conversation model.Conversation) (model.Conversation, error)
+	Get(conversationId int) (model.Conversation, error)
+	GetAllByUser(userId int, page pagination.Page) ([]model.Conversation, pagination.Result, error)
+	GetLastMessages(conversationIds []uint) (map[uint]model.Message, error)
+	CountUnread(userId int, conversationIds []uint) (map[uint]int64, error)
+	CountAllUnread(userId int) (int64, error)
+	GetMessages(conversationId int, page pagination.Page) ([]model.Message, pagination.Result, error)
+	CreateMessage(message model.Message) (model.Message, error)
+	MarkRead(conversationId, userId int) error
+}
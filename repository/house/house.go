@@ -1,11 +1,19 @@
 package house
 
 import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var ErrInvalidPhotoOrder = errors.New("photo order must list every photo of the house once")
+
 type HouseRepository struct {
 	db *gorm.DB
 }
@@ -22,20 +30,116 @@ func (hr *HouseRepository) Create(newHouse model.House) (model.House, error) {
 	return newHouse, nil
 }
 
-func (hr *HouseRepository) GetAll(offset, pageSize int, search, city string) ([]model.House, error) {
+const distanceSql = "(? * ACOS(LEAST(1, COS(RADIANS(?)) * COS(RADIANS(latitude)) * COS(RADIANS(longitude) - RADIANS(?)) + SIN(RADIANS(?)) * SIN(RADIANS(latitude)))))"
+
+func (hr *HouseRepository) GetAll(filter HouseFilter, page pagination.Page) ([]model.House, pagination.Result, error) {
 	houses := []model.House{}
 
-	hr.db.Preload("Features").Preload("User").Preload("Ratings.User").Preload(clause.Associations).Offset(offset).Limit(pageSize).Where("title LIKE ?", "%"+search+"%").Where("city LIKE ?", "%"+city+"%").Find(&houses)
+	query := hr.search(filter)
+	preloads := []string{"Features", "User", "Ratings.User", clause.Associations}
+
+	switch filter.Sort {
+	case helper.PRICE_SORT:
+		query = query.Order("price").Order("id")
+	case helper.RATING_SORT:
+		query = query.Order("rating_average DESC").Order("id")
+	case helper.NEWEST_SORT:
+		query = query.Order("created_at DESC").Order("id DESC")
+	case helper.DISTANCE_SORT:
+		if filter.Near != nil {
+			// houses the same distance away still need a fixed order across
+			// pages. An ORDER BY expression replaces any ordered column, so id
+			// goes into the expression.
+			byDistance := distance(*filter.Near)
+			byDistance.SQL += ", id"
+
+			query = query.Clauses(clause.OrderBy{Expression: byDistance})
+		} else {
+			query = query.Order("id")
+		}
+	default:
+		result, err := pagination.Find(query, page, &houses, preloads...)
+		return houses, result, err
+	}
+
+	result, err := pagination.FindOrdered(query, page, &houses, preloads...)
+	return houses, result, err
+}
+
+// search applies every filter of the public listing.
+func (hr *HouseRepository) search(filter HouseFilter) *gorm.DB {
+	query := hr.db.Model(&model.House{}).Where("title LIKE ?", "%"+filter.Search+"%").Where("city LIKE ?", "%"+filter.City+"%").Where("suspended = ?", false)
+
+	if filter.Near != nil && filter.RadiusKm > 0 {
+		// the box lets the location index skip far away rows before the
+		// exact distance is computed
+		minLat, maxLat, minLng, maxLng := helper.BoundingBox(filter.Near.Latitude, filter.Near.Longitude, filter.RadiusKm)
+		query = whereInBounds(query, GeoBounds{MinLatitude: minLat, MaxLatitude: maxLat, MinLongitude: minLng, MaxLongitude: maxLng})
+		query = query.Where("? <= ?", distance(*filter.Near), filter.RadiusKm)
+	}
+
+	if filter.Bounds != nil {
+		query = whereInBounds(query, *filter.Bounds)
+	}
+
+	if !filter.CheckinDate.IsZero() && !filter.CheckoutDate.IsZero() {
+		// same overlap rules as booking: released transactions free the
+		// dates and both ends of a blocked range are unavailable
+		query = query.Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.house_id = houses.id AND transactions.deleted_at IS NULL AND transactions.status NOT IN ? AND transactions.checkout_date > ? AND transactions.checkin_date < ?)", model.RELEASED_STATUSES, filter.CheckinDate, filter.CheckoutDate)
+		query = query.Where("NOT EXISTS (SELECT 1 FROM house_blocked_dates WHERE house_blocked_dates.house_id = houses.id AND house_blocked_dates.deleted_at IS NULL AND house_blocked_dates.start_date < ? AND house_blocked_dates.end_date >= ?)", filter.CheckoutDate, filter.CheckinDate)
+	}
+
+	if filter.Guests > 0 {
+		query = query.Where("max_guests >= ?", filter.Guests)
+	}
+
+	if filter.MinPrice > 0 {
+		query = query.Where("price >= ?", filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		query = query.Where("price <= ?", filter.MaxPrice)
+	}
+
+	if len(filter.FeatureIDs) > 0 {
+		// a house has to offer every requested feature, not just one of them
+		features := map[int]bool{}
+		for _, featureId := range filter.FeatureIDs {
+			features[featureId] = true
+		}
+		query = query.Where("id IN (SELECT house_id FROM house_has_features WHERE feature_id IN ? GROUP BY house_id HAVING COUNT(DISTINCT feature_id) = ?)", filter.FeatureIDs, len(features))
+	}
+
+	if filter.MinRating > 0 {
+		query = query.Where("rating_average >= ?", filter.MinRating)
+	}
+
+	return query
+}
+
+func distance(near GeoPoint) clause.Expr {
+	return clause.Expr{
+		SQL:  distanceSql,
+		Vars: []interface{}{helper.EARTH_RADIUS_KM, near.Latitude, near.Longitude, near.Latitude},
+	}
+}
+
+func whereInBounds(query *gorm.DB, bounds GeoBounds) *gorm.DB {
+	query = query.Where("latitude BETWEEN ? AND ?", bounds.MinLatitude, bounds.MaxLatitude)
+
+	if bounds.MinLongitude > bounds.MaxLongitude {
+		return query.Where("(longitude >= ? OR longitude <= ?)", bounds.MinLongitude, bounds.MaxLongitude)
+	}
 
-	return houses, nil
+	return query.Where("longitude BETWEEN ? AND ?", bounds.MinLongitude, bounds.MaxLongitude)
 }
 
-func (hr *HouseRepository) GetAllMine(userId int) ([]model.House, error) {
+func (hr *HouseRepository) GetAllMine(userId int, page pagination.Page) ([]model.House, pagination.Result, error) {
 	houses := []model.House{}
 
-	hr.db.Preload("Features").Preload("User").Preload("Ratings.User").Preload(clause.Associations).Where("user_id=?", userId).Find(&houses)
+	result, err := pagination.Find(hr.db.Model(&model.House{}).Where("user_id=?", userId), page, &houses, "Features", "User", "Ratings.User", clause.Associations)
 
-	return houses, nil
+	return houses, result, err
 }
 
 func (hr *HouseRepository) Get(houseId int) (model.House, error) {
@@ -56,6 +160,9 @@ func (hr *HouseRepository) Update(newHouse model.House, houseId, userId int) (mo
 
 	hr.db.Model(&house).Updates(newHouse)
 
+	// the rule switches are written even when they are turned off
+	hr.db.Model(&house).Select("pets_allowed", "smoking_allowed", "events_allowed").Updates(newHouse)
+
 	return house, nil
 }
 
@@ -81,3 +188,245 @@ func (hr *HouseRepository) HouseHasFeatureDelete(houseId int) error {
 	hr.db.Delete(&house)
 	return nil
 }
+
+func (hr *HouseRepository) GetBookings(houseId int, from, to time.Time) ([]model.Transaction, error) {
+	transactions := []model.Transaction{}
+
+	if err := hr.db.Where("house_id = ? AND checkout_date > ? AND checkin_date <= ? AND status NOT IN ?", houseId, from, to, model.RELEASED_STATUSES).Find(&transactions).Error; err != nil {
+		return transactions, err
+	}
+
+	return transactions, nil
+}
+
+func (hr *HouseRepository) GetBlockedDates(houseId int, from, to time.Time) ([]model.HouseBlockedDate, error) {
+	blockedDates := []model.HouseBlockedDate{}
+
+	if err := hr.db.Where("house_id = ? AND end_date >= ? AND start_date <= ?", houseId, from, to).Order("start_date").Find(&blockedDates).Error; err != nil {
+		return blockedDates, err
+	}
+
+	return blockedDates, nil
+}
+
+func (hr *HouseRepository) CreateBlockedDate(blockedDate model.HouseBlockedDate) (model.HouseBlockedDate, error) {
+	if err := hr.db.Create(&blockedDate).Error; err != nil {
+		return blockedDate, err
+	}
+
+	return blockedDate, nil
+}
+
+func (hr *HouseRepository) DeleteBlockedDate(houseId, blockedDateId int) (model.HouseBlockedDate, error) {
+	blockedDate := model.HouseBlockedDate{}
+
+	if err := hr.db.First(&blockedDate, "id = ? AND house_id = ?", blockedDateId, houseId).Error; err != nil {
+		return blockedDate, err
+	}
+
+	hr.db.Delete(&blockedDate)
+
+	return blockedDate, nil
+}
+
+func (hr *HouseRepository) GetPricingRules(houseId int) ([]model.HousePricingRule, error) {
+	pricingRules := []model.HousePricingRule{}
+
+	if err := hr.db.Where("house_id = ?", houseId).Find(&pricingRules).Error; err != nil {
+		return pricingRules, err
+	}
+
+	return pricingRules, nil
+}
+
+func (hr *HouseRepository) CreatePricingRule(pricingRule model.HousePricingRule) (model.HousePricingRule, error) {
+	if err := hr.db.Create(&pricingRule).Error; err != nil {
+		return pricingRule, err
+	}
+
+	return pricingRule, nil
+}
+
+func (hr *HouseRepository) DeletePricingRule(houseId, pricingRuleId int) (model.HousePricingRule, error) {
+	pricingRule := model.HousePricingRule{}
+
+	if err := hr.db.First(&pricingRule, "id = ? AND house_id = ?", pricingRuleId, houseId).Error; err != nil {
+		return pricingRule, err
+	}
+
+	hr.db.Delete(&pricingRule)
+
+	return pricingRule, nil
+}
+
+func (hr *HouseRepository) GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error) {
+	houses := []model.House{}
+
+	result, err := pagination.Find(hr.db.Model(&model.House{}).Where("title LIKE ? OR city LIKE ?", "%"+search+"%", "%"+search+"%"), page, &houses, "User")
+
+	return houses, result, err
+}
+
+func (hr *HouseRepository) SetSuspended(houseId int, suspended bool) (model.House, error) {
+	house := model.House{}
+
+	if err := hr.db.First(&house, houseId).Error; err != nil {
+		return house, err
+	}
+
+	hr.db.Model(&house).Update("suspended", suspended)
+	house.Suspended = suspended
+
+	return house, nil
+}
+
+func (hr *HouseRepository) GetPhotos(houseId int) ([]model.HousePhoto, error) {
+	photos := []model.HousePhoto{}
+
+	if err := hr.db.Where("house_id = ?", houseId).Order("position").Find(&photos).Error; err != nil {
+		return photos, err
+	}
+
+	return photos, nil
+}
+
+// CreatePhoto appends the photo to the end of the gallery. The first photo of
+// a house becomes its cover.
+func (hr *HouseRepository) CreatePhoto(photo model.HousePhoto) (model.HousePhoto, error) {
+	err := hr.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.HousePhoto{}).Where("house_id = ?", photo.HouseID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		photo.Position = int(count)
+		photo.IsCover = count == 0
+
+		return tx.Create(&photo).Error
+	})
+	if err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+func (hr *HouseRepository) SetCoverPhoto(houseId, photoId int) (model.HousePhoto, error) {
+	photo := model.HousePhoto{}
+
+	err := hr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&photo, "id = ? AND house_id = ?", photoId, houseId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.HousePhoto{}).Where("house_id = ?", houseId).Update("is_cover", false).Error; err != nil {
+			return err
+		}
+
+		photo.IsCover = true
+		return tx.Model(&photo).Update("is_cover", true).Error
+	})
+	if err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// ReorderPhotos sets the gallery order to photoIds, which has to contain every
+// photo of the house exactly once.
+func (hr *HouseRepository) ReorderPhotos(houseId int, photoIds []int) ([]model.HousePhoto, error) {
+	photos := []model.HousePhoto{}
+
+	err := hr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("house_id = ?", houseId).Find(&photos).Error; err != nil {
+			return err
+		}
+
+		if len(photoIds) != len(photos) {
+			return ErrInvalidPhotoOrder
+		}
+
+		positions := map[int]int{}
+		for position, photoId := range photoIds {
+			if _, ok := positions[photoId]; ok {
+				return ErrInvalidPhotoOrder
+			}
+			positions[photoId] = position
+		}
+
+		for i := range photos {
+			position, ok := positions[int(photos[i].ID)]
+			if !ok {
+				return ErrInvalidPhotoOrder
+			}
+
+			if err := tx.Model(&photos[i]).Update("position", position).Error; err != nil {
+				return err
+			}
+			photos[i].Position = position
+		}
+
+		return nil
+	})
+	if err != nil {
+		return photos, err
+	}
+
+	sort.Slice(photos, func(i, j int) bool { return photos[i].Position < photos[j].Position })
+
+	return photos, nil
+}
+
+// DeletePhoto removes the photo row and closes the gap it leaves in the
+// order. When it was the cover the first remaining photo takes over.
+func (hr *HouseRepository) DeletePhoto(houseId, photoId int) (model.HousePhoto, error) {
+	photo := model.HousePhoto{}
+
+	err := hr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&photo, "id = ? AND house_id = ?", photoId, houseId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Delete(&photo).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.HousePhoto{}).Where("house_id = ? AND position > ?", houseId, photo.Position).Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+
+		if !photo.IsCover {
+			return nil
+		}
+
+		return tx.Model(&model.HousePhoto{}).Where("house_id = ? AND position = 0", houseId).Update("is_cover", true).Error
+	})
+	if err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// GetSavedHouseIDs reports which of houseIds are in any wishlist of the user.
+func (hr *HouseRepository) GetSavedHouseIDs(userId int, houseIds []uint) (map[uint]bool, error) {
+	savedIds := []uint{}
+	saved := map[uint]bool{}
+
+	if len(houseIds) == 0 {
+		return saved, nil
+	}
+
+	wishlistIds := hr.db.Model(&model.Wishlist{}).Select("id").Where("user_id = ?", userId)
+
+	if err := hr.db.Model(&model.WishlistHouse{}).Distinct("house_id").Where("wishlist_id IN (?) AND house_id IN ?", wishlistIds, houseIds).Pluck("house_id", &savedIds).Error; err != nil {
+		return saved, err
+	}
+
+	for _, houseId := range savedIds {
+		saved[houseId] = true
+	}
+
+	return saved, nil
+}
@@ -1,10 +1,13 @@
 package house
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/config"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"github.com/furqonzt99/airbnb/repository/feature"
 	"github.com/furqonzt99/airbnb/repository/user"
 	"github.com/furqonzt99/airbnb/seed"
@@ -98,7 +101,7 @@ func TestGetAllHouse(t *testing.T) {
 		search := "rumah"
 		city := "indonesia"
 
-		_, err := houseRepo.GetAll(offset, pageSize, search, city)
+		_, _, err := houseRepo.GetAll(HouseFilter{Search: search, City: city}, pagination.Page{Limit: pageSize, Cursor: pagination.Cursor{Offset: offset}})
 		assert.Nil(t, err)
 	})
 }
@@ -131,7 +134,7 @@ func TestGetMyHouse(t *testing.T) {
 
 	t.Run("Get All My House", func(t *testing.T) {
 		userId := 1
-		_, err := houseRepo.GetAllMine(userId)
+		_, _, err := houseRepo.GetAllMine(userId, pagination.Page{Limit: 10})
 		assert.Nil(t, err)
 	})
 }
@@ -217,6 +220,22 @@ func TestUpdateHouse(t *testing.T) {
 		assert.Equal(t, res.Address, "jalan awal")
 	})
 
+	t.Run("Update House Rules", func(t *testing.T) {
+		houseRepo.Update(model.House{PetsAllowed: true, CheckinTime: "14:00"}, 2, 4)
+
+		res, _ := houseRepo.Get(2)
+		assert.True(t, res.PetsAllowed)
+		assert.Equal(t, "14:00", res.CheckinTime)
+		assert.Equal(t, "11:00", res.CheckoutTime)
+
+		// turning a rule off is kept too
+		houseRepo.Update(model.House{PetsAllowed: false}, 2, 4)
+
+		res, _ = houseRepo.Get(2)
+		assert.False(t, res.PetsAllowed)
+		assert.Equal(t, "14:00", res.CheckinTime)
+	})
+
 	t.Run("Error Update House", func(t *testing.T) {
 		var mockHouse model.House
 		mockHouse.Title = "rumah2"
@@ -349,3 +368,461 @@ func TestDeleteHouseHasFeature(t *testing.T) {
 		assert.Equal(t, err, nil)
 	})
 }
+
+func TestBlockedDate(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.HouseBlockedDate{})
+	db.Migrator().DropTable(&model.HousePricingRule{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.HouseBlockedDate{})
+	db.AutoMigrate(&model.HousePricingRule{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	from := time.Now()
+	to := time.Now().AddDate(0, 0, 30)
+
+	t.Run("Create Blocked Date", func(t *testing.T) {
+		res, err := houseRepo.CreateBlockedDate(model.HouseBlockedDate{
+			HouseID:   1,
+			StartDate: from.AddDate(0, 0, 2),
+			EndDate:   from.AddDate(0, 0, 3),
+			Reason:    "maintenance",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, int(res.ID))
+	})
+
+	t.Run("Get Blocked Dates", func(t *testing.T) {
+		res, err := houseRepo.GetBlockedDates(1, from, to)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+	})
+
+	t.Run("Get Bookings", func(t *testing.T) {
+		_, err := houseRepo.GetBookings(1, from, to)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Delete Blocked Date", func(t *testing.T) {
+		_, err := houseRepo.DeleteBlockedDate(1, 1)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Error Delete Blocked Date", func(t *testing.T) {
+		_, err := houseRepo.DeleteBlockedDate(1, 1)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestPricingRule(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.HousePricingRule{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.HousePricingRule{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	t.Run("Create Pricing Rule", func(t *testing.T) {
+		res, err := houseRepo.CreatePricingRule(model.HousePricingRule{
+			HouseID:    1,
+			Type:       "weekend",
+			Percentage: 20,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, int(res.ID))
+	})
+
+	t.Run("Get Pricing Rules", func(t *testing.T) {
+		res, err := houseRepo.GetPricingRules(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+	})
+
+	t.Run("Get House With Pricing Rules", func(t *testing.T) {
+		res, err := houseRepo.Get(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res.PricingRules))
+	})
+
+	t.Run("Delete Pricing Rule", func(t *testing.T) {
+		_, err := houseRepo.DeletePricingRule(1, 1)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Error Delete Pricing Rule", func(t *testing.T) {
+		_, err := houseRepo.DeletePricingRule(1, 1)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAdminHouse(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	t.Run("Suspend House", func(t *testing.T) {
+		res, err := houseRepo.SetSuspended(1, true)
+		assert.Nil(t, err)
+		assert.Equal(t, true, res.Suspended)
+	})
+
+	t.Run("Suspended House Hidden From Listing", func(t *testing.T) {
+		res, _, _ := houseRepo.GetAll(HouseFilter{}, pagination.Page{Limit: 20})
+		assert.Equal(t, 14, len(res))
+	})
+
+	t.Run("Get All Houses Includes Suspended", func(t *testing.T) {
+		res, _, err := houseRepo.GetAllAdmin("", pagination.Page{Limit: 20})
+		assert.Nil(t, err)
+		assert.Equal(t, 15, len(res))
+	})
+
+	t.Run("Error Suspend House No ID", func(t *testing.T) {
+		_, err := houseRepo.SetSuspended(100, true)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestPhoto(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.HousePhoto{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.HousePhoto{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	for i := 1; i <= 3; i++ {
+		houseRepo.CreatePhoto(model.HousePhoto{
+			HouseID:      1,
+			Key:          fmt.Sprintf("houses/1/photo%v.jpg", i),
+			ThumbnailKey: fmt.Sprintf("houses/1/photo%v_thumb.jpg", i),
+			ContentType:  "image/jpeg",
+			Size:         100,
+		})
+	}
+
+	t.Run("Create Photo", func(t *testing.T) {
+		res, err := houseRepo.GetPhotos(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(res))
+		assert.Equal(t, true, res[0].IsCover)
+		assert.Equal(t, 2, res[2].Position)
+	})
+
+	t.Run("Reorder Photos", func(t *testing.T) {
+		res, err := houseRepo.ReorderPhotos(1, []int{3, 1, 2})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, int(res[0].ID))
+		assert.Equal(t, 2, int(res[2].ID))
+	})
+
+	t.Run("Error Reorder Photos Missing Photo", func(t *testing.T) {
+		_, err := houseRepo.ReorderPhotos(1, []int{3, 1})
+		assert.Equal(t, ErrInvalidPhotoOrder, err)
+	})
+
+	t.Run("Error Reorder Photos Duplicate", func(t *testing.T) {
+		_, err := houseRepo.ReorderPhotos(1, []int{3, 3, 1})
+		assert.Equal(t, ErrInvalidPhotoOrder, err)
+	})
+
+	t.Run("Set Cover Photo", func(t *testing.T) {
+		res, err := houseRepo.SetCoverPhoto(1, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, true, res.IsCover)
+
+		photos, _ := houseRepo.GetPhotos(1)
+		covers := 0
+		for _, photo := range photos {
+			if photo.IsCover {
+				covers++
+			}
+		}
+		assert.Equal(t, 1, covers)
+	})
+
+	t.Run("Error Set Cover Photo Other House", func(t *testing.T) {
+		_, err := houseRepo.SetCoverPhoto(2, 3)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Delete Cover Photo", func(t *testing.T) {
+		_, err := houseRepo.DeletePhoto(1, 3)
+		assert.Nil(t, err)
+
+		photos, _ := houseRepo.GetPhotos(1)
+		assert.Equal(t, 2, len(photos))
+		assert.Equal(t, 1, int(photos[0].ID))
+		assert.Equal(t, 0, photos[0].Position)
+		assert.Equal(t, true, photos[0].IsCover)
+		assert.Equal(t, 1, photos[1].Position)
+	})
+
+	t.Run("Error Delete Photo Not Found", func(t *testing.T) {
+		_, err := houseRepo.DeletePhoto(1, 100)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGeoSearch(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+
+	seed.UserSeed(db)
+
+	locations := []struct {
+		title     string
+		latitude  float64
+		longitude float64
+	}{
+		{"Monas", -6.175392, 106.827153},
+		{"Kota Tua", -6.135200, 106.813301},
+		{"Bogor", -6.597147, 106.806039},
+		{"Fiji", -17.713371, 178.065032},
+	}
+	for _, location := range locations {
+		latitude, longitude := location.latitude, location.longitude
+		houseRepo.Create(model.House{UserID: 2, Title: location.title, Address: "Address", City: "City", Price: 100000, Latitude: &latitude, Longitude: &longitude})
+	}
+	houseRepo.Create(model.House{UserID: 2, Title: "Nowhere", Address: "Address", City: "City", Price: 100000})
+
+	jakarta := &GeoPoint{Latitude: -6.2, Longitude: 106.816666}
+
+	t.Run("Search Within Radius", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Near: jakarta, RadiusKm: 10, Sort: "distance"}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, "Monas", res[0].Title)
+		assert.Equal(t, "Kota Tua", res[1].Title)
+	})
+
+	t.Run("Search Larger Radius", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Near: jakarta, RadiusKm: 100, Sort: "distance"}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(res))
+		assert.Equal(t, "Bogor", res[2].Title)
+	})
+
+	t.Run("Search Within Bounds", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Bounds: &GeoBounds{MinLatitude: -6.7, MaxLatitude: -6.5, MinLongitude: 106.7, MaxLongitude: 106.9}}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Bogor", res[0].Title)
+	})
+
+	t.Run("Search Bounds Across Antimeridian", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Bounds: &GeoBounds{MinLatitude: -20, MaxLatitude: -10, MinLongitude: 170, MaxLongitude: -170}}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Fiji", res[0].Title)
+	})
+
+	t.Run("Search Without Location", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 5, len(res))
+	})
+}
+
+func TestSearchFilters(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.HouseBlockedDate{})
+	db.Migrator().DropTable(&model.Rating{})
+
+	houseRepo = NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.HouseBlockedDate{})
+	db.AutoMigrate(&model.Rating{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+
+	houseRepo.Create(model.House{UserID: 2, Title: "Cheap", Address: "Address", City: "City", Price: 50000, MaxGuests: 2})
+	houseRepo.Create(model.House{UserID: 2, Title: "Booked", Address: "Address", City: "City", Price: 100000, MaxGuests: 4})
+	houseRepo.Create(model.House{UserID: 2, Title: "Blocked", Address: "Address", City: "City", Price: 150000, MaxGuests: 6})
+	houseRepo.Create(model.House{UserID: 2, Title: "Luxury", Address: "Address", City: "City", Price: 300000, MaxGuests: 8})
+
+	houseRepo.HouseHasFeature(model.HouseHasFeatures{HouseID: 1, FeatureID: 1})
+	houseRepo.HouseHasFeature(model.HouseHasFeatures{HouseID: 4, FeatureID: 1})
+	houseRepo.HouseHasFeature(model.HouseHasFeatures{HouseID: 4, FeatureID: 2})
+
+	checkinDate := time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC)
+	checkoutDate := time.Date(2021, 12, 23, 0, 0, 0, 0, time.UTC)
+
+	db.Create(&model.Transaction{UserID: 1, HouseID: 2, HostID: 2, CheckinDate: checkinDate.AddDate(0, 0, 1), CheckoutDate: checkoutDate.AddDate(0, 0, 1), Status: "PAID"})
+	db.Create(&model.Transaction{UserID: 1, HouseID: 1, HostID: 2, CheckinDate: checkinDate, CheckoutDate: checkoutDate, Status: "CANCELLED"})
+	houseRepo.CreateBlockedDate(model.HouseBlockedDate{HouseID: 3, StartDate: checkoutDate.AddDate(0, 0, -1), EndDate: checkoutDate.AddDate(0, 0, 2)})
+
+	// the rating repository keeps the summary, the search only reads it
+	db.Model(&model.House{}).Where("id = ?", 1).Update("rating_average", 3)
+	db.Model(&model.House{}).Where("id = ?", 4).Update("rating_average", 5)
+
+	t.Run("Search Available Dates", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{CheckinDate: checkinDate, CheckoutDate: checkoutDate}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, "Cheap", res[0].Title)
+		assert.Equal(t, "Luxury", res[1].Title)
+	})
+
+	t.Run("Search Guests", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Guests: 5}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+	})
+
+	t.Run("Search Price Range", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{MinPrice: 100000, MaxPrice: 200000}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, "Booked", res[0].Title)
+	})
+
+	t.Run("Search All Features", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{FeatureIDs: []int{1, 2}}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Luxury", res[0].Title)
+	})
+
+	t.Run("Search Min Rating", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{MinRating: 4}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Luxury", res[0].Title)
+	})
+
+	t.Run("Sort By Price", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Sort: "price"}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, "Cheap", res[0].Title)
+		assert.Equal(t, "Luxury", res[3].Title)
+	})
+
+	t.Run("Sort By Rating", func(t *testing.T) {
+		res, _, err := houseRepo.GetAll(HouseFilter{Sort: "rating"}, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, "Luxury", res[0].Title)
+		assert.Equal(t, "Cheap", res[1].Title)
+	})
+
+	t.Run("Page Through Matches", func(t *testing.T) {
+		res, result, err := houseRepo.GetAll(HouseFilter{MaxPrice: 150000}, pagination.Page{Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, int64(3), result.Total)
+		assert.True(t, result.HasMore)
+
+		cursor, _ := pagination.DecodeCursor(result.NextCursor)
+		res, result, err = houseRepo.GetAll(HouseFilter{MaxPrice: 150000}, pagination.Page{Limit: 2, Cursor: cursor})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Blocked", res[0].Title)
+		assert.False(t, result.HasMore)
+		assert.Equal(t, "", result.NextCursor)
+	})
+
+	t.Run("Page Through Sorted Matches", func(t *testing.T) {
+		res, result, _ := houseRepo.GetAll(HouseFilter{Sort: "price"}, pagination.Page{Limit: 3})
+		assert.Equal(t, 3, len(res))
+
+		cursor, _ := pagination.DecodeCursor(result.NextCursor)
+		res, _, _ = houseRepo.GetAll(HouseFilter{Sort: "price"}, pagination.Page{Limit: 3, Cursor: cursor})
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "Luxury", res[0].Title)
+	})
+}
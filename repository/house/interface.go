@@ -1,14 +1,67 @@
 package house
 
-import "github.com/furqonzt99/airbnb/model"
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
+
+// HouseFilter narrows the public house search. Near with RadiusKm keeps the
+// houses within that distance, Bounds keeps the houses inside a map area;
+// both can be combined. Zero values leave a filter out.
+type HouseFilter struct {
+	Search       string
+	City         string
+	Near         *GeoPoint
+	RadiusKm     float64
+	Bounds       *GeoBounds
+	CheckinDate  time.Time
+	CheckoutDate time.Time
+	Guests       int
+	MinPrice     float64
+	MaxPrice     float64
+	FeatureIDs   []int
+	MinRating    float64
+	Sort         string
+}
+
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// GeoBounds is a map area. MinLongitude is greater than MaxLongitude when the
+// area crosses the antimeridian.
+type GeoBounds struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
 
 type HouseInterface interface {
 	Create(newHouse model.House) (model.House, error)
-	GetAll(offset, pageSize int, search, city string) ([]model.House, error)
-	GetAllMine(userId int) ([]model.House, error)
+	GetAll(filter HouseFilter, page pagination.Page) ([]model.House, pagination.Result, error)
+	GetAllMine(userId int, page pagination.Page) ([]model.House, pagination.Result, error)
 	Get(houseId int) (model.House, error)
 	Update(newHouse model.House, houseId, userId int) (model.House, error)
 	Delete(houseId, userId int) (model.House, error)
 	HouseHasFeature(houseHasFeature model.HouseHasFeatures) error
 	HouseHasFeatureDelete(houseId int) error
+	GetBookings(houseId int, from, to time.Time) ([]model.Transaction, error)
+	GetBlockedDates(houseId int, from, to time.Time) ([]model.HouseBlockedDate, error)
+	CreateBlockedDate(blockedDate model.HouseBlockedDate) (model.HouseBlockedDate, error)
+	DeleteBlockedDate(houseId, blockedDateId int) (model.HouseBlockedDate, error)
+	GetPricingRules(houseId int) ([]model.HousePricingRule, error)
+	CreatePricingRule(pricingRule model.HousePricingRule) (model.HousePricingRule, error)
+	DeletePricingRule(houseId, pricingRuleId int) (model.HousePricingRule, error)
+	GetAllAdmin(search string, page pagination.Page) ([]model.House, pagination.Result, error)
+	SetSuspended(houseId int, suspended bool) (model.House, error)
+	GetPhotos(houseId int) ([]model.HousePhoto, error)
+	CreatePhoto(photo model.HousePhoto) (model.HousePhoto, error)
+	SetCoverPhoto(houseId, photoId int) (model.HousePhoto, error)
+	ReorderPhotos(houseId int, photoIds []int) ([]model.HousePhoto, error)
+	DeletePhoto(houseId, photoId int) (model.HousePhoto, error)
+	GetSavedHouseIDs(userId int, houseIds []uint) (map[uint]bool, error)
 }
@@ -0,0 +1,14 @@
+package notification
+
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
+
+type NotificationInterface interface {
+	Create(notification model.Notification) (model.Notification, error)
+	GetAll(userId int, unreadOnly bool, page pagination.Page) ([]model.Notification, pagination.Result, error)
+	CountUnread(userId int) (int64, error)
+	MarkRead(userId, notificationId int) (model.Notification, error)
+	MarkAllRead(userId int) error
+}
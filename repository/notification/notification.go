@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (nr *NotificationRepository) Create(notification model.Notification) (model.Notification, error) {
+	if err := nr.db.Create(&notification).Error; err != nil {
+		return notification, err
+	}
+
+	return notification, nil
+}
+
+func (nr *NotificationRepository) GetAll(userId int, unreadOnly bool, page pagination.Page) ([]model.Notification, pagination.Result, error) {
+	notifications := []model.Notification{}
+
+	query := nr.db.Model(&model.Notification{}).Where("user_id = ?", userId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	result, err := pagination.FindOrdered(query.Order("id DESC"), page, &notifications)
+
+	return notifications, result, err
+}
+
+func (nr *NotificationRepository) CountUnread(userId int) (int64, error) {
+	var unread int64
+
+	if err := nr.db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userId).Count(&unread).Error; err != nil {
+		return unread, err
+	}
+
+	return unread, nil
+}
+
+func (nr *NotificationRepository) MarkRead(userId, notificationId int) (model.Notification, error) {
+	var notification model.Notification
+
+	if err := nr.db.Where("user_id = ?", userId).First(&notification, notificationId).Error; err != nil {
+		return notification, err
+	}
+
+	if notification.ReadAt.IsZero() {
+		if err := nr.db.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+			return notification, err
+		}
+	}
+
+	return notification, nil
+}
+
+func (nr *NotificationRepository) MarkAllRead(userId int) error {
+	return nr.db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userId).Update("read_at", time.Now()).Error
+}
@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/util"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var configTest *config.AppConfig
+var db *gorm.DB
+var notificationRepo *NotificationRepository
+
+func TestNotification(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.Notification{})
+
+	notificationRepo = NewNotificationRepository(db)
+
+	db.AutoMigrate(&model.Notification{})
+
+	t.Run("Create Notification", func(t *testing.T) {
+		res, err := notificationRepo.Create(model.Notification{UserID: 2, Type: "booking.created", Message: "New booking", Data: `{"transaction_id":1}`})
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), res.ID)
+
+		notificationRepo.Create(model.Notification{UserID: 2, Type: "rating.created", Message: "New rating"})
+		notificationRepo.Create(model.Notification{UserID: 3, Type: "booking.paid", Message: "Booking paid"})
+	})
+
+	t.Run("Get Notifications Newest First", func(t *testing.T) {
+		res, result, err := notificationRepo.GetAll(2, false, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, int64(2), result.Total)
+		assert.Equal(t, "rating.created", res[0].Type)
+	})
+
+	t.Run("Mark Read", func(t *testing.T) {
+		_, err := notificationRepo.MarkRead(2, 1)
+		assert.Nil(t, err)
+
+		unread, _ := notificationRepo.CountUnread(2)
+		assert.Equal(t, int64(1), unread)
+
+		res, _, _ := notificationRepo.GetAll(2, true, pagination.Page{Limit: 10})
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "rating.created", res[0].Type)
+	})
+
+	t.Run("Error Mark Read Of Another User", func(t *testing.T) {
+		_, err := notificationRepo.MarkRead(2, 3)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Mark All Read", func(t *testing.T) {
+		err := notificationRepo.MarkAllRead(2)
+		assert.Nil(t, err)
+
+		unread, _ := notificationRepo.CountUnread(2)
+		assert.Equal(t, int64(0), unread)
+
+		unread, _ = notificationRepo.CountUnread(3)
+		assert.Equal(t, int64(1), unread)
+	})
+}
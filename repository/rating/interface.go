@@ -1,10 +1,22 @@
 package rating
 
-import "github.com/furqonzt99/airbnb/model"
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
 
 type Rating interface {
 	Create(model.Rating) (model.Rating, error)
 	Update(model.Rating) (model.Rating, error)
-	Delete(userId, houseId int) (model.Rating, error)
-	IsCanGiveRating(userId, houseId int) (bool, error)
+	Delete(userId, transactionId int) (model.Rating, error)
+	GetTransaction(transactionId int) (model.Transaction, error)
+	GetHouse(houseId int) (model.House, error)
+	Get(transactionId int) (model.Rating, error)
+	SetReply(transactionId int, reply string) (model.Rating, error)
+	DeleteReply(transactionId int) (model.Rating, error)
+	AddHelpfulVote(transactionId, voterId int) (model.Rating, error)
+	RemoveHelpfulVote(transactionId, voterId int) (model.Rating, error)
+	GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error)
+	SaveGuestRating(guestRating model.GuestRating) (model.GuestRating, error)
+	GetGuestRatings(guestId int, page pagination.Page) ([]model.GuestRating, pagination.Result, error)
 }
\ No newline at end of file
@@ -1,8 +1,13 @@
 package rating
 
 import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RatingRepository struct {
@@ -14,51 +19,237 @@ func NewRatingRepository(db *gorm.DB) *RatingRepository {
 }
 
 func (rr RatingRepository) Create(rating model.Rating) (model.Rating, error) {
-	if err := rr.db.Create(&rating).Error; err != nil {
+	err := rr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rating).Error; err != nil {
+			return err
+		}
+
+		return updateRatingSummary(tx, rating.HouseID)
+	})
+	if err != nil {
 		return rating, err
 	}
 
 	var r model.Rating
 
-	rr.db.Preload("User").First(&r, "user_id = ? AND house_id = ?", &rating.UserID, &rating.HouseID)
+	rr.db.Preload("User").First(&r, "transaction_id = ?", rating.TransactionID)
 
 	return r, nil
 }
 
-func (rr RatingRepository) IsCanGiveRating(userId, houseId int) (bool, error) {
+func (rr RatingRepository) GetTransaction(transactionId int) (model.Transaction, error) {
 	var transaction model.Transaction
 
-	const PAID_STATUS = "PAID"
+	if err := rr.db.First(&transaction, transactionId).Error; err != nil {
+		return transaction, err
+	}
+
+	return transaction, nil
+}
+
+func (rr *RatingRepository) GetHouse(houseId int) (model.House, error) {
+	var house model.House
 
-	if err := rr.db.Where("user_id = ? AND house_id = ? AND status = ?", userId, houseId, PAID_STATUS).First(&transaction).Error; err != nil {
-		return false, err
+	if err := rr.db.First(&house, houseId).Error; err != nil {
+		return house, err
 	}
 
-	return true, nil
+	return house, nil
 }
 
 func (rr *RatingRepository) Update(rating model.Rating) (model.Rating, error) {
 	var r model.Rating
 
-	if err := rr.db.First(&r, "user_id = ? AND house_id = ?", rating.UserID, rating.HouseID).Error; err != nil {
+	if err := rr.db.First(&r, "transaction_id = ? AND user_id = ?", rating.TransactionID, rating.UserID).Error; err != nil {
 		return r, err
 	}
 
-	rr.db.Model(&r).Updates(rating)
+	err := rr.db.Transaction(func(tx *gorm.DB) error {
+		// selected so sub-scores and the comment can be cleared back to zero
+		if err := tx.Model(&r).Select("rating", "comment", "cleanliness", "accuracy", "location", "communication", "value").Updates(rating).Error; err != nil {
+			return err
+		}
+
+		return updateRatingSummary(tx, r.HouseID)
+	})
+	if err != nil {
+		return r, err
+	}
 
-	rr.db.Preload("User").First(&r, "user_id = ? AND house_id = ?", &rating.UserID, &rating.HouseID)
+	rr.db.Preload("User").First(&r, "transaction_id = ?", rating.TransactionID)
 
 	return r, nil
 }
 
-func (rr *RatingRepository) Delete(userId, houseId int) (model.Rating, error) {
+func (rr *RatingRepository) Delete(userId, transactionId int) (model.Rating, error) {
 	rating := model.Rating{}
 
-	if err := rr.db.First(&rating, "user_id = ? AND house_id = ?", userId, houseId).Error; err != nil {
+	if err := rr.db.First(&rating, "transaction_id = ? AND user_id = ?", transactionId, userId).Error; err != nil {
 		return rating, err
 	}
 
-	rr.db.Delete(&rating)
+	err := rr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&rating).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("transaction_id = ?", transactionId).Delete(&model.RatingHelpfulVote{}).Error; err != nil {
+			return err
+		}
+
+		return updateRatingSummary(tx, rating.HouseID)
+	})
+	if err != nil {
+		return rating, err
+	}
 
 	return rating, nil
 }
+
+// updateRatingSummary recomputes the aggregate stored on the house. The house
+// row is locked so concurrent rating changes can't store a stale summary.
+func updateRatingSummary(tx *gorm.DB, houseId uint) error {
+	var house model.House
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&house, houseId).Error; err != nil {
+		return err
+	}
+
+	ratings := []model.Rating{}
+	if err := tx.Where("house_id = ?", houseId).Find(&ratings).Error; err != nil {
+		return err
+	}
+
+	summary := helper.SummarizeRatings(ratings)
+
+	// a map so counts and averages that dropped to zero are written too
+	return tx.Model(&house).Updates(map[string]interface{}{
+		"rating_count":         summary.Count,
+		"rating_average":       summary.Average,
+		"rating_cleanliness":   summary.Cleanliness,
+		"rating_accuracy":      summary.Accuracy,
+		"rating_location":      summary.Location,
+		"rating_communication": summary.Communication,
+		"rating_value":         summary.Value,
+		"rating_one_star":      summary.OneStar,
+		"rating_two_star":      summary.TwoStar,
+		"rating_three_star":    summary.ThreeStar,
+		"rating_four_star":     summary.FourStar,
+		"rating_five_star":     summary.FiveStar,
+	}).Error
+}
+
+func (rr *RatingRepository) Get(transactionId int) (model.Rating, error) {
+	var rating model.Rating
+
+	if err := rr.db.Preload("User").First(&rating, "transaction_id = ?", transactionId).Error; err != nil {
+		return rating, err
+	}
+
+	return rating, nil
+}
+
+func (rr *RatingRepository) SetReply(transactionId int, reply string) (model.Rating, error) {
+	rating, err := rr.Get(transactionId)
+	if err != nil {
+		return rating, err
+	}
+
+	if err := rr.db.Model(&rating).Updates(map[string]interface{}{"reply": reply, "replied_at": time.Now()}).Error; err != nil {
+		return rating, err
+	}
+
+	return rr.Get(transactionId)
+}
+
+func (rr *RatingRepository) DeleteReply(transactionId int) (model.Rating, error) {
+	rating, err := rr.Get(transactionId)
+	if err != nil {
+		return rating, err
+	}
+
+	if err := rr.db.Model(&rating).Updates(map[string]interface{}{"reply": "", "replied_at": nil}).Error; err != nil {
+		return rating, err
+	}
+
+	return rr.Get(transactionId)
+}
+
+// AddHelpfulVote records the vote once per voter and keeps the helpful count
+// of the rating in step with it.
+func (rr *RatingRepository) AddHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	err := rr.db.Transaction(func(tx *gorm.DB) error {
+		var rating model.Rating
+		if err := tx.First(&rating, "transaction_id = ?", transactionId).Error; err != nil {
+			return err
+		}
+
+		vote := model.RatingHelpfulVote{TransactionID: uint(transactionId), VoterID: uint(voterId)}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return tx.Model(&rating).Update("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+	if err != nil {
+		return model.Rating{}, err
+	}
+
+	return rr.Get(transactionId)
+}
+
+func (rr *RatingRepository) RemoveHelpfulVote(transactionId, voterId int) (model.Rating, error) {
+	err := rr.db.Transaction(func(tx *gorm.DB) error {
+		var rating model.Rating
+		if err := tx.First(&rating, "transaction_id = ?", transactionId).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("transaction_id = ? AND voter_id = ?", transactionId, voterId).Delete(&model.RatingHelpfulVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return tx.Model(&rating).Update("helpful_count", gorm.Expr("helpful_count - 1")).Error
+	})
+	if err != nil {
+		return model.Rating{}, err
+	}
+
+	return rr.Get(transactionId)
+}
+
+func (rr *RatingRepository) GetAllAdmin(search string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	ratings := []model.Rating{}
+
+	// ratings are keyed by their stay, there is no id to continue after
+	query := rr.db.Model(&model.Rating{}).Where("comment LIKE ?", "%"+search+"%").Order("transaction_id")
+	result, err := pagination.FindOrdered(query, page, &ratings, "User")
+
+	return ratings, result, err
+}
+
+// SaveGuestRating creates the review of the guest of the stay, or replaces it
+// when the host reviews the stay again.
+func (rr *RatingRepository) SaveGuestRating(guestRating model.GuestRating) (model.GuestRating, error) {
+	err := rr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "transaction_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "comment", "updated_at"}),
+	}).Create(&guestRating).Error
+	if err != nil {
+		return guestRating, err
+	}
+
+	rr.db.Preload("Host").First(&guestRating, "transaction_id = ?", guestRating.TransactionID)
+
+	return guestRating, nil
+}
+
+func (rr *RatingRepository) GetGuestRatings(guestId int, page pagination.Page) ([]model.GuestRating, pagination.Result, error) {
+	guestRatings := []model.GuestRating{}
+
+	query := rr.db.Model(&model.GuestRating{}).Where("guest_id = ?", guestId).Order("transaction_id DESC")
+	result, err := pagination.FindOrdered(query, page, &guestRatings, "Host")
+
+	return guestRatings, result, err
+}
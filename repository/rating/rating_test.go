@@ -2,9 +2,11 @@ package rating
 
 import (
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/config"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"github.com/furqonzt99/airbnb/repository/feature"
 	"github.com/furqonzt99/airbnb/repository/house"
 	"github.com/furqonzt99/airbnb/repository/user"
@@ -50,6 +52,7 @@ func TestCreateRating(t *testing.T) {
 
 	t.Run("Create Rating", func(t *testing.T) {
 		var mockRating model.Rating
+		mockRating.TransactionID = 1
 		mockRating.HouseID = 1
 		mockRating.UserID = 1
 		mockRating.Rating = 5
@@ -97,15 +100,18 @@ func TestUpdateRating(t *testing.T) {
 	seed.HouseSeed(db)
 
 	dummyRating := model.Rating{
-		HouseID: 1,
-		UserID:  1,
-		Rating:  5,
-		Comment: "nyaman",
+		TransactionID: 1,
+		HouseID:       1,
+		UserID:        1,
+		Rating:        5,
+		Comment:       "nyaman",
+		Cleanliness:   4,
 	}
 	ratingRepo.Create(dummyRating)
 
 	t.Run("Update Rating", func(t *testing.T) {
 		var mockRating model.Rating
+		mockRating.TransactionID = 1
 		mockRating.HouseID = 1
 		mockRating.UserID = 1
 		mockRating.Rating = 3
@@ -114,11 +120,16 @@ func TestUpdateRating(t *testing.T) {
 		res, err := ratingRepo.Update(mockRating)
 		assert.Nil(t, err)
 		assert.Equal(t, 3, res.Rating)
+		assert.Equal(t, 0, res.Cleanliness)
+
+		house, _ := ratingRepo.GetHouse(1)
+		assert.Equal(t, float64(3), house.RatingSummary.Average)
+		assert.Equal(t, float64(0), house.RatingSummary.Cleanliness)
 	})
 
 	t.Run("Error Update Rating", func(t *testing.T) {
 		var mockRating model.Rating
-		mockRating.HouseID = 100
+		mockRating.TransactionID = 100
 		mockRating.UserID = 100
 		mockRating.Rating = 3
 		mockRating.Comment = "biasa"
@@ -156,26 +167,314 @@ func TestDeleteRating(t *testing.T) {
 	seed.HouseSeed(db)
 
 	dummyRating := model.Rating{
-		HouseID: 1,
-		UserID:  1,
-		Rating:  5,
-		Comment: "nyaman",
+		TransactionID: 1,
+		HouseID:       1,
+		UserID:        1,
+		Rating:        5,
+		Comment:       "nyaman",
 	}
 	ratingRepo.Create(dummyRating)
 
 	t.Run("Delete Rating", func(t *testing.T) {
-		houseId := 1
+		transactionId := 1
 		userId := 1
 
-		_, err := ratingRepo.Delete(userId, houseId)
+		_, err := ratingRepo.Delete(userId, transactionId)
 		assert.Nil(t, err)
 	})
 
 	t.Run("Error Delete Rating", func(t *testing.T) {
-		houseId := 100
+		transactionId := 100
 		userId := 100
 
-		_, err := ratingRepo.Delete(userId, houseId)
+		_, err := ratingRepo.Delete(userId, transactionId)
 		assert.NotNil(t, err)
 	})
 }
+
+func TestAdminRating(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+
+	userRepo = user.NewUserRepo(db)
+	featureRepo = feature.NewFeatureRepo(db)
+	houseRepo = house.NewHouseRepo(db)
+	ratingRepo = NewRatingRepository(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	ratingRepo.Create(model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 1, Comment: "spam"})
+	ratingRepo.Create(model.Rating{TransactionID: 2, HouseID: 2, UserID: 1, Rating: 5, Comment: "nyaman"})
+
+	t.Run("Get All Ratings", func(t *testing.T) {
+		res, _, err := ratingRepo.GetAllAdmin("", pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+	})
+
+	t.Run("Search Ratings", func(t *testing.T) {
+		res, _, err := ratingRepo.GetAllAdmin("spam", pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+	})
+}
+
+func TestReplyAndHelpfulRating(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.RatingHelpfulVote{})
+
+	userRepo = user.NewUserRepo(db)
+	featureRepo = feature.NewFeatureRepo(db)
+	houseRepo = house.NewHouseRepo(db)
+	ratingRepo = NewRatingRepository(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.RatingHelpfulVote{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	ratingRepo.Create(model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Comment: "nyaman"})
+
+	t.Run("Reply Rating", func(t *testing.T) {
+		res, err := ratingRepo.SetReply(1, "Terima kasih")
+		assert.Nil(t, err)
+		assert.Equal(t, "Terima kasih", res.Reply)
+		assert.False(t, res.RepliedAt.IsZero())
+	})
+
+	t.Run("Edit Reply Keeps One Reply", func(t *testing.T) {
+		res, err := ratingRepo.SetReply(1, "Terima kasih banyak")
+		assert.Nil(t, err)
+		assert.Equal(t, "Terima kasih banyak", res.Reply)
+	})
+
+	t.Run("Updating Rating Keeps Reply", func(t *testing.T) {
+		res, err := ratingRepo.Update(model.Rating{TransactionID: 1, UserID: 1, Rating: 4})
+		assert.Nil(t, err)
+		assert.Equal(t, "Terima kasih banyak", res.Reply)
+	})
+
+	t.Run("Delete Reply", func(t *testing.T) {
+		res, err := ratingRepo.DeleteReply(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "", res.Reply)
+		assert.True(t, res.RepliedAt.IsZero())
+	})
+
+	t.Run("Error Reply Missing Rating", func(t *testing.T) {
+		_, err := ratingRepo.SetReply(100, "Terima kasih")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Vote Helpful Once Per User", func(t *testing.T) {
+		ratingRepo.AddHelpfulVote(1, 2)
+		ratingRepo.AddHelpfulVote(1, 2)
+
+		res, err := ratingRepo.AddHelpfulVote(1, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, res.HelpfulCount)
+	})
+
+	t.Run("Remove Helpful Vote", func(t *testing.T) {
+		ratingRepo.RemoveHelpfulVote(1, 2)
+
+		res, err := ratingRepo.RemoveHelpfulVote(1, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, res.HelpfulCount)
+	})
+
+	t.Run("Error Vote Missing Rating", func(t *testing.T) {
+		_, err := ratingRepo.AddHelpfulVote(100, 2)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Delete Rating Removes Votes", func(t *testing.T) {
+		ratingRepo.Delete(1, 1)
+
+		var votes int64
+		db.Model(&model.RatingHelpfulVote{}).Count(&votes)
+		assert.Equal(t, int64(0), votes)
+	})
+}
+
+func TestRatingSummary(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.RatingHelpfulVote{})
+
+	userRepo = user.NewUserRepo(db)
+	featureRepo = feature.NewFeatureRepo(db)
+	houseRepo = house.NewHouseRepo(db)
+	ratingRepo = NewRatingRepository(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.RatingHelpfulVote{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	summary := func() model.RatingSummary {
+		var h model.House
+		db.First(&h, 1)
+		return h.RatingSummary
+	}
+
+	t.Run("Create Updates Summary", func(t *testing.T) {
+		ratingRepo.Create(model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5, Cleanliness: 4, Location: 5})
+		ratingRepo.Create(model.Rating{TransactionID: 2, HouseID: 1, UserID: 2, Rating: 4, Cleanliness: 5})
+		ratingRepo.Create(model.Rating{TransactionID: 3, HouseID: 1, UserID: 3, Rating: 5})
+
+		res := summary()
+		assert.Equal(t, 3, res.Count)
+		assert.Equal(t, 4.67, res.Average)
+		assert.Equal(t, 4.5, res.Cleanliness)
+		assert.Equal(t, float64(5), res.Location)
+		assert.Equal(t, float64(0), res.Value)
+		assert.Equal(t, 2, res.FiveStar)
+		assert.Equal(t, 1, res.FourStar)
+	})
+
+	t.Run("Update Updates Summary", func(t *testing.T) {
+		ratingRepo.Update(model.Rating{TransactionID: 3, UserID: 3, Rating: 2})
+
+		res := summary()
+		assert.Equal(t, 3.67, res.Average)
+		assert.Equal(t, 1, res.FiveStar)
+		assert.Equal(t, 1, res.TwoStar)
+	})
+
+	t.Run("Delete Updates Summary", func(t *testing.T) {
+		ratingRepo.Delete(1, 1)
+		ratingRepo.Delete(2, 2)
+		ratingRepo.Delete(3, 3)
+
+		res := summary()
+		assert.Equal(t, 0, res.Count)
+		assert.Equal(t, float64(0), res.Average)
+		assert.Equal(t, 0, res.TwoStar)
+	})
+}
+
+func TestStayRating(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.GuestRating{})
+
+	userRepo = user.NewUserRepo(db)
+	featureRepo = feature.NewFeatureRepo(db)
+	houseRepo = house.NewHouseRepo(db)
+	ratingRepo = NewRatingRepository(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.GuestRating{})
+
+	seed.UserSeed(db)
+	seed.FeatureSeed(db)
+	seed.HouseSeed(db)
+
+	checkoutDate := time.Now().AddDate(0, 0, -1)
+	db.Create(&model.Transaction{UserID: 1, HouseID: 1, HostID: 2, CheckinDate: checkoutDate.AddDate(0, 0, -2), CheckoutDate: checkoutDate, Status: "PAID"})
+	db.Create(&model.Transaction{UserID: 1, HouseID: 1, HostID: 2, CheckinDate: checkoutDate.AddDate(0, 0, -9), CheckoutDate: checkoutDate.AddDate(0, 0, -7), Status: "PAID"})
+
+	t.Run("Get Transaction", func(t *testing.T) {
+		res, err := ratingRepo.GetTransaction(1)
+		assert.Nil(t, err)
+		assert.Equal(t, uint(2), res.HostID)
+	})
+
+	t.Run("Error Get Transaction", func(t *testing.T) {
+		_, err := ratingRepo.GetTransaction(100)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("One Review Per Stay", func(t *testing.T) {
+		_, err := ratingRepo.Create(model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 5})
+		assert.Nil(t, err)
+
+		_, err = ratingRepo.Create(model.Rating{TransactionID: 1, HouseID: 1, UserID: 1, Rating: 3})
+		assert.NotNil(t, err)
+
+		_, err = ratingRepo.Create(model.Rating{TransactionID: 2, HouseID: 1, UserID: 1, Rating: 3})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Rate Guest", func(t *testing.T) {
+		res, err := ratingRepo.SaveGuestRating(model.GuestRating{TransactionID: 1, HostID: 2, GuestID: 1, Rating: 5, Comment: "rapi"})
+		assert.Nil(t, err)
+		assert.Equal(t, 5, res.Rating)
+		assert.NotEqual(t, "", res.Host.Name)
+	})
+
+	t.Run("Rate Guest Again Replaces Review", func(t *testing.T) {
+		res, err := ratingRepo.SaveGuestRating(model.GuestRating{TransactionID: 1, HostID: 2, GuestID: 1, Rating: 3, Comment: "berisik"})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, res.Rating)
+		assert.Equal(t, "berisik", res.Comment)
+	})
+
+	t.Run("Get Guest Ratings", func(t *testing.T) {
+		ratingRepo.SaveGuestRating(model.GuestRating{TransactionID: 2, HostID: 2, GuestID: 1, Rating: 4})
+
+		res, result, err := ratingRepo.GetGuestRatings(1, pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, int64(2), result.Total)
+		assert.Equal(t, uint(2), res[0].TransactionID)
+	})
+}
@@ -4,21 +4,34 @@ import (
 	"time"
 
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 )
 
 type Transaction interface {
-	GetAll(userId int, status string) ([]model.Transaction, error)
-	GetAllHostTransaction(hostId int, status string) ([]model.Transaction, error)
+	GetAll(userId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error)
+	GetAllHostTransaction(hostId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error)
 	Get(userId int) (model.Transaction, error)
 	GetByInvoice(invId string) (model.Transaction, error)
 	GetByTransactionId(userId, trxId int) (model.Transaction, error)
+	GetByParticipant(userId, trxId int) (model.Transaction, error)
+	GetById(trxId int) (model.Transaction, error)
 	
 	GetHostId(houseId int) (int, error)
+	GetHouse(houseId int) (model.House, error)
+	GetUser(userId int) (model.User, error)
+	GetPendingBefore(before time.Time) ([]model.Transaction, error)
+	GetRequestedBefore(before time.Time) ([]model.Transaction, error)
+	GetCheckinBefore(before time.Time) ([]model.Transaction, error)
+	GetCheckoutBefore(before time.Time) ([]model.Transaction, error)
 	
-	IsHouseAvailable(houseId int, checkinDate, checkoutDate time.Time) (bool, error)
-	IsHouseAvailableReschedule(trxId, houseId int, checkinDate, checkoutDate time.Time) (bool, error)
 	
-	Create(model.Transaction) (model.Transaction, error)
+	CreateIfAvailable(model.Transaction) (model.Transaction, error)
+	Reschedule(trxId int, checkinDate, checkoutDate time.Time) (model.Transaction, error)
 
 	Update(invId string, transaction model.Transaction) (model.Transaction, error)
+	ExpirePending(invId string) (bool, error)
+	UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error)
+	ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error)
+
+	GetStatusHistory(trxId int) ([]model.TransactionStatusHistory, error)
 }
\ No newline at end of file
@@ -1,12 +1,18 @@
 package transaction
 
 import (
+	"errors"
 	"time"
 
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrHouseNotAvailable = errors.New("house already booked at the date")
+var ErrInvalidTransition = errors.New("transaction can't move to this status")
+
 type TransactionRepository struct {
 	db *gorm.DB
 }
@@ -15,30 +21,54 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (tr *TransactionRepository) GetAll(userId int, status string) ([]model.Transaction, error) {
+func (tr *TransactionRepository) GetAll(userId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
 	var transactions []model.Transaction
 
-	if err := tr.db.Preload("User").Preload("House").Where("status lIKE ?", "%"+status+"%").Find(&transactions, "user_id = ?", userId).Error; err != nil {
-		return nil, err
+	query := tr.db.Model(&model.Transaction{}).Where("status lIKE ?", "%"+status+"%").Where("user_id = ?", userId)
+	result, err := pagination.Find(query, page, &transactions, "User", "House")
+	if err != nil {
+		return nil, result, err
 	}
 
-	return transactions, nil
+	return transactions, result, nil
 }
 
-func (tr *TransactionRepository) GetAllHostTransaction(hostId int, status string) ([]model.Transaction, error) {
+func (tr *TransactionRepository) GetAllHostTransaction(hostId int, status string, page pagination.Page) ([]model.Transaction, pagination.Result, error) {
 	var transactions []model.Transaction
 
-	if err := tr.db.Preload("User").Preload("House").Where("status lIKE ?", "%"+status+"%").Find(&transactions, "host_id = ?", hostId).Error; err != nil {
-		return nil, err
+	query := tr.db.Model(&model.Transaction{}).Where("status lIKE ?", "%"+status+"%").Where("host_id = ?", hostId)
+	result, err := pagination.Find(query, page, &transactions, "User", "House")
+	if err != nil {
+		return nil, result, err
 	}
 
-	return transactions, nil
+	return transactions, result, nil
 }
 
 func (tr *TransactionRepository) GetByTransactionId(userId, trxId int) (model.Transaction, error) {
 	var transaction model.Transaction
 
-	if err := tr.db.Preload("User").Preload("House").Where("user_id = ?", userId).First(&transaction, trxId).Error; err != nil {
+	if err := tr.db.Preload("User").Preload("House").Preload("LineItems").Where("user_id = ?", userId).First(&transaction, trxId).Error; err != nil {
+		return transaction, err
+	}
+
+	return transaction, nil
+}
+
+func (tr *TransactionRepository) GetByParticipant(userId, trxId int) (model.Transaction, error) {
+	var transaction model.Transaction
+
+	if err := tr.db.Preload("User").Preload("House").Preload("LineItems").Where("user_id = ? OR host_id = ?", userId, userId).First(&transaction, trxId).Error; err != nil {
+		return transaction, err
+	}
+
+	return transaction, nil
+}
+
+func (tr *TransactionRepository) GetById(trxId int) (model.Transaction, error) {
+	var transaction model.Transaction
+
+	if err := tr.db.Preload("User").Preload("House").First(&transaction, trxId).Error; err != nil {
 		return transaction, err
 	}
 
@@ -55,28 +85,67 @@ func (tr *TransactionRepository) GetHostId(houseId int) (int, error) {
 	return int(house.UserID), nil
 }
 
-func (tr *TransactionRepository) IsHouseAvailable(houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
+func (tr *TransactionRepository) GetHouse(houseId int) (model.House, error) {
+	var house model.House
+
+	if err := tr.db.Preload("PricingRules").Where("suspended = ?", false).First(&house, houseId).Error; err != nil {
+		return house, err
+	}
+
+	return house, nil
+}
+
+func (tr *TransactionRepository) GetUser(userId int) (model.User, error) {
+	var user model.User
+
+	if err := tr.db.First(&user, userId).Error; err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (tr *TransactionRepository) GetPendingBefore(before time.Time) ([]model.Transaction, error) {
 	var transactions []model.Transaction
 
-	const CANCEL_PAYMENT_STATUS = "EXPIRED"
+	// approved requests get their payment window from the approval
+	if err := tr.db.Preload("House").Where("status = ? AND COALESCE(approved_at, created_at) < ?", model.PENDING_STATUS, before).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
 
-	if err := tr.db.Where("checkout_date > ? AND checkin_date < ? AND status <> ?", checkinDate, checkoutDate, CANCEL_PAYMENT_STATUS).First(&transactions, "house_id = ?", houseId).Error; err != nil {
-		return true, err
+func (tr *TransactionRepository) GetRequestedBefore(before time.Time) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+
+	if err := tr.db.Preload("House").Where("status = ? AND respond_by < ?", model.REQUESTED_STATUS, before).Find(&transactions).Error; err != nil {
+		return nil, err
 	}
 
-	return false, nil
+	return transactions, nil
 }
 
-func (tr *TransactionRepository) IsHouseAvailableReschedule(trxId, houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
+// GetCheckinBefore returns the paid bookings whose checkin date has come.
+func (tr *TransactionRepository) GetCheckinBefore(before time.Time) ([]model.Transaction, error) {
 	var transactions []model.Transaction
 
-	const CANCEL_PAYMENT_STATUS = "EXPIRED"
+	if err := tr.db.Where("status = ? AND checkin_date < ?", model.PAID_STATUS, before).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// GetCheckoutBefore returns the checked in stays whose checkout date has passed.
+func (tr *TransactionRepository) GetCheckoutBefore(before time.Time) ([]model.Transaction, error) {
+	var transactions []model.Transaction
 
-	if err := tr.db.Where("checkout_date > ? AND checkin_date < ? AND status <> ? AND id <> ?", checkinDate, checkoutDate, CANCEL_PAYMENT_STATUS, trxId).First(&transactions, "house_id = ?", houseId).Error; err != nil {
-		return true, err
+	if err := tr.db.Where("status = ? AND checkout_date < ?", model.CHECKED_IN_STATUS, before).Find(&transactions).Error; err != nil {
+		return nil, err
 	}
 
-	return false, nil
+	return transactions, nil
 }
 
 func (tr *TransactionRepository) Get(userId int) (model.Transaction, error) {
@@ -99,20 +168,90 @@ func (tr *TransactionRepository) GetByInvoice(invId string) (model.Transaction,
 	return transaction, nil
 }
 
-func (tr *TransactionRepository) Create(transaction model.Transaction) (model.Transaction, error) {
-	if err := tr.db.Create(&transaction).Error; err != nil {
+// CreateIfAvailable checks the dates and inserts the booking inside one
+// database transaction. The house row is locked with SELECT ... FOR UPDATE so
+// concurrent bookings for the same house are serialised and only one of two
+// overlapping requests can win. Line items on the transaction are inserted
+// together with it.
+func (tr *TransactionRepository) CreateIfAvailable(transaction model.Transaction) (model.Transaction, error) {
+	err := tr.db.Transaction(func(tx *gorm.DB) error {
+		var house model.House
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&house, transaction.HouseID).Error; err != nil {
+			return err
+		}
+
+		var overlap int64
+		if err := tx.Model(&model.Transaction{}).Where("house_id = ? AND checkout_date > ? AND checkin_date < ? AND status NOT IN ?", transaction.HouseID, transaction.CheckinDate, transaction.CheckoutDate, model.RELEASED_STATUSES).Count(&overlap).Error; err != nil {
+			return err
+		}
+
+		blocked, err := isHouseBlocked(tx, int(transaction.HouseID), transaction.CheckinDate, transaction.CheckoutDate)
+		if err != nil {
+			return err
+		}
+
+		if overlap > 0 || blocked {
+			return ErrHouseNotAvailable
+		}
+
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(newStatusHistory(transaction, "", int(transaction.UserID), "")).Error
+	})
+	if err != nil {
 		return transaction, err
 	}
 
 	var t model.Transaction
 
-	if err := tr.db.Preload("User").Preload("House").First(&t, &transaction.ID).Error; err != nil {
+	if err := tr.db.Preload("User").Preload("House").Preload("LineItems").First(&t, &transaction.ID).Error; err != nil {
 		return transaction, err
 	}
 
 	return t, nil
 }
 
+// Reschedule moves a booking to new dates. Like CreateIfAvailable it locks the
+// house row while the dates are checked, so a booking for the same dates can't
+// slip in between the check and the update.
+func (tr *TransactionRepository) Reschedule(trxId int, checkinDate, checkoutDate time.Time) (model.Transaction, error) {
+	var transaction model.Transaction
+
+	err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "house_id").First(&transaction, trxId).Error; err != nil {
+			return err
+		}
+
+		var house model.House
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&house, transaction.HouseID).Error; err != nil {
+			return err
+		}
+
+		var overlap int64
+		if err := tx.Model(&model.Transaction{}).Where("house_id = ? AND checkout_date > ? AND checkin_date < ? AND status NOT IN ? AND id <> ?", transaction.HouseID, checkinDate, checkoutDate, model.RELEASED_STATUSES, trxId).Count(&overlap).Error; err != nil {
+			return err
+		}
+
+		blocked, err := isHouseBlocked(tx, int(transaction.HouseID), checkinDate, checkoutDate)
+		if err != nil {
+			return err
+		}
+
+		if overlap > 0 || blocked {
+			return ErrHouseNotAvailable
+		}
+
+		return tx.Model(&transaction).Updates(model.Transaction{CheckinDate: checkinDate, CheckoutDate: checkoutDate}).Error
+	})
+	if err != nil {
+		return transaction, err
+	}
+
+	return tr.GetById(trxId)
+}
+
 func (tr *TransactionRepository) Update(invId string, transaction model.Transaction) (model.Transaction, error) {
 	var t model.Transaction
 
@@ -120,7 +259,128 @@ func (tr *TransactionRepository) Update(invId string, transaction model.Transact
 		return t, err
 	}
 
-	tr.db.Model(&t).Updates(transaction)
+	// the status only moves through UpdateStatus, so it is never skipped in
+	// the history
+	tr.db.Model(&t).Omit("status").Updates(transaction)
 
 	return t, nil
-}
\ No newline at end of file
+}
+
+// ForceStatus moves a transaction to any status on behalf of an admin and
+// stores the reason next to it.
+func (tr *TransactionRepository) ForceStatus(trxId, adminId int, status model.TransactionStatus, note string) (model.Transaction, error) {
+	var transaction model.Transaction
+
+	err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&transaction, trxId).Error; err != nil {
+			return err
+		}
+
+		auditNote := model.TransactionAuditNote{
+			TransactionID: transaction.ID,
+			AdminID:       uint(adminId),
+			FromStatus:    transaction.Status,
+			ToStatus:      status,
+			Note:          note,
+		}
+
+		history := newStatusHistory(transaction, transaction.Status, adminId, note)
+		history.ToStatus = status
+
+		if err := tx.Model(&transaction).Update("status", status).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&auditNote).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(history).Error
+	})
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction.Status = status
+
+	return transaction, nil
+}
+
+func (tr *TransactionRepository) ExpirePending(invId string) (bool, error) {
+	var transaction model.Transaction
+
+	if err := tr.db.Select("id").First(&transaction, "invoice_id = ?", invId).Error; err != nil {
+		return false, err
+	}
+
+	// only flip rows that are still pending so a late PAID callback wins
+	return tr.UpdateStatus(int(transaction.ID), model.PENDING_STATUS, model.Transaction{Status: model.EXPIRED_STATUS}, 0, "")
+}
+
+// UpdateStatus moves a transaction from one status to the status set on
+// transaction, together with its other fields, and records the change in the
+// status history. Only a row that is still in from changes, so it reports
+// false when someone else moved the transaction first. changedBy is 0 for
+// changes made by the system.
+func (tr *TransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error) {
+	if !from.CanTransitionTo(transaction.Status) {
+		return false, ErrInvalidTransition
+	}
+
+	updated := false
+
+	err := tr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Transaction{}).Where("id = ? AND status = ?", trxId, from).Updates(transaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		updated = true
+		transaction.ID = uint(trxId)
+
+		return tx.Create(newStatusHistory(transaction, from, changedBy, note)).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated, nil
+}
+
+func (tr *TransactionRepository) GetStatusHistory(trxId int) ([]model.TransactionStatusHistory, error) {
+	var history []model.TransactionStatusHistory
+
+	if err := tr.db.Where("transaction_id = ?", trxId).Order("id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func newStatusHistory(transaction model.Transaction, from model.TransactionStatus, changedBy int, note string) *model.TransactionStatusHistory {
+	history := model.TransactionStatusHistory{
+		TransactionID: transaction.ID,
+		FromStatus:    from,
+		ToStatus:      transaction.Status,
+		Note:          note,
+	}
+
+	if changedBy != 0 {
+		userId := uint(changedBy)
+		history.ChangedBy = &userId
+	}
+
+	return &history
+}
+
+// isHouseBlocked reports whether the host blocked any night between checkin
+// and checkout.
+func isHouseBlocked(db *gorm.DB, houseId int, checkinDate, checkoutDate time.Time) (bool, error) {
+	var blocked int64
+
+	if err := db.Model(&model.HouseBlockedDate{}).Where("house_id = ? AND start_date < ? AND end_date >= ?", houseId, checkoutDate, checkinDate).Count(&blocked).Error; err != nil {
+		return false, err
+	}
+
+	return blocked > 0, nil
+}
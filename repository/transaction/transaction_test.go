@@ -1,11 +1,14 @@
 package transaction
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/furqonzt99/airbnb/config"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"github.com/furqonzt99/airbnb/repository/house"
 	"github.com/furqonzt99/airbnb/repository/user"
 	"github.com/furqonzt99/airbnb/seed"
@@ -36,6 +39,11 @@ func TestMain(m *testing.M) {
 	db.Migrator().DropTable(&model.HouseHasFeatures{})
 	db.Migrator().DropTable(&model.Transaction{})
 	db.Migrator().DropTable(&model.Rating{})
+	db.Migrator().DropTable(&model.HouseBlockedDate{})
+	db.Migrator().DropTable(&model.HousePricingRule{})
+	db.Migrator().DropTable(&model.TransactionLineItem{})
+	db.Migrator().DropTable(&model.TransactionAuditNote{})
+	db.Migrator().DropTable(&model.TransactionStatusHistory{})
 
 	userRepo = user.NewUserRepo(db)
 	houseRepo = house.NewHouseRepo(db)
@@ -47,6 +55,11 @@ func TestMain(m *testing.M) {
 	db.AutoMigrate(&model.HouseHasFeatures{})
 	db.AutoMigrate(&model.Transaction{})
 	db.AutoMigrate(&model.Rating{})
+	db.AutoMigrate(&model.HouseBlockedDate{})
+	db.AutoMigrate(&model.HousePricingRule{})
+	db.AutoMigrate(&model.TransactionLineItem{})
+	db.AutoMigrate(&model.TransactionAuditNote{})
+	db.AutoMigrate(&model.TransactionStatusHistory{})
 
 	seed.UserSeed(db)
 	seed.FeatureSeed(db)
@@ -81,12 +94,12 @@ func TestBooking(t *testing.T)  {
 			Status: "PAID",
 		}
 
-		res, err := transactionRepo.Create(mockTransaction)
+		res, err := transactionRepo.CreateIfAvailable(mockTransaction)
 		assert.Nil(t, err)
 		assert.Equal(t, 1, int(res.UserID))
 		assert.Equal(t, 4, int(res.HouseID))
 		assert.Equal(t, 2, int(res.HostID))
-		assert.Equal(t, "PAID", res.Status)
+		assert.Equal(t, model.PAID_STATUS, res.Status)
 	})
 	
 	t.Run("Success Booking 2", func(t *testing.T) {
@@ -106,12 +119,12 @@ func TestBooking(t *testing.T)  {
 			Status: "PAID",
 		}
 
-		res, err := transactionRepo.Create(mockTransaction)
+		res, err := transactionRepo.CreateIfAvailable(mockTransaction)
 		assert.Nil(t, err)
 		assert.Equal(t, 5, int(res.UserID))
 		assert.Equal(t, 4, int(res.HouseID))
 		assert.Equal(t, 2, int(res.HostID))
-		assert.Equal(t, "PAID", res.Status)
+		assert.Equal(t, model.PAID_STATUS, res.Status)
 	})
 	
 	t.Run("Failed Booking", func(t *testing.T) {
@@ -127,7 +140,7 @@ func TestBooking(t *testing.T)  {
 			TotalPrice:     300000,
 		}
 
-		_, err := transactionRepo.Create(mockTransaction)
+		_, err := transactionRepo.CreateIfAvailable(mockTransaction)
 		assert.NotNil(t, err)
 	})
 }
@@ -135,12 +148,12 @@ func TestBooking(t *testing.T)  {
 func TestGet(t *testing.T)  {
 	
 	t.Run("Success Get All", func(t *testing.T) {
-		_, err := transactionRepo.GetAll(1, "")
+		_, _, err := transactionRepo.GetAll(1, "", pagination.Page{Limit: 10})
 		assert.Nil(t, err)
 	})
 	
 	t.Run("Success Get All Host Trx", func(t *testing.T) {
-		_, err := transactionRepo.GetAllHostTransaction(2, "")
+		_, _, err := transactionRepo.GetAllHostTransaction(2, "", pagination.Page{Limit: 10})
 		assert.Nil(t, err)
 	})
 	
@@ -154,6 +167,16 @@ func TestGet(t *testing.T)  {
 		assert.NotNil(t, err)
 	})
 	
+	t.Run("Success Get By Participant", func(t *testing.T) {
+		_, err := transactionRepo.GetByParticipant(2, 1)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Failed Get By Participant", func(t *testing.T) {
+		_, err := transactionRepo.GetByParticipant(4, 1)
+		assert.NotNil(t, err)
+	})
+	
 	t.Run("Success Get By Inv ID", func(t *testing.T) {
 		_, err := transactionRepo.GetByInvoice("US89IYSD9DAHA")
 		assert.Nil(t, err)
@@ -176,30 +199,24 @@ func TestGet(t *testing.T)  {
 
 }
 
-func TestIsAvailable(t *testing.T)  {
+func TestReschedule(t *testing.T)  {
 
-	t.Run("Failed Is Available", func(t *testing.T) {
-		res, err := transactionRepo.IsHouseAvailable(4, checkinDate.AddDate(0, 0, 10), checkoutDate.AddDate(0, 0, 12))
-		assert.NotNil(t, err)
-		assert.Equal(t, true, res)
+	transaction, _ := transactionRepo.GetByInvoice("US89IYSD9DAHA")
+
+	t.Run("Failed Reschedule Booked", func(t *testing.T) {
+		_, err := transactionRepo.Reschedule(int(transaction.ID), checkinDate.AddDate(0, 0, 3), checkoutDate.AddDate(0, 0, 3))
+		assert.Equal(t, ErrHouseNotAvailable, err)
 	})
-	
-	t.Run("Success Is Available", func(t *testing.T) {
-		res, err := transactionRepo.IsHouseAvailable(4, checkinDate.AddDate(0, 0, 1), checkoutDate)
+
+	t.Run("Success Reschedule", func(t *testing.T) {
+		res, err := transactionRepo.Reschedule(int(transaction.ID), checkinDate.AddDate(0, 0, 13), checkoutDate.AddDate(0, 0, 13))
 		assert.Nil(t, err)
-		assert.Equal(t, false, res)
+		assert.Equal(t, checkinDate.AddDate(0, 0, 13).Format("2006-01-02"), res.CheckinDate.Format("2006-01-02"))
 	})
 
-	t.Run("Failed Is Available Reschedule", func(t *testing.T) {
-		res, err := transactionRepo.IsHouseAvailableReschedule(1, 4, checkinDate.AddDate(0, 0, 13), checkoutDate.AddDate(0, 0, 15))
+	t.Run("Failed Reschedule Not Found", func(t *testing.T) {
+		_, err := transactionRepo.Reschedule(1000, checkinDate.AddDate(0, 0, 40), checkoutDate.AddDate(0, 0, 40))
 		assert.NotNil(t, err)
-		assert.Equal(t, true, res)
-	})
-	
-	t.Run("Success Is Available Reschedule", func(t *testing.T) {
-		res, err := transactionRepo.IsHouseAvailableReschedule(1, 4, time.Now().AddDate(0, 0, 1), time.Now().AddDate(0, 0, 2))
-		assert.Nil(t, err)
-		assert.Equal(t, false, res)
 	})
 	
 }
@@ -218,6 +235,20 @@ func TestGetHostId(t *testing.T)  {
 	
 }
 
+func TestGetHouse(t *testing.T)  {
+
+	t.Run("Success Get House", func(t *testing.T) {
+		res, err := transactionRepo.GetHouse(2)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, int(res.ID))
+	})
+
+	t.Run("Failed Get House", func(t *testing.T) {
+		_, err := transactionRepo.GetHouse(26)
+		assert.NotNil(t, err)
+	})
+}
+
 func TestUpdate(t *testing.T)  {
 	
 	t.Run("Success Update 1", func(t *testing.T) {
@@ -231,19 +262,19 @@ func TestUpdate(t *testing.T)  {
 			PaymentChannel: "",
 			PaymentMethod:  "",
 			PaidAt:         time.Now(),
-			CheckinDate:    checkinDate,
-			CheckoutDate:   checkoutDate,
+			CheckinDate:    checkinDate.AddDate(0, 0, 40),
+			CheckoutDate:   checkoutDate.AddDate(0, 0, 40),
 			TotalPrice:     450000,
 			Status: "PAID",
 		}
 
-		res, err := transactionRepo.Create(mockTransaction)
+		res, err := transactionRepo.CreateIfAvailable(mockTransaction)
 		assert.Nil(t, err)
 		assert.Equal(t, 1, int(res.UserID))
 		assert.Equal(t, 4, int(res.HouseID))
 		assert.Equal(t, 2, int(res.HostID))
 		assert.Equal(t, float64(450000), res.TotalPrice)
-		assert.Equal(t, "PAID", res.Status)
+		assert.Equal(t, model.PAID_STATUS, res.Status)
 	})
 	
 	t.Run("Success Booking 2", func(t *testing.T) {
@@ -269,7 +300,7 @@ func TestUpdate(t *testing.T)  {
 		assert.Equal(t, 4, int(res.HouseID))
 		assert.Equal(t, 2, int(res.HostID))
 		assert.Equal(t, "urlnih", res.PaymentUrl)
-		assert.Equal(t, "PAID", res.Status)
+		assert.Equal(t, model.PAID_STATUS, res.Status)
 	})
 	
 	t.Run("Failed Booking", func(t *testing.T) {
@@ -288,4 +319,240 @@ func TestUpdate(t *testing.T)  {
 		_, err := transactionRepo.Update("US89IYSD9DAHV", mockTransaction)
 		assert.NotNil(t, err)
 	})
-}
\ No newline at end of file
+}
+
+func TestExpirePending(t *testing.T)  {
+
+	t.Run("Success Get Pending Before", func(t *testing.T) {
+		mockTransaction := model.Transaction{
+			UserID:         1,
+			HouseID:        5,
+			HostID:         2,
+			InvoiceID:      "US89IYSD9DAHP",
+			CheckinDate:    checkinDate,
+			CheckoutDate:   checkoutDate,
+			TotalPrice:     300000,
+			Status: "PENDING",
+		}
+		transactionRepo.CreateIfAvailable(mockTransaction)
+
+		res, err := transactionRepo.GetPendingBefore(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, true, len(res) > 0)
+	})
+
+	t.Run("Success Expire Pending", func(t *testing.T) {
+		res, err := transactionRepo.ExpirePending("US89IYSD9DAHP")
+		assert.Nil(t, err)
+		assert.Equal(t, true, res)
+	})
+
+	t.Run("Failed Expire Not Pending", func(t *testing.T) {
+		res, err := transactionRepo.ExpirePending("US89IYSD9DAHP")
+		assert.Nil(t, err)
+		assert.Equal(t, false, res)
+	})
+}
+
+func TestUpdateStatus(t *testing.T)  {
+
+	var trxId int
+
+	t.Run("Success Get Requested Before", func(t *testing.T) {
+		res, _ := transactionRepo.CreateIfAvailable(model.Transaction{
+			UserID:         1,
+			HouseID:        5,
+			HostID:         2,
+			InvoiceID:      "REQUESTEDINV01",
+			CheckinDate:    checkinDate.AddDate(0, 0, 60),
+			CheckoutDate:   checkoutDate.AddDate(0, 0, 60),
+			TotalPrice:     300000,
+			Status:         "REQUESTED",
+			RespondBy:      time.Now().Add(-time.Minute),
+		})
+		trxId = int(res.ID)
+
+		requested, err := transactionRepo.GetRequestedBefore(time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(requested))
+		assert.Equal(t, uint(trxId), requested[0].ID)
+	})
+
+	t.Run("Success Update Status", func(t *testing.T) {
+		res, err := transactionRepo.UpdateStatus(trxId, model.REQUESTED_STATUS, model.Transaction{Status: model.DECLINED_STATUS, DeclineReason: "Not available"}, 2, "Not available")
+		assert.Nil(t, err)
+		assert.Equal(t, true, res)
+	})
+
+	t.Run("Failed Update Status Moved Already", func(t *testing.T) {
+		res, err := transactionRepo.UpdateStatus(trxId, model.REQUESTED_STATUS, model.Transaction{Status: model.PENDING_STATUS}, 2, "")
+		assert.Nil(t, err)
+		assert.Equal(t, false, res)
+	})
+
+	t.Run("Failed Update Status Invalid Transition", func(t *testing.T) {
+		_, err := transactionRepo.UpdateStatus(trxId, model.DECLINED_STATUS, model.Transaction{Status: model.PAID_STATUS}, 2, "")
+		assert.Equal(t, ErrInvalidTransition, err)
+	})
+
+	t.Run("Success Get Status History", func(t *testing.T) {
+		res, err := transactionRepo.GetStatusHistory(trxId)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, model.TransactionStatus(""), res[0].FromStatus)
+		assert.Equal(t, model.REQUESTED_STATUS, res[0].ToStatus)
+		assert.Equal(t, uint(1), *res[0].ChangedBy)
+		assert.Equal(t, model.REQUESTED_STATUS, res[1].FromStatus)
+		assert.Equal(t, model.DECLINED_STATUS, res[1].ToStatus)
+		assert.Equal(t, "Not available", res[1].Note)
+	})
+}
+
+func TestGetStaysDue(t *testing.T)  {
+
+	t.Run("Success Get Checkin Before", func(t *testing.T) {
+		res, err := transactionRepo.GetCheckinBefore(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, true, len(res) > 0)
+		for _, transaction := range res {
+			assert.Equal(t, model.PAID_STATUS, transaction.Status)
+		}
+	})
+
+	t.Run("Success Get Checkout Before", func(t *testing.T) {
+		// seeded stays may hold the past dates, so this skips the availability check
+		transaction := model.Transaction{
+			UserID:         1,
+			HouseID:        8,
+			HostID:         2,
+			InvoiceID:      "CHECKEDIN01",
+			CheckinDate:    checkinDate.AddDate(0, 0, -3),
+			CheckoutDate:   checkinDate.AddDate(0, 0, -1),
+			Status:         "CHECKED_IN",
+		}
+		db.Create(&transaction)
+
+		checkedIn, err := transactionRepo.GetCheckoutBefore(time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(checkedIn))
+		assert.Equal(t, transaction.ID, checkedIn[0].ID)
+	})
+}
+
+func TestCreateIfAvailable(t *testing.T)  {
+
+	t.Run("Only One Concurrent Booking Wins", func(t *testing.T) {
+		const REQUESTS = 10
+
+		var wg sync.WaitGroup
+		results := make(chan error, REQUESTS)
+
+		for i := 0; i < REQUESTS; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				_, err := transactionRepo.CreateIfAvailable(model.Transaction{
+					UserID:       1,
+					HouseID:      6,
+					HostID:       2,
+					InvoiceID:    fmt.Sprint("CONCURRENT", i),
+					CheckinDate:  checkinDate.AddDate(0, 0, 30),
+					CheckoutDate: checkoutDate.AddDate(0, 0, 30),
+				})
+				results <- err
+			}(i)
+		}
+
+		wg.Wait()
+		close(results)
+
+		success := 0
+		for err := range results {
+			if err == nil {
+				success++
+			} else {
+				assert.Equal(t, ErrHouseNotAvailable, err)
+			}
+		}
+
+		assert.Equal(t, 1, success)
+	})
+
+	t.Run("Success Create With Line Items", func(t *testing.T) {
+		res, err := transactionRepo.CreateIfAvailable(model.Transaction{
+			UserID:       1,
+			HouseID:      6,
+			HostID:       2,
+			InvoiceID:    "LINEITEMS",
+			CheckinDate:  checkinDate.AddDate(0, 0, 60),
+			CheckoutDate: checkoutDate.AddDate(0, 0, 60),
+			TotalPrice:   350000,
+			LineItems: []model.TransactionLineItem{
+				{Type: "night", Name: "House 6", UnitPrice: 150000, Quantity: 2, Amount: 300000},
+				{Type: "cleaning_fee", Name: "Cleaning fee", UnitPrice: 50000, Quantity: 1, Amount: 50000},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res.LineItems))
+	})
+
+	t.Run("Failed Blocked By Host", func(t *testing.T) {
+		db.Create(&model.HouseBlockedDate{
+			HouseID:   7,
+			StartDate: checkinDate.AddDate(0, 0, 20),
+			EndDate:   checkinDate.AddDate(0, 0, 21),
+		})
+
+		_, err := transactionRepo.CreateIfAvailable(model.Transaction{
+			UserID:       1,
+			HouseID:      7,
+			HostID:       2,
+			InvoiceID:    "BLOCKED",
+			CheckinDate:  checkinDate.AddDate(0, 0, 19),
+			CheckoutDate: checkinDate.AddDate(0, 0, 22),
+		})
+		assert.Equal(t, ErrHouseNotAvailable, err)
+	})
+
+	t.Run("Failed House Not Found", func(t *testing.T) {
+		_, err := transactionRepo.CreateIfAvailable(model.Transaction{
+			UserID:       1,
+			HouseID:      100,
+			HostID:       2,
+			InvoiceID:    "CONCURRENT100",
+			CheckinDate:  checkinDate,
+			CheckoutDate: checkoutDate,
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestForceStatus(t *testing.T)  {
+
+	transaction, _ := transactionRepo.CreateIfAvailable(model.Transaction{
+		UserID:       1,
+		HouseID:      7,
+		HostID:       2,
+		InvoiceID:    "FORCESTATUS",
+		CheckinDate:  checkinDate.AddDate(0, 0, 90),
+		CheckoutDate: checkoutDate.AddDate(0, 0, 90),
+		Status:       "PENDING",
+	})
+
+	t.Run("Success Force Status", func(t *testing.T) {
+		res, err := transactionRepo.ForceStatus(int(transaction.ID), 1, "PAID", "paid by bank transfer")
+		assert.Nil(t, err)
+		assert.Equal(t, model.PAID_STATUS, res.Status)
+
+		var auditNote model.TransactionAuditNote
+		db.First(&auditNote, "transaction_id = ?", transaction.ID)
+		assert.Equal(t, model.PENDING_STATUS, auditNote.FromStatus)
+		assert.Equal(t, model.PAID_STATUS, auditNote.ToStatus)
+	})
+
+	t.Run("Failed Force Status Not Found", func(t *testing.T) {
+		_, err := transactionRepo.ForceStatus(1000, 1, "PAID", "paid by bank transfer")
+		assert.NotNil(t, err)
+	})
+}
@@ -1,6 +1,9 @@
 package user
 
-import "github.com/furqonzt99/airbnb/model"
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
 
 type UserInterface interface {
 	Register(newUser model.User) (model.User, error)
@@ -8,4 +11,15 @@ type UserInterface interface {
 	Get(userId int) (model.User, error)
 	Update(newUser model.User, userId int) (model.User, error)
 	Delete(userId int) (model.User, error)
+	GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error)
+	SetSuspended(userId int, suspended bool) (model.User, error)
+	CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error)
+	GetRefreshToken(tokenHash string) (model.RefreshToken, error)
+	RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error)
+	RevokeRefreshToken(userId, refreshTokenId int) error
+	RevokeAllRefreshTokens(userId int) error
+	IsSessionActive(userId, refreshTokenId int) bool
+	CreateUserToken(userToken model.UserToken) (model.UserToken, error)
+	VerifyEmail(tokenHash string) (model.User, error)
+	ResetPassword(tokenHash, password string) (model.User, error)
 }
@@ -1,10 +1,18 @@
 package user
 
 import (
+	"errors"
+	"time"
+
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"gorm.io/gorm"
 )
 
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+var ErrUserTokenInvalid = errors.New("token is invalid, expired or already used")
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -43,7 +51,18 @@ func (ur *UserRepository) Update(newUser model.User, userId int) (model.User, er
 	if err := ur.db.First(&user, "id=?", userId).Error; err != nil {
 		return newUser, err
 	}
+
+	// a new email has to be verified again, Updates would skip the false
+	if newUser.Email != "" && newUser.Email != user.Email {
+		if err := ur.db.Model(&user).Update("email_verified", false).Error; err != nil {
+			return newUser, err
+		}
+	} else {
+		newUser.EmailVerified = user.EmailVerified
+	}
+
 	ur.db.Model(&user).Updates(newUser)
+	newUser.ID = user.ID
 	return newUser, nil
 }
 
@@ -55,3 +74,155 @@ func (ur *UserRepository) Delete(userId int) (model.User, error) {
 	ur.db.Delete(&user)
 	return user, nil
 }
+
+func (ur *UserRepository) GetAllAdmin(search string, page pagination.Page) ([]model.User, pagination.Result, error) {
+	users := []model.User{}
+	result, err := pagination.Find(ur.db.Model(&model.User{}).Where("name LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%"), page, &users)
+	return users, result, err
+}
+
+func (ur *UserRepository) SetSuspended(userId int, suspended bool) (model.User, error) {
+	user := model.User{}
+	if err := ur.db.First(&user, "id=?", userId).Error; err != nil {
+		return user, err
+	}
+	ur.db.Model(&user).Update("suspended", suspended)
+	user.Suspended = suspended
+	return user, nil
+}
+
+func (ur *UserRepository) CreateRefreshToken(refreshToken model.RefreshToken) (model.RefreshToken, error) {
+	if err := ur.db.Create(&refreshToken).Error; err != nil {
+		return refreshToken, err
+	}
+	return refreshToken, nil
+}
+
+func (ur *UserRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	refreshToken := model.RefreshToken{}
+	if err := ur.db.First(&refreshToken, "token_hash = ?", tokenHash).Error; err != nil {
+		return refreshToken, err
+	}
+	return refreshToken, nil
+}
+
+// RotateRefreshToken revokes prev and stores next in one transaction. The
+// revoke only matches a row that is still active, so when two requests race
+// with the same refresh token only one of them gets a new one.
+func (ur *UserRepository) RotateRefreshToken(prev, next model.RefreshToken) (model.RefreshToken, error) {
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.RefreshToken{}).Where("id = ? AND revoked_at IS NULL", prev.ID).Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		return tx.Create(&next).Error
+	})
+	if err != nil {
+		return next, err
+	}
+	return next, nil
+}
+
+func (ur *UserRepository) RevokeRefreshToken(userId, refreshTokenId int) error {
+	return ur.db.Model(&model.RefreshToken{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", refreshTokenId, userId).Update("revoked_at", time.Now()).Error
+}
+
+func (ur *UserRepository) RevokeAllRefreshTokens(userId int) error {
+	return ur.db.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userId).Update("revoked_at", time.Now()).Error
+}
+
+func (ur *UserRepository) IsSessionActive(userId, refreshTokenId int) bool {
+	var active int64
+	ur.db.Model(&model.RefreshToken{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", refreshTokenId, userId, time.Now()).Count(&active)
+	return active > 0
+}
+
+// CreateUserToken stores a new mailed token and retires any unused token with
+// the same purpose, so only the latest email works.
+func (ur *UserRepository) CreateUserToken(userToken model.UserToken) (model.UserToken, error) {
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.UserToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", userToken.UserID, userToken.Purpose).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&userToken).Error
+	})
+	if err != nil {
+		return userToken, err
+	}
+	return userToken, nil
+}
+
+func (ur *UserRepository) VerifyEmail(tokenHash string) (model.User, error) {
+	user := model.User{}
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := useUserToken(tx, constant.EMAIL_VERIFICATION_TOKEN, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, userToken.UserID).Error; err != nil {
+			return err
+		}
+
+		user.EmailVerified = true
+		return tx.Model(&user).Update("email_verified", true).Error
+	})
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// ResetPassword sets the new password hash and logs out every session. The
+// reset link reached the user's mailbox, so it also counts as verifying it.
+func (ur *UserRepository) ResetPassword(tokenHash, password string) (model.User, error) {
+	user := model.User{}
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := useUserToken(tx, constant.PASSWORD_RESET_TOKEN, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, userToken.UserID).Error; err != nil {
+			return err
+		}
+
+		user.Password = password
+		user.EmailVerified = true
+		if err := tx.Model(&user).Updates(map[string]interface{}{"password": password, "email_verified": true}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// useUserToken marks the token used. The update only matches an unused,
+// unexpired row so a token clicked twice at once is only honoured once.
+func useUserToken(tx *gorm.DB, purpose, tokenHash string) (model.UserToken, error) {
+	userToken := model.UserToken{}
+	if err := tx.First(&userToken, "token_hash = ? AND purpose = ?", tokenHash, purpose).Error; err != nil {
+		return userToken, ErrUserTokenInvalid
+	}
+
+	result := tx.Model(&model.UserToken{}).Where("id = ? AND used_at IS NULL AND expires_at > ?", userToken.ID, time.Now()).Update("used_at", time.Now())
+	if result.Error != nil {
+		return userToken, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return userToken, ErrUserTokenInvalid
+	}
+
+	return userToken, nil
+}
@@ -2,9 +2,12 @@ package user
 
 import (
 	"testing"
+	"time"
 
 	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
 	"github.com/furqonzt99/airbnb/seed"
 	"github.com/furqonzt99/airbnb/util"
 	"github.com/stretchr/testify/assert"
@@ -173,6 +176,22 @@ func TestUpdateUser(t *testing.T) {
 		assert.Equal(t, mockUser.Email, res.Email)
 		assert.Equal(t, mockUser.Password, res.Password)
 		assert.Equal(t, mockUser.Name, res.Name)
+
+		// the seeded user was verified with its old email
+		user, _ := userRepo.Get(userId)
+		assert.False(t, res.EmailVerified)
+		assert.False(t, user.EmailVerified)
+	})
+
+	t.Run("Update User Same Email Stays Verified", func(t *testing.T) {
+		db.Model(&model.User{}).Where("id = ?", 1).Update("email_verified", true)
+
+		res, err := userRepo.Update(model.User{Email: "test@gmail.com", Name: "tester3"}, 1)
+		assert.Nil(t, err)
+		assert.True(t, res.EmailVerified)
+
+		user, _ := userRepo.Get(1)
+		assert.True(t, user.EmailVerified)
 	})
 
 	t.Run("Error Update User No ID", func(t *testing.T) {
@@ -221,3 +240,183 @@ func TestDeleteUser(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestAdminUser(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Feature{})
+	db.Migrator().DropTable(&model.HouseHasFeatures{})
+	db.Migrator().DropTable(&model.Transaction{})
+	db.Migrator().DropTable(&model.Rating{})
+
+	userRepo = NewUserRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Feature{})
+	db.AutoMigrate(&model.HouseHasFeatures{})
+	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Rating{})
+
+	seed.UserSeed(db)
+
+	t.Run("Get All Users", func(t *testing.T) {
+		res, _, err := userRepo.GetAllAdmin("user", pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 5, len(res))
+	})
+
+	t.Run("Search Users", func(t *testing.T) {
+		res, _, err := userRepo.GetAllAdmin("user2@", pagination.Page{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res))
+	})
+
+	t.Run("Suspend User", func(t *testing.T) {
+		res, err := userRepo.SetSuspended(2, true)
+		assert.Nil(t, err)
+		assert.Equal(t, true, res.Suspended)
+	})
+
+	t.Run("Error Suspend User No ID", func(t *testing.T) {
+		_, err := userRepo.SetSuspended(100, true)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.RefreshToken{})
+
+	userRepo = NewUserRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.RefreshToken{})
+
+	seed.UserSeed(db)
+
+	var session model.RefreshToken
+
+	t.Run("Create Refresh Token", func(t *testing.T) {
+		res, err := userRepo.CreateRefreshToken(model.RefreshToken{
+			UserID:    1,
+			TokenHash: "hash-1",
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, int(res.ID))
+		assert.Equal(t, true, userRepo.IsSessionActive(1, 1))
+		session = res
+	})
+
+	t.Run("Get Refresh Token", func(t *testing.T) {
+		res, err := userRepo.GetRefreshToken("hash-1")
+		assert.Nil(t, err)
+		assert.Equal(t, true, res.RevokedAt.IsZero())
+	})
+
+	t.Run("Rotate Refresh Token", func(t *testing.T) {
+		res, err := userRepo.RotateRefreshToken(session, model.RefreshToken{
+			UserID:    1,
+			TokenHash: "hash-2",
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, int(res.ID))
+		assert.Equal(t, false, userRepo.IsSessionActive(1, 1))
+		assert.Equal(t, true, userRepo.IsSessionActive(1, 2))
+	})
+
+	t.Run("Error Rotate Refresh Token Reused", func(t *testing.T) {
+		_, err := userRepo.RotateRefreshToken(session, model.RefreshToken{
+			UserID:    1,
+			TokenHash: "hash-3",
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.Equal(t, ErrRefreshTokenReused, err)
+	})
+
+	t.Run("Revoke Refresh Token", func(t *testing.T) {
+		err := userRepo.RevokeRefreshToken(1, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, false, userRepo.IsSessionActive(1, 2))
+	})
+
+	t.Run("Revoke All Refresh Tokens", func(t *testing.T) {
+		userRepo.CreateRefreshToken(model.RefreshToken{UserID: 2, TokenHash: "hash-4", ExpiresAt: time.Now().Add(time.Hour)})
+		userRepo.CreateRefreshToken(model.RefreshToken{UserID: 2, TokenHash: "hash-5", ExpiresAt: time.Now().Add(time.Hour)})
+
+		err := userRepo.RevokeAllRefreshTokens(2)
+		assert.Nil(t, err)
+		assert.Equal(t, false, userRepo.IsSessionActive(2, 3))
+		assert.Equal(t, false, userRepo.IsSessionActive(2, 4))
+	})
+}
+
+func TestUserToken(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.RefreshToken{})
+	db.Migrator().DropTable(&model.UserToken{})
+
+	userRepo = NewUserRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.RefreshToken{})
+	db.AutoMigrate(&model.UserToken{})
+
+	userRepo.Register(model.User{Name: "tester", Email: "test@gmail.com", Password: "test1234"})
+
+	t.Run("Verify Email", func(t *testing.T) {
+		_, err := userRepo.CreateUserToken(model.UserToken{UserID: 1, Purpose: constant.EMAIL_VERIFICATION_TOKEN, TokenHash: "verify-1", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.Nil(t, err)
+
+		res, err := userRepo.VerifyEmail("verify-1")
+		assert.Nil(t, err)
+		assert.Equal(t, true, res.EmailVerified)
+	})
+
+	t.Run("Error Verify Email Token Used", func(t *testing.T) {
+		_, err := userRepo.VerifyEmail("verify-1")
+		assert.Equal(t, ErrUserTokenInvalid, err)
+	})
+
+	t.Run("Error Verify Email Token Expired", func(t *testing.T) {
+		userRepo.CreateUserToken(model.UserToken{UserID: 1, Purpose: constant.EMAIL_VERIFICATION_TOKEN, TokenHash: "verify-2", ExpiresAt: time.Now().Add(-time.Minute)})
+
+		_, err := userRepo.VerifyEmail("verify-2")
+		assert.Equal(t, ErrUserTokenInvalid, err)
+	})
+
+	t.Run("Error New Token Retires Old One", func(t *testing.T) {
+		userRepo.CreateUserToken(model.UserToken{UserID: 1, Purpose: constant.PASSWORD_RESET_TOKEN, TokenHash: "reset-1", ExpiresAt: time.Now().Add(time.Hour)})
+		userRepo.CreateUserToken(model.UserToken{UserID: 1, Purpose: constant.PASSWORD_RESET_TOKEN, TokenHash: "reset-2", ExpiresAt: time.Now().Add(time.Hour)})
+
+		_, err := userRepo.ResetPassword("reset-1", "newhash")
+		assert.Equal(t, ErrUserTokenInvalid, err)
+	})
+
+	t.Run("Error Reset Password Wrong Purpose", func(t *testing.T) {
+		userRepo.CreateUserToken(model.UserToken{UserID: 1, Purpose: constant.EMAIL_VERIFICATION_TOKEN, TokenHash: "verify-3", ExpiresAt: time.Now().Add(time.Hour)})
+
+		_, err := userRepo.ResetPassword("verify-3", "newhash")
+		assert.Equal(t, ErrUserTokenInvalid, err)
+	})
+
+	t.Run("Reset Password", func(t *testing.T) {
+		userRepo.CreateRefreshToken(model.RefreshToken{UserID: 1, TokenHash: "refresh-1", ExpiresAt: time.Now().Add(time.Hour)})
+
+		res, err := userRepo.ResetPassword("reset-2", "newhash")
+		assert.Nil(t, err)
+		assert.Equal(t, "newhash", res.Password)
+		assert.Equal(t, false, userRepo.IsSessionActive(1, 1))
+	})
+}
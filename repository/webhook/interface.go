@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
+
+type WebhookInterface interface {
+	Create(webhookEvent model.WebhookEvent) (model.WebhookEvent, bool, error)
+	Get(webhookEventId int) (model.WebhookEvent, error)
+	GetAll(invId string, page pagination.Page) ([]model.WebhookEvent, pagination.Result, error)
+	GetLatestApplied(invId string) (model.WebhookEvent, error)
+	Claim(webhookEventId int, result string) (bool, error)
+	MarkProcessed(webhookEventId int, result string) error
+}
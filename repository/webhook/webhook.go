@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// APPLIED_RESULT is the result of an event that changed its transaction.
+const APPLIED_RESULT = "applied"
+
+// PROCESSING_RESULT is the result of an event while it is being applied.
+const PROCESSING_RESULT = "processing"
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create stores an event unless one with the same event id was stored
+// already. It returns the stored event and whether it is new.
+func (wr *WebhookRepository) Create(webhookEvent model.WebhookEvent) (model.WebhookEvent, bool, error) {
+	result := wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&webhookEvent)
+	if result.Error != nil {
+		return webhookEvent, false, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return webhookEvent, true, nil
+	}
+
+	var stored model.WebhookEvent
+
+	if err := wr.db.Where("event_id = ?", webhookEvent.EventID).First(&stored).Error; err != nil {
+		return stored, false, err
+	}
+
+	return stored, false, nil
+}
+
+func (wr *WebhookRepository) Get(webhookEventId int) (model.WebhookEvent, error) {
+	var webhookEvent model.WebhookEvent
+
+	if err := wr.db.First(&webhookEvent, webhookEventId).Error; err != nil {
+		return webhookEvent, err
+	}
+
+	return webhookEvent, nil
+}
+
+func (wr *WebhookRepository) GetAll(invId string, page pagination.Page) ([]model.WebhookEvent, pagination.Result, error) {
+	webhookEvents := []model.WebhookEvent{}
+
+	query := wr.db.Model(&model.WebhookEvent{})
+	if invId != "" {
+		query = query.Where("invoice_id = ?", invId)
+	}
+
+	result, err := pagination.FindOrdered(query.Order("id DESC"), page, &webhookEvents)
+
+	return webhookEvents, result, err
+}
+
+// GetLatestApplied returns the newest event by event time that changed the
+// transaction of the invoice.
+func (wr *WebhookRepository) GetLatestApplied(invId string) (model.WebhookEvent, error) {
+	var webhookEvent model.WebhookEvent
+
+	if err := wr.db.Where("invoice_id = ? AND result = ?", invId, APPLIED_RESULT).Order("occurred_at DESC").First(&webhookEvent).Error; err != nil {
+		return webhookEvent, err
+	}
+
+	return webhookEvent, nil
+}
+
+// Claim marks an event as being processed if its result is still the given
+// one, so of two deliveries of the same event only one gets to apply it. It
+// returns whether the event was claimed.
+func (wr *WebhookRepository) Claim(webhookEventId int, result string) (bool, error) {
+	query := wr.db.Model(&model.WebhookEvent{}).Where("id = ? AND result = ?", webhookEventId, result).Updates(map[string]interface{}{
+		"processed_at": time.Now(),
+		"result":       PROCESSING_RESULT,
+	})
+	if query.Error != nil {
+		return false, query.Error
+	}
+
+	return query.RowsAffected > 0, nil
+}
+
+func (wr *WebhookRepository) MarkProcessed(webhookEventId int, result string) error {
+	return wr.db.Model(&model.WebhookEvent{}).Where("id = ?", webhookEventId).Updates(map[string]interface{}{
+		"processed_at": time.Now(),
+		"result":       result,
+	}).Error
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"github.com/furqonzt99/airbnb/util"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var configTest *config.AppConfig
+var db *gorm.DB
+var webhookRepo *WebhookRepository
+
+func TestWebhook(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.WebhookEvent{})
+
+	webhookRepo = NewWebhookRepository(db)
+
+	db.AutoMigrate(&model.WebhookEvent{})
+
+	occurredAt := time.Now().Add(-time.Hour)
+
+	t.Run("Create Webhook Event", func(t *testing.T) {
+		res, created, err := webhookRepo.Create(model.WebhookEvent{EventID: "evt-1", InvoiceID: "INV-1", Status: "PENDING", Amount: 300000, OccurredAt: occurredAt})
+		assert.Nil(t, err)
+		assert.True(t, created)
+		assert.Equal(t, uint(1), res.ID)
+	})
+
+	t.Run("Create Duplicate Webhook Event", func(t *testing.T) {
+		res, created, err := webhookRepo.Create(model.WebhookEvent{EventID: "evt-1", InvoiceID: "INV-1", Status: "PENDING", Amount: 300000, OccurredAt: occurredAt})
+		assert.Nil(t, err)
+		assert.False(t, created)
+		assert.Equal(t, uint(1), res.ID)
+	})
+
+	t.Run("Claim Webhook Event", func(t *testing.T) {
+		claimed, err := webhookRepo.Claim(1, "")
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+
+		// the other delivery finds the event claimed already
+		claimed, err = webhookRepo.Claim(1, "")
+		assert.Nil(t, err)
+		assert.False(t, claimed)
+
+		res, _ := webhookRepo.Get(1)
+		assert.Equal(t, PROCESSING_RESULT, res.Result)
+	})
+
+	t.Run("Mark Processed", func(t *testing.T) {
+		assert.Nil(t, webhookRepo.MarkProcessed(1, APPLIED_RESULT))
+
+		res, err := webhookRepo.Get(1)
+		assert.Nil(t, err)
+		assert.Equal(t, APPLIED_RESULT, res.Result)
+		assert.False(t, res.ProcessedAt.IsZero())
+	})
+
+	t.Run("Get Latest Applied", func(t *testing.T) {
+		webhookRepo.Create(model.WebhookEvent{EventID: "evt-2", InvoiceID: "INV-1", Status: "PAID", Amount: 300000, OccurredAt: occurredAt.Add(30 * time.Minute)})
+		webhookRepo.Create(model.WebhookEvent{EventID: "evt-3", InvoiceID: "INV-1", Status: "EXPIRED", OccurredAt: occurredAt.Add(10 * time.Minute)})
+
+		webhookRepo.MarkProcessed(2, APPLIED_RESULT)
+		webhookRepo.MarkProcessed(3, "stale webhook event")
+
+		res, err := webhookRepo.GetLatestApplied("INV-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "evt-2", res.EventID)
+
+		_, err = webhookRepo.GetLatestApplied("INV-2")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Get All Webhook Events Of Invoice", func(t *testing.T) {
+		webhookRepo.Create(model.WebhookEvent{EventID: "evt-4", InvoiceID: "INV-2", Status: "PENDING", OccurredAt: occurredAt})
+
+		page, _ := pagination.NewPage("", "")
+
+		res, _, err := webhookRepo.GetAll("INV-1", page)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(res))
+		assert.Equal(t, "evt-3", res[0].EventID)
+
+		res, _, err = webhookRepo.GetAll("", page)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(res))
+	})
+
+	t.Run("Error Get Webhook Event Not Found", func(t *testing.T) {
+		_, err := webhookRepo.Get(100)
+		assert.NotNil(t, err)
+	})
+}
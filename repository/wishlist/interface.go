@@ -0,0 +1,21 @@
+package wishlist
+
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+)
+
+type WishlistInterface interface {
+	Create(wishlist model.Wishlist) (model.Wishlist, error)
+	GetAll(userId int, page pagination.Page) ([]model.Wishlist, pagination.Result, error)
+	CountHouses(wishlistIds []uint) (map[uint]int64, error)
+	Get(userId, wishlistId int) (model.Wishlist, error)
+	GetByShareToken(shareToken string) (model.Wishlist, error)
+	Rename(userId, wishlistId int, name string) (model.Wishlist, error)
+	SetShareToken(userId, wishlistId int, shareToken *string) (model.Wishlist, error)
+	Delete(userId, wishlistId int) (model.Wishlist, error)
+	GetHouse(houseId int) (model.House, error)
+	AddHouse(wishlistId, houseId int) error
+	RemoveHouse(wishlistId, houseId int) error
+	GetHouses(wishlistId int, page pagination.Page) ([]model.House, pagination.Result, error)
+}
@@ -0,0 +1,170 @@
+package wishlist
+
+import (
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+func (wr *WishlistRepository) Create(wishlist model.Wishlist) (model.Wishlist, error) {
+	if err := wr.db.Create(&wishlist).Error; err != nil {
+		return wishlist, err
+	}
+
+	return wishlist, nil
+}
+
+func (wr *WishlistRepository) GetAll(userId int, page pagination.Page) ([]model.Wishlist, pagination.Result, error) {
+	wishlists := []model.Wishlist{}
+
+	result, err := pagination.Find(wr.db.Model(&model.Wishlist{}).Where("user_id = ?", userId), page, &wishlists)
+
+	return wishlists, result, err
+}
+
+// CountHouses counts the houses still listed in each of the wishlists.
+func (wr *WishlistRepository) CountHouses(wishlistIds []uint) (map[uint]int64, error) {
+	rows := []struct {
+		WishlistID uint
+		Houses     int64
+	}{}
+	counts := map[uint]int64{}
+
+	if len(wishlistIds) == 0 {
+		return counts, nil
+	}
+
+	houseIds := wr.db.Model(&model.House{}).Select("id").Where("suspended = ?", false)
+
+	if err := wr.db.Model(&model.WishlistHouse{}).Select("wishlist_id, COUNT(*) AS houses").Where("wishlist_id IN ? AND house_id IN (?)", wishlistIds, houseIds).Group("wishlist_id").Scan(&rows).Error; err != nil {
+		return counts, err
+	}
+
+	for _, row := range rows {
+		counts[row.WishlistID] = row.Houses
+	}
+
+	return counts, nil
+}
+
+func (wr *WishlistRepository) Get(userId, wishlistId int) (model.Wishlist, error) {
+	var wishlist model.Wishlist
+
+	if err := wr.db.Where("user_id = ?", userId).First(&wishlist, wishlistId).Error; err != nil {
+		return wishlist, err
+	}
+
+	return wishlist, nil
+}
+
+func (wr *WishlistRepository) GetByShareToken(shareToken string) (model.Wishlist, error) {
+	var wishlist model.Wishlist
+
+	if err := wr.db.Where("share_token = ?", shareToken).First(&wishlist).Error; err != nil {
+		return wishlist, err
+	}
+
+	return wishlist, nil
+}
+
+func (wr *WishlistRepository) Rename(userId, wishlistId int, name string) (model.Wishlist, error) {
+	wishlist, err := wr.Get(userId, wishlistId)
+	if err != nil {
+		return wishlist, err
+	}
+
+	if err := wr.db.Model(&wishlist).Update("name", name).Error; err != nil {
+		return wishlist, err
+	}
+
+	return wishlist, nil
+}
+
+// SetShareToken shares the wishlist under shareToken, or stops sharing it
+// when shareToken is nil.
+func (wr *WishlistRepository) SetShareToken(userId, wishlistId int, shareToken *string) (model.Wishlist, error) {
+	wishlist, err := wr.Get(userId, wishlistId)
+	if err != nil {
+		return wishlist, err
+	}
+
+	if err := wr.db.Model(&wishlist).Update("share_token", shareToken).Error; err != nil {
+		return wishlist, err
+	}
+
+	wishlist.ShareToken = shareToken
+
+	return wishlist, nil
+}
+
+func (wr *WishlistRepository) Delete(userId, wishlistId int) (model.Wishlist, error) {
+	wishlist, err := wr.Get(userId, wishlistId)
+	if err != nil {
+		return wishlist, err
+	}
+
+	err = wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", wishlist.ID).Delete(&model.WishlistHouse{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&wishlist).Error
+	})
+	if err != nil {
+		return wishlist, err
+	}
+
+	return wishlist, nil
+}
+
+func (wr *WishlistRepository) GetHouse(houseId int) (model.House, error) {
+	var house model.House
+
+	if err := wr.db.Where("suspended = ?", false).First(&house, houseId).Error; err != nil {
+		return house, err
+	}
+
+	return house, nil
+}
+
+// AddHouse saves the house to the wishlist; saving it twice is a no-op.
+func (wr *WishlistRepository) AddHouse(wishlistId, houseId int) error {
+	wishlistHouse := model.WishlistHouse{WishlistID: uint(wishlistId), HouseID: uint(houseId)}
+
+	return wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&wishlistHouse).Error
+}
+
+func (wr *WishlistRepository) RemoveHouse(wishlistId, houseId int) error {
+	result := wr.db.Where("wishlist_id = ? AND house_id = ?", wishlistId, houseId).Delete(&model.WishlistHouse{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetHouses lists the houses of the wishlist with the same associations as
+// the public house listing. Suspended houses are left out.
+func (wr *WishlistRepository) GetHouses(wishlistId int, page pagination.Page) ([]model.House, pagination.Result, error) {
+	houses := []model.House{}
+
+	houseIds := wr.db.Model(&model.WishlistHouse{}).Select("house_id").Where("wishlist_id = ?", wishlistId)
+	query := wr.db.Model(&model.House{}).Where("id IN (?)", houseIds).Where("suspended = ?", false)
+
+	result, err := pagination.Find(query, page, &houses, "Features", "User", "Ratings.User", clause.Associations)
+
+	return houses, result, err
+}
@@ -0,0 +1,130 @@
+package wishlist
+
+import (
+	"testing"
+
+	"github.com/furqonzt99/airbnb/config"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/pagination"
+	hr "github.com/furqonzt99/airbnb/repository/house"
+	"github.com/furqonzt99/airbnb/seed"
+	"github.com/furqonzt99/airbnb/util"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var configTest *config.AppConfig
+var db *gorm.DB
+var wishlistRepo *WishlistRepository
+
+func TestWishlist(t *testing.T) {
+	configTest = config.GetConfig()
+	db = util.InitDB(configTest)
+
+	db.Migrator().DropTable(&model.User{})
+	db.Migrator().DropTable(&model.House{})
+	db.Migrator().DropTable(&model.Wishlist{})
+	db.Migrator().DropTable(&model.WishlistHouse{})
+
+	wishlistRepo = NewWishlistRepository(db)
+	houseRepo := hr.NewHouseRepo(db)
+
+	db.AutoMigrate(&model.User{})
+	db.AutoMigrate(&model.House{})
+	db.AutoMigrate(&model.Wishlist{})
+	db.AutoMigrate(&model.WishlistHouse{})
+
+	seed.UserSeed(db)
+
+	db.Create(&model.House{UserID: 2, Title: "Rumah Bagus", Address: "Jalan Ujung", City: "Bali", Price: 100000})
+	db.Create(&model.House{UserID: 2, Title: "Rumah Indah", Address: "Jalan Pantai", City: "Bali", Price: 150000})
+	db.Create(&model.House{UserID: 2, Title: "Rumah Tutup", Address: "Jalan Buntu", City: "Bali", Price: 90000, Suspended: true})
+
+	t.Run("Create Wishlist", func(t *testing.T) {
+		res, err := wishlistRepo.Create(model.Wishlist{UserID: 5, Name: "Bali"})
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), res.ID)
+	})
+
+	t.Run("Get Wishlist Of Owner Only", func(t *testing.T) {
+		_, err := wishlistRepo.Get(5, 1)
+		assert.Nil(t, err)
+
+		_, err = wishlistRepo.Get(6, 1)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Add Houses", func(t *testing.T) {
+		assert.Nil(t, wishlistRepo.AddHouse(1, 1))
+		assert.Nil(t, wishlistRepo.AddHouse(1, 3))
+
+		// saving the same house again is not an error
+		assert.Nil(t, wishlistRepo.AddHouse(1, 1))
+
+		counts, err := wishlistRepo.CountHouses([]uint{1})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), counts[1])
+	})
+
+	t.Run("Get Houses Without Suspended", func(t *testing.T) {
+		page, _ := pagination.NewPage("", "")
+
+		houses, result, err := wishlistRepo.GetHouses(1, page)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), result.Total)
+		assert.Equal(t, "Rumah Bagus", houses[0].Title)
+	})
+
+	t.Run("Get Saved House Ids", func(t *testing.T) {
+		saved, err := houseRepo.GetSavedHouseIDs(5, []uint{1, 2})
+		assert.Nil(t, err)
+		assert.Equal(t, map[uint]bool{1: true}, saved)
+
+		saved, err = houseRepo.GetSavedHouseIDs(6, []uint{1, 2})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(saved))
+	})
+
+	t.Run("Remove House", func(t *testing.T) {
+		assert.Nil(t, wishlistRepo.RemoveHouse(1, 3))
+		assert.NotNil(t, wishlistRepo.RemoveHouse(1, 2))
+	})
+
+	t.Run("Share Wishlist", func(t *testing.T) {
+		shareToken := "shared-token"
+
+		_, err := wishlistRepo.SetShareToken(6, 1, &shareToken)
+		assert.NotNil(t, err)
+
+		res, err := wishlistRepo.SetShareToken(5, 1, &shareToken)
+		assert.Nil(t, err)
+		assert.Equal(t, "shared-token", *res.ShareToken)
+
+		res, err = wishlistRepo.GetByShareToken("shared-token")
+		assert.Nil(t, err)
+		assert.Equal(t, "Bali", res.Name)
+	})
+
+	t.Run("Unshare Wishlist", func(t *testing.T) {
+		_, err := wishlistRepo.SetShareToken(5, 1, nil)
+		assert.Nil(t, err)
+
+		_, err = wishlistRepo.GetByShareToken("shared-token")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Rename Wishlist", func(t *testing.T) {
+		res, err := wishlistRepo.Rename(5, 1, "Bali 2025")
+		assert.Nil(t, err)
+		assert.Equal(t, "Bali 2025", res.Name)
+	})
+
+	t.Run("Delete Wishlist", func(t *testing.T) {
+		_, err := wishlistRepo.Delete(5, 1)
+		assert.Nil(t, err)
+
+		saved, err := houseRepo.GetSavedHouseIDs(5, []uint{1})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(saved))
+	})
+}
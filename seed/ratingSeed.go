@@ -3,7 +3,9 @@ package seed
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
+	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
 	"gorm.io/gorm"
 )
@@ -12,12 +14,43 @@ func RatingSeed(db *gorm.DB) {
 	for i := 1; i <= 45; i++ {
 		houseId := uint(rand.Intn(15-1) + 1)
 		userId := uint(rand.Intn(5-1) + 1)
+
+		var house model.House
+		db.First(&house, houseId)
+
+		// every review belongs to a stay, so each one gets a completed booking
+		checkoutDate := time.Now().AddDate(0, 0, -rand.Intn(30)-1)
+		transaction := model.Transaction{
+			UserID:       userId,
+			HouseID:      houseId,
+			HostID:       house.UserID,
+			CheckinDate:  checkoutDate.AddDate(0, 0, -2),
+			CheckoutDate: checkoutDate,
+			TotalPrice:   house.Price * 2,
+			Status:       "PAID",
+		}
+		db.Create(&transaction)
+
 		rating := model.Rating{
-			HouseID: houseId,
-			UserID:  userId,
-			Rating:  rand.Intn(5-1) + 1,
-			Comment: fmt.Sprintf("Rating Untuk House %v dari User %v", houseId, userId),
+			TransactionID: transaction.ID,
+			HouseID:       houseId,
+			UserID:        userId,
+			Rating:        rand.Intn(5-1) + 1,
+			Cleanliness:   rand.Intn(5) + 1,
+			Accuracy:      rand.Intn(5) + 1,
+			Location:      rand.Intn(5) + 1,
+			Communication: rand.Intn(5) + 1,
+			Value:         rand.Intn(5) + 1,
+			Comment:       fmt.Sprintf("Rating Untuk House %v dari User %v", houseId, userId),
 		}
 		db.Create(&rating)
 	}
+
+	// seeded ratings skip the repository, so the house summaries are filled here
+	for houseId := 1; houseId < 15; houseId++ {
+		ratings := []model.Rating{}
+		db.Where("house_id = ?", houseId).Find(&ratings)
+
+		db.Model(&model.House{}).Where("id = ?", houseId).Updates(model.House{RatingSummary: helper.SummarizeRatings(ratings)})
+	}
 }
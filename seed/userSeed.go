@@ -3,6 +3,7 @@ package seed
 import (
 	"fmt"
 
+	"github.com/furqonzt99/airbnb/constant"
 	"github.com/furqonzt99/airbnb/helper"
 	"github.com/furqonzt99/airbnb/model"
 	"gorm.io/gorm"
@@ -11,10 +12,20 @@ import (
 func UserSeed(db *gorm.DB) {
 	password, _ := helper.Hashpwd("1234qwer")
 	for i := 1; i <= 5; i++ {
+		// user 1 moderates, users 2-4 host and user 5 only books
+		role := constant.GUEST_ROLE
+		if i == 1 {
+			role = constant.ADMIN_ROLE
+		} else if i <= 4 {
+			role = constant.HOST_ROLE
+		}
+
 		user := model.User{
-			Name:     "User " + fmt.Sprint(i),
-			Email:    fmt.Sprintf("user%v@gmail.com", i),
-			Password: password,
+			Name:          "User " + fmt.Sprint(i),
+			Email:         fmt.Sprintf("user%v@gmail.com", i),
+			Password:      password,
+			Role:          role,
+			EmailVerified: true,
 		}
 		db.Create(&user)
 	}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps uploaded files under a slash separated key and hands out the
+// URL clients use to download them.
+type Storage interface {
+	Save(key string, content io.Reader) error
+	Delete(key string) error
+	URL(key string) string
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage writes files below Dir on the local disk. BaseUrl is where Dir
+// is served from, see the static route registered in main.
+type LocalStorage struct {
+	Dir     string
+	BaseUrl string
+}
+
+func NewLocalStorage(dir, baseUrl string) *LocalStorage {
+	return &LocalStorage{
+		Dir:     dir,
+		BaseUrl: strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+func (ls *LocalStorage) Save(key string, content io.Reader) error {
+	filename, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(filename)
+		return err
+	}
+
+	return file.Close()
+}
+
+func (ls *LocalStorage) Delete(key string) error {
+	filename, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (ls *LocalStorage) URL(key string) string {
+	return ls.BaseUrl + "/" + key
+}
+
+// path maps a key into Dir and refuses keys that would escape it.
+func (ls *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean[1:] != key {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(ls.Dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	localStorage := NewLocalStorage(dir, "http://localhost:1326/uploads/")
+
+	t.Run("Save File", func(t *testing.T) {
+		err := localStorage.Save("houses/1/photo.jpg", strings.NewReader("image"))
+		assert.Nil(t, err)
+
+		content, err := os.ReadFile(filepath.Join(dir, "houses", "1", "photo.jpg"))
+		assert.Nil(t, err)
+		assert.Equal(t, "image", string(content))
+	})
+
+	t.Run("File URL", func(t *testing.T) {
+		assert.Equal(t, "http://localhost:1326/uploads/houses/1/photo.jpg", localStorage.URL("houses/1/photo.jpg"))
+	})
+
+	t.Run("Delete File", func(t *testing.T) {
+		err := localStorage.Delete("houses/1/photo.jpg")
+		assert.Nil(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "houses", "1", "photo.jpg"))
+		assert.Equal(t, true, os.IsNotExist(err))
+	})
+
+	t.Run("Delete Missing File", func(t *testing.T) {
+		err := localStorage.Delete("houses/1/missing.jpg")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Error Key Outside Directory", func(t *testing.T) {
+		err := localStorage.Save("../photo.jpg", strings.NewReader("image"))
+		assert.Equal(t, ErrInvalidKey, err)
+
+		err = localStorage.Save("houses/../../photo.jpg", strings.NewReader("image"))
+		assert.Equal(t, ErrInvalidKey, err)
+
+		err = localStorage.Save("", strings.NewReader("image"))
+		assert.Equal(t, ErrInvalidKey, err)
+	})
+}
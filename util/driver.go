@@ -30,23 +30,75 @@ func InitialMigrate(db *gorm.DB) {
 		db.Migrator().DropTable(&model.Feature{})
 		db.Migrator().DropTable(&model.Rating{})
 		db.Migrator().DropTable(&model.Transaction{})
+		db.Migrator().DropTable(&model.HouseBlockedDate{})
+		db.Migrator().DropTable(&model.HousePricingRule{})
+		db.Migrator().DropTable(&model.TransactionLineItem{})
+		db.Migrator().DropTable(&model.TransactionAuditNote{})
+		db.Migrator().DropTable(&model.TransactionStatusHistory{})
+		db.Migrator().DropTable(&model.WebhookEvent{})
+		db.Migrator().DropTable(&model.RefreshToken{})
+		db.Migrator().DropTable(&model.UserToken{})
+		db.Migrator().DropTable(&model.HousePhoto{})
+		db.Migrator().DropTable(&model.Conversation{})
+		db.Migrator().DropTable(&model.Message{})
+		db.Migrator().DropTable(&model.Notification{})
+		db.Migrator().DropTable(&model.RatingHelpfulVote{})
+		db.Migrator().DropTable(&model.GuestRating{})
+		db.Migrator().DropTable(&model.Wishlist{})
+		db.Migrator().DropTable(&model.WishlistHouse{})
 
 		db.AutoMigrate(&model.User{})
 		db.AutoMigrate(&model.House{})
 		db.AutoMigrate(&model.Feature{})
 		db.AutoMigrate(&model.Rating{})
 		db.AutoMigrate(&model.Transaction{})
+		db.AutoMigrate(&model.HouseBlockedDate{})
+		db.AutoMigrate(&model.HousePricingRule{})
+		db.AutoMigrate(&model.TransactionLineItem{})
+		db.AutoMigrate(&model.TransactionAuditNote{})
+		db.AutoMigrate(&model.TransactionStatusHistory{})
+		db.AutoMigrate(&model.WebhookEvent{})
+		db.AutoMigrate(&model.RefreshToken{})
+		db.AutoMigrate(&model.UserToken{})
+		db.AutoMigrate(&model.HousePhoto{})
+		db.AutoMigrate(&model.Conversation{})
+		db.AutoMigrate(&model.Message{})
+		db.AutoMigrate(&model.Notification{})
+		db.AutoMigrate(&model.RatingHelpfulVote{})
+		db.AutoMigrate(&model.GuestRating{})
+		db.AutoMigrate(&model.Wishlist{})
+		db.AutoMigrate(&model.WishlistHouse{})
 
 		seed.FeatureSeed(db)
 		seed.UserSeed(db)
 		seed.HouseSeed(db)
 		seed.RatingSeed(db)
 	} else {
+		migrate(db, true)
+
 		db.AutoMigrate(&model.User{})
 		db.AutoMigrate(&model.House{})
 		db.AutoMigrate(&model.Feature{})
 		db.AutoMigrate(&model.Rating{})
 		db.AutoMigrate(&model.Transaction{})
+		db.AutoMigrate(&model.HouseBlockedDate{})
+		db.AutoMigrate(&model.HousePricingRule{})
+		db.AutoMigrate(&model.TransactionLineItem{})
+		db.AutoMigrate(&model.TransactionAuditNote{})
+		db.AutoMigrate(&model.TransactionStatusHistory{})
+		db.AutoMigrate(&model.WebhookEvent{})
+		db.AutoMigrate(&model.RefreshToken{})
+		db.AutoMigrate(&model.UserToken{})
+		db.AutoMigrate(&model.HousePhoto{})
+		db.AutoMigrate(&model.Conversation{})
+		db.AutoMigrate(&model.Message{})
+		db.AutoMigrate(&model.Notification{})
+		db.AutoMigrate(&model.RatingHelpfulVote{})
+		db.AutoMigrate(&model.GuestRating{})
+		db.AutoMigrate(&model.Wishlist{})
+		db.AutoMigrate(&model.WishlistHouse{})
+
+		migrate(db, false)
 	}
 
 }
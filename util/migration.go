@@ -0,0 +1,187 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/furqonzt99/airbnb/constant"
+	"github.com/furqonzt99/airbnb/helper"
+	"github.com/furqonzt99/airbnb/model"
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a data migration that ran, so it runs once per
+// database.
+type schemaMigration struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	CreatedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migration fills in data that AutoMigrate can't, for databases created
+// before the change that needs it. Migrations that change a table so
+// AutoMigrate can handle it run before AutoMigrate.
+type migration struct {
+	name              string
+	beforeAutoMigrate bool
+	run               func(tx *gorm.DB) error
+}
+
+var migrations = []migration{
+	{name: "host_role_for_house_owners", run: migrateHostRoles},
+	{name: "verify_existing_users", run: migrateVerifiedUsers},
+	{name: "ratings_keyed_by_transaction", beforeAutoMigrate: true, run: migrateRatingKeys},
+	{name: "house_rating_summaries", run: migrateRatingSummaries},
+	{name: "settled_transactions_paid", run: migrateSettledTransactions},
+}
+
+// migrate runs the migrations of one phase the database hasn't run yet, each
+// one in a transaction together with its record.
+func migrate(db *gorm.DB, beforeAutoMigrate bool) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		panic(err)
+	}
+
+	for _, m := range migrations {
+		if m.beforeAutoMigrate != beforeAutoMigrate {
+			continue
+		}
+
+		var ran int64
+		if err := db.Model(&schemaMigration{}).Where("name = ?", m.name).Count(&ran).Error; err != nil {
+			panic(err)
+		}
+
+		if ran > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.run(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{Name: m.name}).Error
+		})
+		if err != nil {
+			panic("migration " + m.name + " failed: " + err.Error())
+		}
+	}
+}
+
+// migrateHostRoles makes everyone who owns a house a host. Users existed
+// before roles, so the new role column makes them all guests and owners would
+// lose the host routes.
+func migrateHostRoles(tx *gorm.DB) error {
+	return tx.Model(&model.User{}).Where("role = ? AND id IN (?)", constant.GUEST_ROLE, tx.Model(&model.House{}).Select("user_id")).Update("role", constant.HOST_ROLE).Error
+}
+
+// migrateVerifiedUsers marks the accounts made before email verification as
+// verified, they signed up when there was no way to verify and couldn't book
+// otherwise.
+func migrateVerifiedUsers(tx *gorm.DB) error {
+	return tx.Model(&model.User{}).Where("email_verified = ?", false).Update("email_verified", true).Error
+}
+
+// migrateRatingSummaries fills in the rating summary of every rated house.
+// The repository keeps it up to date as ratings change, ratings made before
+// the summary columns would otherwise leave their house at no ratings.
+func migrateRatingSummaries(tx *gorm.DB) error {
+	var houseIds []uint
+	if err := tx.Model(&model.Rating{}).Distinct().Pluck("house_id", &houseIds).Error; err != nil {
+		return err
+	}
+
+	for _, houseId := range houseIds {
+		ratings := []model.Rating{}
+		if err := tx.Where("house_id = ?", houseId).Find(&ratings).Error; err != nil {
+			return err
+		}
+
+		// the columns are new, so the zero scores Updates skips are zero already
+		if err := tx.Model(&model.House{}).Where("id = ?", houseId).Updates(model.House{RatingSummary: helper.SummarizeRatings(ratings)}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateSettledTransactions moves the transactions left at SETTLED to PAID.
+// Callbacks stored the gateway status as is before there was a status set,
+// and a settled invoice is a paid one whose money reached the balance. Left
+// alone they could never be cancelled, checked in or reviewed.
+func migrateSettledTransactions(tx *gorm.DB) error {
+	var transactionIds []uint
+	if err := tx.Model(&model.Transaction{}).Where("status = ?", "SETTLED").Pluck("id", &transactionIds).Error; err != nil {
+		return err
+	}
+
+	for _, transactionId := range transactionIds {
+		if err := tx.Model(&model.Transaction{}).Where("id = ?", transactionId).Update("status", model.PAID_STATUS).Error; err != nil {
+			return err
+		}
+
+		history := model.TransactionStatusHistory{
+			TransactionID: transactionId,
+			FromStatus:    "SETTLED",
+			ToStatus:      model.PAID_STATUS,
+			Note:          "settled invoices are paid",
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateRatingKeys moves ratings from one per user and house to one per
+// stay. AutoMigrate can't change a primary key, so each rating gets the
+// latest paid booking of its user for the house, which the old rating rules
+// required, and helpful votes follow their rating.
+func migrateRatingKeys(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasTable(&model.Rating{}) || migrator.HasColumn(&model.Rating{}, "TransactionID") {
+		return nil
+	}
+
+	var unmatched int64
+	if err := tx.Table("ratings").Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.user_id = ratings.user_id AND transactions.house_id = ratings.house_id)").Count(&unmatched).Error; err != nil {
+		return err
+	}
+
+	if unmatched > 0 {
+		return fmt.Errorf("%v ratings have no booking of their user for the house", unmatched)
+	}
+
+	statements := []string{
+		"ALTER TABLE ratings ADD COLUMN transaction_id bigint unsigned NULL",
+		// a booking cancelled after the rating still beats no booking
+		"UPDATE ratings SET transaction_id = COALESCE(" +
+			"(SELECT MAX(id) FROM transactions WHERE transactions.user_id = ratings.user_id AND transactions.house_id = ratings.house_id AND transactions.status = 'PAID'), " +
+			"(SELECT MAX(id) FROM transactions WHERE transactions.user_id = ratings.user_id AND transactions.house_id = ratings.house_id))",
+		"ALTER TABLE ratings DROP PRIMARY KEY, MODIFY transaction_id bigint unsigned NOT NULL, ADD PRIMARY KEY (transaction_id)",
+	}
+
+	if migrator.HasTable(&model.RatingHelpfulVote{}) && !migrator.HasColumn(&model.RatingHelpfulVote{}, "TransactionID") {
+		statements = append(statements,
+			"ALTER TABLE rating_helpful_votes ADD COLUMN transaction_id bigint unsigned NULL",
+			"UPDATE rating_helpful_votes SET transaction_id = (SELECT transaction_id FROM ratings WHERE ratings.house_id = rating_helpful_votes.house_id AND ratings.user_id = rating_helpful_votes.user_id)",
+			// votes for ratings deleted since have nothing left to count for
+			"DELETE FROM rating_helpful_votes WHERE transaction_id IS NULL",
+			"ALTER TABLE rating_helpful_votes DROP PRIMARY KEY, DROP COLUMN house_id, DROP COLUMN user_id, MODIFY transaction_id bigint unsigned NOT NULL, ADD PRIMARY KEY (transaction_id, voter_id)",
+		)
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
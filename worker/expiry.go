@@ -0,0 +1,218 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/payment"
+	"github.com/labstack/gommon/log"
+)
+
+// ExpiryRepository is the part of the transaction repository the sweeper needs.
+type ExpiryRepository interface {
+	GetPendingBefore(before time.Time) ([]model.Transaction, error)
+	ExpirePending(invId string) (bool, error)
+	GetRequestedBefore(before time.Time) ([]model.Transaction, error)
+	GetCheckinBefore(before time.Time) ([]model.Transaction, error)
+	GetCheckoutBefore(before time.Time) ([]model.Transaction, error)
+	UpdateStatus(trxId int, from model.TransactionStatus, transaction model.Transaction, changedBy int, note string) (bool, error)
+}
+
+// ExpirySweeper periodically expires PENDING transactions whose payment
+// window has passed, so an unpaid booking cannot lock a house forever when
+// the gateway never sends the EXPIRED callback. Booking requests the host
+// didn't answer in time are declined the same way, and paid stays are checked
+// in and completed as their dates pass.
+type ExpirySweeper struct {
+	Repository    ExpiryRepository
+	Payment       payment.PaymentProvider
+	Events        event.Publisher
+	PaymentWindow time.Duration
+	Interval      time.Duration
+	Now           func() time.Time
+
+	started  bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewExpirySweeper(repo ExpiryRepository, paymentProvider payment.PaymentProvider, events event.Publisher, paymentWindow, interval time.Duration) *ExpirySweeper {
+	return &ExpirySweeper{
+		Repository:    repo,
+		Payment:       paymentProvider,
+		Events:        events,
+		PaymentWindow: paymentWindow,
+		Interval:      interval,
+		Now:           time.Now,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Sweep runs a single pass and returns how many transactions were expired.
+func (es *ExpirySweeper) Sweep() (int, error) {
+	deadline := es.Now().Add(-es.PaymentWindow)
+
+	transactions, err := es.Repository.GetPendingBefore(deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, transaction := range transactions {
+		ok, err := es.Repository.ExpirePending(transaction.InvoiceID)
+		if err != nil {
+			log.Error("failed to expire transaction ", transaction.InvoiceID, ": ", err)
+			continue
+		}
+
+		// status changed since we read it, most likely paid in the meantime
+		if !ok {
+			continue
+		}
+
+		if err := es.Payment.ExpireInvoice(transaction); err != nil {
+			log.Warn("failed to expire invoice ", transaction.InvoiceID, " upstream: ", err)
+		}
+
+		es.Events.Publish(event.Event{
+			Type:    event.BOOKING_EXPIRED,
+			UserID:  int(transaction.UserID),
+			Message: fmt.Sprintf("Your booking for %v expired before it was paid", transaction.House.Title),
+			Data:    map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID},
+		})
+
+		expired++
+	}
+
+	return expired, nil
+}
+
+// DeclineStale declines the booking requests past their respond by deadline
+// and returns how many were declined.
+func (es *ExpirySweeper) DeclineStale() (int, error) {
+	transactions, err := es.Repository.GetRequestedBefore(es.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	declined := 0
+	for _, transaction := range transactions {
+		const reason = "The host didn't respond in time"
+
+		ok, err := es.Repository.UpdateStatus(int(transaction.ID), model.REQUESTED_STATUS, model.Transaction{Status: model.DECLINED_STATUS, DeclineReason: reason}, 0, reason)
+		if err != nil {
+			log.Error("failed to decline transaction ", transaction.ID, ": ", err)
+			continue
+		}
+
+		// the host answered in the meantime
+		if !ok {
+			continue
+		}
+
+		es.Events.Publish(event.Event{
+			Type:    event.BOOKING_DECLINED,
+			UserID:  int(transaction.UserID),
+			Message: fmt.Sprintf("Your booking request for %v was declined because the host didn't respond in time", transaction.House.Title),
+			Data:    map[string]interface{}{"transaction_id": transaction.ID, "house_id": transaction.HouseID},
+		})
+
+		declined++
+	}
+
+	return declined, nil
+}
+
+// AdvanceStays checks in the paid bookings whose checkin date has come and
+// completes the stays whose checkout date has passed. It returns how many
+// transactions moved.
+func (es *ExpirySweeper) AdvanceStays() (int, error) {
+	now := es.Now()
+
+	transactions, err := es.Repository.GetCheckinBefore(now)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, transaction := range transactions {
+		ok, err := es.Repository.UpdateStatus(int(transaction.ID), model.PAID_STATUS, model.Transaction{Status: model.CHECKED_IN_STATUS}, 0, "")
+		if err != nil {
+			log.Error("failed to check in transaction ", transaction.ID, ": ", err)
+			continue
+		}
+
+		// cancelled in the meantime
+		if ok {
+			moved++
+		}
+	}
+
+	// after the check ins, so a stay that is over already completes in the same pass
+	transactions, err = es.Repository.GetCheckoutBefore(now)
+	if err != nil {
+		return moved, err
+	}
+
+	for _, transaction := range transactions {
+		ok, err := es.Repository.UpdateStatus(int(transaction.ID), model.CHECKED_IN_STATUS, model.Transaction{Status: model.COMPLETED_STATUS}, 0, "")
+		if err != nil {
+			log.Error("failed to complete transaction ", transaction.ID, ": ", err)
+			continue
+		}
+
+		if ok {
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// Start runs Sweep, DeclineStale and AdvanceStays every Interval in a background goroutine until Stop is called.
+func (es *ExpirySweeper) Start() {
+	es.started = true
+
+	go func() {
+		defer close(es.done)
+
+		ticker := time.NewTicker(es.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := es.Sweep(); err != nil {
+					log.Error("expiry sweep failed: ", err)
+				}
+
+				if _, err := es.DeclineStale(); err != nil {
+					log.Error("booking request sweep failed: ", err)
+				}
+
+				if _, err := es.AdvanceStays(); err != nil {
+					log.Error("stay sweep failed: ", err)
+				}
+			case <-es.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the background goroutine to exit and waits for it.
+func (es *ExpirySweeper) Stop() {
+	if !es.started {
+		return
+	}
+
+	es.stopOnce.Do(func() {
+		close(es.stop)
+	})
+	<-es.done
+}
@@ -0,0 +1,291 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/furqonzt99/airbnb/event"
+	"github.com/furqonzt99/airbnb/model"
+	"github.com/furqonzt99/airbnb/payment"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSweep(t *testing.T) {
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Expire Pending Older Than Window", func(t *testing.T) {
+		repo := newMockTransactionRepository(
+			model.Transaction{Model: gorm.Model{ID: 1, CreatedAt: now.Add(-25 * time.Hour)}, UserID: 5, InvoiceID: "INV1", Status: "PENDING"},
+			model.Transaction{Model: gorm.Model{ID: 2, CreatedAt: now.Add(-1 * time.Hour)}, InvoiceID: "INV2", Status: "PENDING"},
+			model.Transaction{Model: gorm.Model{ID: 3, CreatedAt: now.Add(-48 * time.Hour)}, InvoiceID: "INV3", Status: "PAID"},
+		)
+		paymentProvider := payment.NewFakeProvider("token")
+		for _, transaction := range repo.transactions {
+			paymentProvider.CreateInvoice(*transaction, "test@gmail.com", payment.Invoice{})
+		}
+
+		bus := event.NewBus(nil)
+		defer bus.Close()
+
+		events, unsubscribe := bus.Subscribe(5)
+		defer unsubscribe()
+
+		expirySweeper := NewExpirySweeper(repo, paymentProvider, bus, 24*time.Hour, time.Minute)
+		expirySweeper.Now = func() time.Time { return now }
+
+		count, err := expirySweeper.Sweep()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, model.EXPIRED_STATUS, repo.transactions["INV1"].Status)
+		assert.Equal(t, model.PENDING_STATUS, repo.transactions["INV2"].Status)
+		assert.Equal(t, model.PAID_STATUS, repo.transactions["INV3"].Status)
+
+		invoice, _ := paymentProvider.Invoice("INV1")
+		assert.Equal(t, "EXPIRED", invoice.Status)
+
+		select {
+		case expired := <-events:
+			assert.Equal(t, event.BOOKING_EXPIRED, expired.Type)
+		case <-time.After(time.Second):
+			t.Fatal("no event published")
+		}
+	})
+
+	t.Run("Expire After Clock Moves Forward", func(t *testing.T) {
+		repo := newMockTransactionRepository(
+			model.Transaction{Model: gorm.Model{ID: 1, CreatedAt: now.Add(-1 * time.Hour)}, InvoiceID: "INV1", Status: "PENDING"},
+		)
+
+		clock := now
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Minute)
+		expirySweeper.Now = func() time.Time { return clock }
+
+		count, _ := expirySweeper.Sweep()
+		assert.Equal(t, 0, count)
+
+		clock = now.Add(24 * time.Hour)
+
+		count, _ = expirySweeper.Sweep()
+		assert.Equal(t, 1, count)
+		assert.Equal(t, model.EXPIRED_STATUS, repo.transactions["INV1"].Status)
+	})
+
+	t.Run("Sweep Failed", func(t *testing.T) {
+		repo := newMockTransactionRepository()
+		repo.err = errors.New("Error")
+
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Minute)
+
+		_, err := expirySweeper.Sweep()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDeclineStale(t *testing.T) {
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Decline Requests Past Deadline", func(t *testing.T) {
+		repo := newMockTransactionRepository(
+			model.Transaction{Model: gorm.Model{ID: 1}, UserID: 5, InvoiceID: "INV1", Status: "REQUESTED", RespondBy: now.Add(-time.Minute)},
+			model.Transaction{Model: gorm.Model{ID: 2}, UserID: 5, InvoiceID: "INV2", Status: "REQUESTED", RespondBy: now.Add(time.Hour)},
+			model.Transaction{Model: gorm.Model{ID: 3}, UserID: 5, InvoiceID: "INV3", Status: "PENDING", RespondBy: now.Add(-time.Hour)},
+		)
+
+		bus := event.NewBus(nil)
+		defer bus.Close()
+
+		events, unsubscribe := bus.Subscribe(5)
+		defer unsubscribe()
+
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), bus, 24*time.Hour, time.Minute)
+		expirySweeper.Now = func() time.Time { return now }
+
+		count, err := expirySweeper.DeclineStale()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, model.DECLINED_STATUS, repo.status("INV1"))
+		assert.Equal(t, model.REQUESTED_STATUS, repo.status("INV2"))
+		assert.Equal(t, model.PENDING_STATUS, repo.status("INV3"))
+
+		select {
+		case declined := <-events:
+			assert.Equal(t, event.BOOKING_DECLINED, declined.Type)
+		case <-time.After(time.Second):
+			t.Fatal("no event published")
+		}
+	})
+
+	t.Run("Decline Failed", func(t *testing.T) {
+		repo := newMockTransactionRepository()
+		repo.err = errors.New("Error")
+
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Minute)
+
+		_, err := expirySweeper.DeclineStale()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAdvanceStays(t *testing.T) {
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Check In And Complete Stays", func(t *testing.T) {
+		repo := newMockTransactionRepository(
+			model.Transaction{Model: gorm.Model{ID: 1}, InvoiceID: "INV1", Status: "PAID", CheckinDate: now.AddDate(0, 0, -1), CheckoutDate: now.AddDate(0, 0, 1)},
+			model.Transaction{Model: gorm.Model{ID: 2}, InvoiceID: "INV2", Status: "PAID", CheckinDate: now.AddDate(0, 0, 1), CheckoutDate: now.AddDate(0, 0, 3)},
+			model.Transaction{Model: gorm.Model{ID: 3}, InvoiceID: "INV3", Status: "CHECKED_IN", CheckinDate: now.AddDate(0, 0, -3), CheckoutDate: now.AddDate(0, 0, -1)},
+			model.Transaction{Model: gorm.Model{ID: 4}, InvoiceID: "INV4", Status: "PAID", CheckinDate: now.AddDate(0, 0, -5), CheckoutDate: now.AddDate(0, 0, -3)},
+			model.Transaction{Model: gorm.Model{ID: 5}, InvoiceID: "INV5", Status: "CANCELLED", CheckinDate: now.AddDate(0, 0, -1), CheckoutDate: now.AddDate(0, 0, 1)},
+		)
+
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Minute)
+		expirySweeper.Now = func() time.Time { return now }
+
+		count, err := expirySweeper.AdvanceStays()
+		assert.Nil(t, err)
+		assert.Equal(t, 4, count)
+		assert.Equal(t, model.CHECKED_IN_STATUS, repo.status("INV1"))
+		assert.Equal(t, model.PAID_STATUS, repo.status("INV2"))
+		assert.Equal(t, model.COMPLETED_STATUS, repo.status("INV3"))
+		assert.Equal(t, model.COMPLETED_STATUS, repo.status("INV4"))
+		assert.Equal(t, model.CANCELLED_STATUS, repo.status("INV5"))
+	})
+
+	t.Run("Advance Stays Failed", func(t *testing.T) {
+		repo := newMockTransactionRepository()
+		repo.err = errors.New("Error")
+
+		expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Minute)
+
+		_, err := expirySweeper.AdvanceStays()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestStartStop(t *testing.T) {
+	repo := newMockTransactionRepository(
+		model.Transaction{Model: gorm.Model{ID: 1, CreatedAt: time.Now().Add(-48 * time.Hour)}, InvoiceID: "INV1", Status: "PENDING"},
+	)
+
+	expirySweeper := NewExpirySweeper(repo, payment.NewFakeProvider("token"), event.NewBus(nil), 24*time.Hour, time.Millisecond)
+	expirySweeper.Start()
+
+	assert.Eventually(t, func() bool {
+		return repo.status("INV1") == "EXPIRED"
+	}, time.Second, time.Millisecond)
+
+	expirySweeper.Stop()
+	expirySweeper.Stop()
+}
+
+type mockTransactionRepository struct {
+	mu           sync.Mutex
+	err          error
+	transactions map[string]*model.Transaction
+}
+
+func newMockTransactionRepository(transactions ...model.Transaction) *mockTransactionRepository {
+	repo := &mockTransactionRepository{transactions: map[string]*model.Transaction{}}
+	for i := range transactions {
+		repo.transactions[transactions[i].InvoiceID] = &transactions[i]
+	}
+	return repo
+}
+
+func (m *mockTransactionRepository) status(invId string) model.TransactionStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.transactions[invId].Status
+}
+
+func (m *mockTransactionRepository) GetPendingBefore(before time.Time) ([]model.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	transactions := []model.Transaction{}
+	for _, transaction := range m.transactions {
+		if transaction.Status == "PENDING" && transaction.CreatedAt.Before(before) {
+			transactions = append(transactions, *transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *mockTransactionRepository) ExpirePending(invId string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transaction, ok := m.transactions[invId]
+	if !ok || transaction.Status != "PENDING" {
+		return false, nil
+	}
+
+	transaction.Status = "EXPIRED"
+	return true, nil
+}
+
+func (m *mockTransactionRepository) GetRequestedBefore(before time.Time) ([]model.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	transactions := []model.Transaction{}
+	for _, transaction := range m.transactions {
+		if transaction.Status == "REQUESTED" && transaction.RespondBy.Before(before) {
+			transactions = append(transactions, *transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *mockTransactionRepository) GetCheckinBefore(before time.Time) ([]model.Transaction, error) {
+	return m.getBefore(model.PAID_STATUS, func(transaction *model.Transaction) bool {
+		return transaction.CheckinDate.Before(before)
+	})
+}
+
+func (m *mockTransactionRepository) GetCheckoutBefore(before time.Time) ([]model.Transaction, error) {
+	return m.getBefore(model.CHECKED_IN_STATUS, func(transaction *model.Transaction) bool {
+		return transaction.CheckoutDate.Before(before)
+	})
+}
+
+func (m *mockTransactionRepository) getBefore(status model.TransactionStatus, due func(*model.Transaction) bool) ([]model.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	transactions := []model.Transaction{}
+	for _, transaction := range m.transactions {
+		if transaction.Status == status && due(transaction) {
+			transactions = append(transactions, *transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *mockTransactionRepository) UpdateStatus(trxId int, from model.TransactionStatus, data model.Transaction, changedBy int, note string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, transaction := range m.transactions {
+		if int(transaction.ID) == trxId && transaction.Status == from {
+			transaction.Status = data.Status
+			return true, nil
+		}
+	}
+	return false, nil
+}